@@ -0,0 +1,347 @@
+package alert
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	alertReq "github.com/flipped-aurora/gin-vue-admin/server/model/alert/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AlertApi struct{}
+
+// CreateAlertRule 新建告警规则
+// @Tags Alert
+// @Summary 新建告警规则
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body alertModel.AlertRule true "告警规则"
+// @Success 200 {object} response.Response{msg=string} "创建成功"
+// @Router /alert/createAlertRule [post]
+func (alertApi *AlertApi) CreateAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var rule alertModel.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := alertService.CreateAlertRule(ctx, &rule); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("创建成功", c)
+}
+
+// UpdateAlertRule 更新告警规则
+// @Tags Alert
+// @Summary 更新告警规则
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body alertModel.AlertRule true "告警规则"
+// @Success 200 {object} response.Response{msg=string} "更新成功"
+// @Router /alert/updateAlertRule [put]
+func (alertApi *AlertApi) UpdateAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var rule alertModel.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := alertService.UpdateAlertRule(ctx, rule); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteAlertRule 删除告警规则
+// @Tags Alert
+// @Summary 删除告警规则，该规则未恢复的告警一并标记为已恢复
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "规则ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /alert/deleteAlertRule [delete]
+func (alertApi *AlertApi) DeleteAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := alertService.DeleteAlertRule(ctx, ID); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetAlertRuleList 分页获取告警规则
+// @Tags Alert
+// @Summary 分页获取告警规则
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query alertReq.AlertRuleSearch true "分页获取告警规则"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /alert/getAlertRuleList [get]
+func (alertApi *AlertApi) GetAlertRuleList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo alertReq.AlertRuleSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := alertService.GetAlertRuleList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// CreateAlertChannel 新建通知渠道
+// @Tags Alert
+// @Summary 新建通知渠道（email/webhook/dingtalk/wecom/feishu）
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body alertModel.AlertChannel true "通知渠道"
+// @Success 200 {object} response.Response{msg=string} "创建成功"
+// @Router /alert/createAlertChannel [post]
+func (alertApi *AlertApi) CreateAlertChannel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var ch alertModel.AlertChannel
+	if err := c.ShouldBindJSON(&ch); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := alertService.CreateAlertChannel(ctx, &ch); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("创建成功", c)
+}
+
+// UpdateAlertChannel 更新通知渠道
+// @Tags Alert
+// @Summary 更新通知渠道
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body alertModel.AlertChannel true "通知渠道"
+// @Success 200 {object} response.Response{msg=string} "更新成功"
+// @Router /alert/updateAlertChannel [put]
+func (alertApi *AlertApi) UpdateAlertChannel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var ch alertModel.AlertChannel
+	if err := c.ShouldBindJSON(&ch); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := alertService.UpdateAlertChannel(ctx, ch); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteAlertChannel 删除通知渠道
+// @Tags Alert
+// @Summary 删除通知渠道，仍被告警规则引用时不允许删除
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "渠道ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /alert/deleteAlertChannel [delete]
+func (alertApi *AlertApi) DeleteAlertChannel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := alertService.DeleteAlertChannel(ctx, ID); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// TestAlertChannel 发送测试通知
+// @Tags Alert
+// @Summary 向通知渠道发送一条测试消息
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "渠道ID"
+// @Success 200 {object} response.Response{msg=string} "发送成功"
+// @Router /alert/testAlertChannel [post]
+func (alertApi *AlertApi) TestAlertChannel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := alertService.TestAlertChannel(ctx, ID); err != nil {
+		global.GVA_LOG.Error("发送测试通知失败!", zap.Error(err))
+		response.FailWithMessage("发送失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("发送成功", c)
+}
+
+// GetAlertChannelList 分页获取通知渠道
+// @Tags Alert
+// @Summary 分页获取通知渠道
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query alertReq.AlertChannelSearch true "分页获取通知渠道"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /alert/getAlertChannelList [get]
+func (alertApi *AlertApi) GetAlertChannelList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo alertReq.AlertChannelSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := alertService.GetAlertChannelList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// CreateAlertSilence 新建静默
+// @Tags Alert
+// @Summary 新建静默，规则、对象类型或对象ID为空表示匹配全部
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body alertModel.AlertSilence true "静默"
+// @Success 200 {object} response.Response{msg=string} "创建成功"
+// @Router /alert/createAlertSilence [post]
+func (alertApi *AlertApi) CreateAlertSilence(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var s alertModel.AlertSilence
+	if err := c.ShouldBindJSON(&s); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	s.CreatedBy = utils.GetUserID(c)
+	if err := alertService.CreateAlertSilence(ctx, &s); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("创建成功", c)
+}
+
+// DeleteAlertSilence 删除静默
+// @Tags Alert
+// @Summary 删除静默
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "静默ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /alert/deleteAlertSilence [delete]
+func (alertApi *AlertApi) DeleteAlertSilence(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := alertService.DeleteAlertSilence(ctx, ID); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetAlertSilenceList 分页获取静默
+// @Tags Alert
+// @Summary 分页获取静默
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query alertReq.AlertSilenceSearch true "分页获取静默"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /alert/getAlertSilenceList [get]
+func (alertApi *AlertApi) GetAlertSilenceList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo alertReq.AlertSilenceSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := alertService.GetAlertSilenceList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetAlertEventList 分页获取告警历史
+// @Tags Alert
+// @Summary 分页获取告警历史
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query alertReq.AlertEventSearch true "分页获取告警历史"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /alert/getAlertEventList [get]
+func (alertApi *AlertApi) GetAlertEventList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo alertReq.AlertEventSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := alertService.GetAlertEventList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
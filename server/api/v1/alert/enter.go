@@ -0,0 +1,7 @@
+package alert
+
+import "github.com/flipped-aurora/gin-vue-admin/server/service"
+
+type ApiGroup struct{ AlertApi }
+
+var alertService = service.ServiceGroupApp.AlertServiceGroup.AlertService
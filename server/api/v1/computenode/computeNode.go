@@ -188,3 +188,238 @@ func (computeNodeApi *ComputeNodeApi) GetComputeNodePublic(c *gin.Context) {
        "info": "不需要鉴权的算力节点接口信息",
     }, "获取成功", c)
 }
+
+// PrePullImage 预拉取镜像到指定节点
+// @Tags ComputeNode
+// @Summary 预拉取镜像到指定节点
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.PrePullImageReq true "镜像ID与节点ID列表"
+// @Success 200 {object} response.Response{msg=string} "预拉取任务已提交"
+// @Router /computeNode/prePullImage [post]
+func (computeNodeApi *ComputeNodeApi) PrePullImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.PrePullImageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := computeNodeService.PrePullImage(ctx, req); err != nil {
+		global.GVA_LOG.Error("提交预拉取任务失败!", zap.Error(err))
+		response.FailWithMessage("提交预拉取任务失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("预拉取任务已提交", c)
+}
+
+// GetNodeImageList 分页获取节点镜像缓存清单
+// @Tags ComputeNode
+// @Summary 分页获取节点镜像缓存清单
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query computenodeReq.NodeImageSearch true "分页获取节点镜像缓存清单"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /computeNode/getNodeImageList [get]
+func (computeNodeApi *ComputeNodeApi) GetNodeImageList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo computenodeReq.NodeImageSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := computeNodeService.GetNodeImageList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// CordonNode 隔离节点，不再接受新实例
+// @Tags ComputeNode
+// @Summary 隔离节点，不再接受新实例，已有实例不受影响
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.NodeMaintenanceReq true "节点ID"
+// @Success 200 {object} response.Response{msg=string} "隔离成功"
+// @Router /computeNode/cordonNode [post]
+func (computeNodeApi *ComputeNodeApi) CordonNode(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.NodeMaintenanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := computeNodeService.CordonNode(ctx, req.ID); err != nil {
+		global.GVA_LOG.Error("隔离节点失败!", zap.Error(err))
+		response.FailWithMessage("隔离节点失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("隔离成功", c)
+}
+
+// UncordonNode 解除节点隔离
+// @Tags ComputeNode
+// @Summary 解除节点隔离，同时取消未完成的排空
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.NodeMaintenanceReq true "节点ID"
+// @Success 200 {object} response.Response{msg=string} "已解除隔离"
+// @Router /computeNode/uncordonNode [post]
+func (computeNodeApi *ComputeNodeApi) UncordonNode(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.NodeMaintenanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := computeNodeService.UncordonNode(ctx, req.ID); err != nil {
+		global.GVA_LOG.Error("解除隔离失败!", zap.Error(err))
+		response.FailWithMessage("解除隔离失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已解除隔离", c)
+}
+
+// DrainNode 排空节点
+// @Tags ComputeNode
+// @Summary 排空节点：通知实例所有者，截止时间后停止或迁移节点上的实例
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.DrainNodeReq true "节点ID、排空方式与等待时间"
+// @Success 200 {object} response.Response{msg=string} "已开始排空"
+// @Router /computeNode/drainNode [post]
+func (computeNodeApi *ComputeNodeApi) DrainNode(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.DrainNodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := computeNodeService.DrainNode(ctx, req); err != nil {
+		global.GVA_LOG.Error("排空节点失败!", zap.Error(err))
+		response.FailWithMessage("排空节点失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已开始排空", c)
+}
+
+// GetNodeMaintenance 查询节点维护状态
+// @Tags ComputeNode
+// @Summary 查询节点维护状态与排空进度
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query computenodeReq.NodeMaintenanceReq true "节点ID"
+// @Success 200 {object} response.Response{data=object,msg=string} "获取成功"
+// @Router /computeNode/getNodeMaintenance [get]
+func (computeNodeApi *ComputeNodeApi) GetNodeMaintenance(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.NodeMaintenanceReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	res, err := computeNodeService.GetNodeMaintenance(ctx, req.ID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// GetNodeInventory 查询节点硬件清单
+// @Tags ComputeNode
+// @Summary 查询自动采集的节点硬件清单及与录入配置的差异
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query computenodeReq.NodeMaintenanceReq true "节点ID"
+// @Success 200 {object} response.Response{data=computenode.ComputeNodeInventory,msg=string} "获取成功"
+// @Router /computeNode/getNodeInventory [get]
+func (computeNodeApi *ComputeNodeApi) GetNodeInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.NodeMaintenanceReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	res, err := computeNodeService.GetNodeInventory(ctx, req.ID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// RefreshNodeInventory 立即采集节点硬件信息
+// @Tags ComputeNode
+// @Summary 立即通过 Docker 与 nvidia-smi 采集节点硬件信息
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.NodeMaintenanceReq true "节点ID"
+// @Success 200 {object} response.Response{data=computenode.ComputeNodeInventory,msg=string} "采集成功"
+// @Router /computeNode/refreshNodeInventory [post]
+func (computeNodeApi *ComputeNodeApi) RefreshNodeInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.NodeMaintenanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	res, err := computeNodeService.RefreshNodeInventory(ctx, req.ID)
+	if err != nil {
+		global.GVA_LOG.Error("采集硬件信息失败!", zap.Error(err))
+		response.FailWithMessage("采集硬件信息失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "采集成功", c)
+}
+
+// ReconcileNodes 对账节点孤儿容器与数据卷
+// @Tags ComputeNode
+// @Summary 比对节点上的受管容器、数据卷与实例记录；execute 为 false 时只演练
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body computenodeReq.ReconcileNodesReq true "节点ID与是否执行"
+// @Success 200 {object} response.Response{data=object,msg=string} "对账完成"
+// @Router /computeNode/reconcileNodes [post]
+func (computeNodeApi *ComputeNodeApi) ReconcileNodes(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req computenodeReq.ReconcileNodesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	res, err := computeNodeService.ReconcileNodes(ctx, req)
+	if err != nil {
+		global.GVA_LOG.Error("节点对账失败!", zap.Error(err))
+		response.FailWithMessage("节点对账失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "对账完成", c)
+}
@@ -1,12 +1,16 @@
 package v1
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/alert"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/idlepolicy"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/imageregistry"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/instance"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/quota"
 	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/api/v1/wallet"
 )
 
 var ApiGroupApp = new(ApiGroup)
@@ -18,4 +22,8 @@ type ApiGroup struct {
 	ComputenodeApiGroup   computenode.ApiGroup
 	ProductApiGroup       product.ApiGroup
 	InstanceApiGroup      instance.ApiGroup
+	WalletApiGroup        wallet.ApiGroup
+	QuotaApiGroup         quota.ApiGroup
+	IdlepolicyApiGroup    idlepolicy.ApiGroup
+	AlertApiGroup         alert.ApiGroup
 }
@@ -0,0 +1,7 @@
+package idlepolicy
+
+import "github.com/flipped-aurora/gin-vue-admin/server/service"
+
+type ApiGroup struct{ IdlePolicyApi }
+
+var idlePolicyService = service.ServiceGroupApp.IdlepolicyServiceGroup.IdlePolicyService
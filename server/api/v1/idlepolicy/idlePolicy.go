@@ -0,0 +1,120 @@
+package idlepolicy
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	idleModel "github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy"
+	idleReq "github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IdlePolicyApi struct{}
+
+// SetIdlePolicy 设置空闲停止策略
+// @Tags IdlePolicy
+// @Summary 设置产品规格或角色的空闲停止策略
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body idleModel.IdlePolicy true "空闲停止策略"
+// @Success 200 {object} response.Response{msg=string} "设置成功"
+// @Router /idlePolicy/setIdlePolicy [post]
+func (idlePolicyApi *IdlePolicyApi) SetIdlePolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var p idleModel.IdlePolicy
+	if err := c.ShouldBindJSON(&p); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := idlePolicyService.SetIdlePolicy(ctx, &p); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
+
+// DeleteIdlePolicy 删除空闲停止策略
+// @Tags IdlePolicy
+// @Summary 删除空闲停止策略
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "策略ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /idlePolicy/deleteIdlePolicy [delete]
+func (idlePolicyApi *IdlePolicyApi) DeleteIdlePolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := idlePolicyService.DeleteIdlePolicy(ctx, ID); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetIdlePolicyList 分页获取空闲停止策略
+// @Tags IdlePolicy
+// @Summary 分页获取空闲停止策略
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query idleReq.IdlePolicySearch true "分页获取空闲停止策略"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /idlePolicy/getIdlePolicyList [get]
+func (idlePolicyApi *IdlePolicyApi) GetIdlePolicyList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo idleReq.IdlePolicySearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := idlePolicyService.GetIdlePolicyList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetIdleStopRecordList 分页获取空闲停止审计记录
+// @Tags IdlePolicy
+// @Summary 分页获取空闲提醒、自动停止与豁免变更记录
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query idleReq.IdleStopRecordSearch true "分页获取空闲停止审计记录"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /idlePolicy/getIdleStopRecordList [get]
+func (idlePolicyApi *IdlePolicyApi) GetIdleStopRecordList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo idleReq.IdleStopRecordSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := idlePolicyService.GetIdleStopRecordList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
@@ -1,6 +1,12 @@
 package instance
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
@@ -15,12 +21,12 @@ type InstanceApi struct{}
 
 // CreateInstance 创建实例管理
 // @Tags Instance
-// @Summary 创建实例管理
+// @Summary 创建实例管理（未指定nodeId时按调度打分自动选择节点，可传region限定区域）
 // @Security ApiKeyAuth
 // @Accept application/json
 // @Produce application/json
 // @Param data body instanceModel.Instance true "创建实例管理"
-// @Success 200 {object} response.Response{msg=string} "创建成功"
+// @Success 200 {object} response.Response{data=instanceModel.Instance,msg=string} "创建任务已提交"
 // @Router /instance/createInstance [post]
 func (instanceApi *InstanceApi) CreateInstance(c *gin.Context) {
 	// 创建业务用Context
@@ -46,7 +52,8 @@ func (instanceApi *InstanceApi) CreateInstance(c *gin.Context) {
 		response.FailWithMessage("创建失败:"+err.Error(), c)
 		return
 	}
-	response.OkWithMessage("创建成功", c)
+	// 容器在后台创建，前端根据返回的实例ID轮询 getProvisionProgress
+	response.OkWithDetailed(inst, "创建任务已提交", c)
 }
 
 // DeleteInstance 删除实例管理
@@ -219,7 +226,8 @@ func (instanceApi *InstanceApi) GetInstanceDataSource(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// 此接口为获取数据源定义的数据
-	dataSource, err := instanceService.GetInstanceDataSource(ctx)
+	// 该接口无需鉴权，携带token时返回当前用户的私有镜像
+	dataSource, err := instanceService.GetInstanceDataSource(ctx, utils.GetUserID(c))
 	if err != nil {
 		global.GVA_LOG.Error("查询失败!", zap.Error(err))
 		response.FailWithMessage("查询失败:"+err.Error(), c)
@@ -254,6 +262,7 @@ func (instanceApi *InstanceApi) GetInstancePublic(c *gin.Context) {
 // @Accept application/json
 // @Produce application/json
 // @Param specId query int true "产品规格ID"
+// @Param imageId query int false "镜像ID，已缓存该镜像的节点优先"
 // @Success 200 {object} response.Response{data=object,msg=string} "查询成功"
 // @Router /instance/getAvailableNodes [get]
 func (instanceApi *InstanceApi) GetAvailableNodes(c *gin.Context) {
@@ -269,6 +278,10 @@ func (instanceApi *InstanceApi) GetAvailableNodes(c *gin.Context) {
 		Region:   c.Query("region"),
 		ISP:      c.Query("isp"),
 		UserHash: c.Query("userHash"),
+		UserId:   utils.GetUserID(c),
+	}
+	if imageId, parseErr := strconv.ParseUint(c.Query("imageId"), 10, 64); parseErr == nil {
+		meta.ImageId = uint(imageId)
 	}
 	nodes, err := instanceService.GetAvailableNodes(ctx, specId, meta)
 	if err != nil {
@@ -279,6 +292,470 @@ func (instanceApi *InstanceApi) GetAvailableNodes(c *gin.Context) {
 	response.OkWithData(nodes, c)
 }
 
+// GetProvisionProgress 查询实例创建进度
+// @Tags Instance
+// @Summary 查询实例创建进度
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "实例ID"
+// @Success 200 {object} response.Response{data=instanceServicePkg.ProvisionProgress,msg=string} "获取成功"
+// @Router /instance/getProvisionProgress [get]
+func (instanceApi *InstanceApi) GetProvisionProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+	ID := c.Query("ID")
+	if ID == "" {
+		response.FailWithMessage("实例ID不能为空", c)
+		return
+	}
+
+	userID := utils.GetUserID(c)
+	authorityId := utils.GetUserAuthorityId(c)
+	isAdmin := authorityId == 888
+
+	progress, err := instanceService.GetProvisionProgress(ctx, ID, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("查询创建进度失败!", zap.Error(err))
+		response.FailWithMessage("查询创建进度失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(progress, c)
+}
+
+// GetUsageReport 获取月度用量报表
+// @Tags Instance
+// @Summary 按用户或产品规格汇总月度运行时长与费用
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query instanceReq.UsageReportReq true "统计月份与汇总维度"
+// @Success 200 {object} response.Response{data=[]instanceServicePkg.UsageReportRow,msg=string} "获取成功"
+// @Router /instance/getUsageReport [get]
+func (instanceApi *InstanceApi) GetUsageReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.UsageReportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	rows, err := instanceService.GetUsageReport(ctx, req, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("获取用量报表失败!", zap.Error(err))
+		response.FailWithMessage("获取用量报表失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(rows, c)
+}
+
+// ExportUsageReport 导出月度用量报表
+// @Tags Instance
+// @Summary 导出月度用量报表Excel
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/octet-stream
+// @Param data query instanceReq.UsageReportReq true "统计月份与汇总维度"
+// @Success 200
+// @Router /instance/exportUsageReport [get]
+func (instanceApi *InstanceApi) ExportUsageReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.UsageReportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	file, name, err := instanceService.ExportUsageReport(ctx, req, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("导出用量报表失败!", zap.Error(err))
+		response.FailWithMessage("导出用量报表失败:"+err.Error(), c)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", url.PathEscape(name)))
+	c.Header("success", "true")
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", file.Bytes())
+}
+
+// SaveInstanceImage 实例保存为镜像
+// @Tags Instance
+// @Summary 将实例容器提交为新的私有镜像，可选推送到快照仓库
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body instanceReq.SaveInstanceImageReq true "实例ID、镜像名称、是否推送"
+// @Success 200 {object} response.Response{data=imageregistry.ImageRegistry,msg=string} "保存成功"
+// @Router /instance/saveInstanceImage [post]
+func (instanceApi *InstanceApi) SaveInstanceImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.SaveInstanceImageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	img, err := instanceService.SaveInstanceImage(ctx, req, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("保存镜像失败!", zap.Error(err))
+		response.FailWithMessage("保存镜像失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(img, "保存成功", c)
+}
+
+// ResizeInstance 实例变更规格
+// @Tags Instance
+// @Summary 将实例变更为另一产品规格，仅CPU/内存变化时在线生效，否则在原节点重建容器并保留数据卷
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body instanceReq.ResizeInstanceReq true "实例ID、目标规格ID"
+// @Success 200 {object} response.Response{data=instanceServicePkg.ResizeResult,msg=string} "变更成功"
+// @Router /instance/resizeInstance [post]
+func (instanceApi *InstanceApi) ResizeInstance(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.ResizeInstanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	res, err := instanceService.ResizeInstance(ctx, req, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("变更规格失败!", zap.Error(err))
+		response.FailWithMessage("变更规格失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "变更成功", c)
+}
+
+// SetIdleStopExempt 设置实例空闲自动停止豁免
+// @Tags Instance
+// @Summary 设置实例是否豁免空闲自动停止，策略不允许豁免时不生效
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body instanceReq.SetIdleStopExemptReq true "实例ID、是否豁免"
+// @Success 200 {object} response.Response{msg=string} "设置成功"
+// @Router /instance/setIdleStopExempt [post]
+func (instanceApi *InstanceApi) SetIdleStopExempt(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.SetIdleStopExemptReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	if err := instanceService.SetIdleStopExempt(ctx, req, userID, isAdmin); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
+
+// MigrateInstance 实例迁移
+// @Tags Instance
+// @Summary 将实例迁移到其他算力节点，数据卷随实例迁移，失败时回滚到原节点
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body instanceReq.MigrateInstanceReq true "实例ID、目标节点ID"
+// @Success 200 {object} response.Response{data=instanceServicePkg.MigrateResult,msg=string} "迁移成功"
+// @Router /instance/migrateInstance [post]
+func (instanceApi *InstanceApi) MigrateInstance(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.MigrateInstanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可迁移实例", c)
+		return
+	}
+
+	res, err := instanceService.MigrateInstance(ctx, req)
+	if err != nil {
+		global.GVA_LOG.Error("迁移实例失败!", zap.Error(err))
+		response.FailWithMessage("迁移实例失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "迁移成功", c)
+}
+
+// SimulateSchedule 调度模拟
+// @Tags Instance
+// @Summary 按当前节点与实例状态重放一批假设的创建请求，返回落位、失败与每卡利用率，不写库
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body instanceReq.SimulateScheduleReq true "规格×数量、区域、可选策略版本"
+// @Success 200 {object} response.Response{data=instanceServicePkg.SimulationResult,msg=string} "模拟成功"
+// @Router /instance/simulateSchedule [post]
+func (instanceApi *InstanceApi) SimulateSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.SimulateScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可执行调度模拟", c)
+		return
+	}
+
+	res, err := instanceService.SimulateSchedule(ctx, req)
+	if err != nil {
+		global.GVA_LOG.Error("调度模拟失败!", zap.Error(err))
+		response.FailWithMessage("调度模拟失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "模拟成功", c)
+}
+
+// GetSchedulerBreaker 查询调度熔断状态
+// @Tags Instance
+// @Summary 查询调度熔断状态：closed/open/half_open、滑动窗口内请求数、失败率、平均延迟与半开探测进度
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} response.Response{data=instanceServicePkg.CircuitBreakerStatus,msg=string} "获取成功"
+// @Router /instance/getSchedulerBreaker [get]
+func (instanceApi *InstanceApi) GetSchedulerBreaker(c *gin.Context) {
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可查看调度熔断状态", c)
+		return
+	}
+	response.OkWithData(instanceService.GetCircuitBreakerStatus(), c)
+}
+
+// GetSchedulingLogList 分页查询调度决策记录
+// @Tags Instance
+// @Summary 分页查询调度决策记录：请求信息、策略版本与命中规则、候选节点得分、选中节点与耗时
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query instanceReq.SchedulingLogSearch true "按时间、实例、用户、节点、来源、策略版本、命中规则过滤"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /instance/getSchedulingLogList [get]
+func (instanceApi *InstanceApi) GetSchedulingLogList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo instanceReq.SchedulingLogSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可查看调度决策记录", c)
+		return
+	}
+
+	list, total, err := instanceService.GetSchedulingLogList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetInstanceMetrics 查询实例监控历史
+// @Tags Instance
+// @Summary 查询实例CPU、内存、GPU显存使用率历史，按区间长度自动选择原始/5分钟/1小时精度
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query instanceReq.MetricsQueryReq true "实例ID、时间区间与精度"
+// @Success 200 {object} response.Response{data=instanceServicePkg.MetricSeries,msg=string} "获取成功"
+// @Router /instance/getInstanceMetrics [get]
+func (instanceApi *InstanceApi) GetInstanceMetrics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.MetricsQueryReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	res, err := instanceService.GetInstanceMetrics(ctx, req, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("获取监控历史失败!", zap.Error(err))
+		response.FailWithMessage("获取监控历史失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// GetNodeMetrics 查询节点监控历史
+// @Tags Instance
+// @Summary 查询节点上运行实例的平均使用率历史
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query instanceReq.MetricsQueryReq true "节点ID、时间区间与精度"
+// @Success 200 {object} response.Response{data=instanceServicePkg.MetricSeries,msg=string} "获取成功"
+// @Router /instance/getNodeMetrics [get]
+func (instanceApi *InstanceApi) GetNodeMetrics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.MetricsQueryReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可查看节点监控历史", c)
+		return
+	}
+
+	res, err := instanceService.GetNodeMetrics(ctx, req)
+	if err != nil {
+		global.GVA_LOG.Error("获取监控历史失败!", zap.Error(err))
+		response.FailWithMessage("获取监控历史失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// GetNodeGpuTelemetry 查询节点显卡最新遥测
+// @Tags Instance
+// @Summary 查询节点各卡的利用率、显存、温度、功耗、ECC与计算进程
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "节点ID"
+// @Success 200 {object} response.Response{data=[]computenode.NodeGpuTelemetry,msg=string} "获取成功"
+// @Router /instance/getNodeGpuTelemetry [get]
+func (instanceApi *InstanceApi) GetNodeGpuTelemetry(c *gin.Context) {
+	ctx := c.Request.Context()
+	ID := c.Query("ID")
+	if ID == "" {
+		response.FailWithMessage("节点ID不能为空", c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可查看节点显卡遥测", c)
+		return
+	}
+
+	res, err := instanceService.GetNodeGpuTelemetry(ctx, ID)
+	if err != nil {
+		global.GVA_LOG.Error("获取显卡遥测失败!", zap.Error(err))
+		response.FailWithMessage("获取显卡遥测失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// GetNodeGpuHistory 查询节点显卡遥测历史
+// @Tags Instance
+// @Summary 查询节点显卡遥测历史（每分钟一个采样点，保留7天）
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query instanceReq.GpuHistoryReq true "节点ID、时间区间与显卡序号"
+// @Success 200 {object} response.Response{data=[]computenode.NodeGpuSample,msg=string} "获取成功"
+// @Router /instance/getNodeGpuHistory [get]
+func (instanceApi *InstanceApi) GetNodeGpuHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req instanceReq.GpuHistoryReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可查看节点显卡遥测", c)
+		return
+	}
+
+	res, err := instanceService.GetNodeGpuHistory(ctx, req)
+	if err != nil {
+		global.GVA_LOG.Error("获取显卡遥测历史失败!", zap.Error(err))
+		response.FailWithMessage("获取显卡遥测历史失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// GetInstanceGpuTelemetry 查询实例显卡遥测
+// @Tags Instance
+// @Summary 查询实例所用显卡的最新遥测，只包含该实例的进程
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "实例ID"
+// @Success 200 {object} response.Response{data=[]computenode.NodeGpuTelemetry,msg=string} "获取成功"
+// @Router /instance/getInstanceGpuTelemetry [get]
+func (instanceApi *InstanceApi) GetInstanceGpuTelemetry(c *gin.Context) {
+	ctx := c.Request.Context()
+	ID := c.Query("ID")
+	if ID == "" {
+		response.FailWithMessage("实例ID不能为空", c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+
+	res, err := instanceService.GetInstanceGpuTelemetry(ctx, ID, userID, isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("获取显卡遥测失败!", zap.Error(err))
+		response.FailWithMessage("获取显卡遥测失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(res, c)
+}
+
+// PrometheusMetrics Prometheus 指标
+// @Tags Instance
+// @Summary 以 Prometheus 文本格式导出节点、实例与调度熔断指标
+// @Produce plain
+// @Success 200 {string} string "Prometheus 文本格式指标"
+// @Router /metrics [get]
+func (instanceApi *InstanceApi) PrometheusMetrics(c *gin.Context) {
+	cfg := global.GVA_CONFIG.Metrics
+	if !cfg.Enabled || cfg.BearerToken == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+cfg.BearerToken)) != 1 {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+	body, err := instanceService.FleetMetrics(c.Request.Context())
+	if err != nil {
+		global.GVA_LOG.Error("生成Prometheus指标失败!", zap.Error(err))
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", body)
+}
+
 // StartContainer 启动容器
 // @Tags Instance
 // @Summary 启动容器
@@ -0,0 +1,10 @@
+package quota
+
+import "github.com/flipped-aurora/gin-vue-admin/server/service"
+
+type ApiGroup struct{ ResourceQuotaApi }
+
+var (
+	resourceQuotaService = service.ServiceGroupApp.QuotaServiceGroup.ResourceQuotaService
+	instanceService      = service.ServiceGroupApp.InstanceServiceGroup.InstanceService
+)
@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"strconv"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	quotaModel "github.com/flipped-aurora/gin-vue-admin/server/model/quota"
+	quotaReq "github.com/flipped-aurora/gin-vue-admin/server/model/quota/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ResourceQuotaApi struct{}
+
+// SetResourceQuota 设置资源配额
+// @Tags ResourceQuota
+// @Summary 设置用户或角色的资源配额
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body quotaModel.ResourceQuota true "资源配额"
+// @Success 200 {object} response.Response{msg=string} "设置成功"
+// @Router /quota/setResourceQuota [post]
+func (resourceQuotaApi *ResourceQuotaApi) SetResourceQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var q quotaModel.ResourceQuota
+	if err := c.ShouldBindJSON(&q); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := resourceQuotaService.SetResourceQuota(ctx, &q); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
+
+// DeleteResourceQuota 删除资源配额
+// @Tags ResourceQuota
+// @Summary 删除资源配额
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "配额ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /quota/deleteResourceQuota [delete]
+func (resourceQuotaApi *ResourceQuotaApi) DeleteResourceQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ID := c.Query("ID")
+	if err := resourceQuotaService.DeleteResourceQuota(ctx, ID); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetResourceQuotaList 分页获取资源配额
+// @Tags ResourceQuota
+// @Summary 分页获取资源配额
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query quotaReq.ResourceQuotaSearch true "分页获取资源配额"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /quota/getResourceQuotaList [get]
+func (resourceQuotaApi *ResourceQuotaApi) GetResourceQuotaList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo quotaReq.ResourceQuotaSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := resourceQuotaService.GetResourceQuotaList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetQuotaUsage 获取配额与当前用量
+// @Tags ResourceQuota
+// @Summary 获取生效配额与当前用量，管理员可指定用户
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param userId query int false "用户ID，仅管理员有效"
+// @Success 200 {object} response.Response{data=instance.QuotaOverview,msg=string} "获取成功"
+// @Router /quota/getQuotaUsage [get]
+func (resourceQuotaApi *ResourceQuotaApi) GetQuotaUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := utils.GetUserID(c)
+	if utils.GetUserAuthorityId(c) == 888 {
+		if id, err := strconv.ParseUint(c.Query("userId"), 10, 64); err == nil {
+			userID = uint(id)
+		}
+	}
+	overview, err := instanceService.GetQuotaUsage(ctx, userID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(overview, c)
+}
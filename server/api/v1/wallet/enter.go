@@ -0,0 +1,7 @@
+package wallet
+
+import "github.com/flipped-aurora/gin-vue-admin/server/service"
+
+type ApiGroup struct{ WalletApi }
+
+var walletService = service.ServiceGroupApp.WalletServiceGroup.WalletService
@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"strconv"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	walletReq "github.com/flipped-aurora/gin-vue-admin/server/model/wallet/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WalletApi struct{}
+
+// Recharge 为用户充值
+// @Tags Wallet
+// @Summary 为用户充值
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body walletReq.WalletChangeReq true "用户ID、金额、备注"
+// @Success 200 {object} response.Response{data=wallet.Wallet,msg=string} "充值成功"
+// @Router /wallet/recharge [post]
+func (walletApi *WalletApi) Recharge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可以充值", c)
+		return
+	}
+	var req walletReq.WalletChangeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	w, err := walletService.Recharge(ctx, req, utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("充值失败!", zap.Error(err))
+		response.FailWithMessage("充值失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(w, "充值成功", c)
+}
+
+// Deduct 手工扣减用户余额
+// @Tags Wallet
+// @Summary 手工扣减用户余额
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body walletReq.WalletChangeReq true "用户ID、金额、备注"
+// @Success 200 {object} response.Response{data=wallet.Wallet,msg=string} "扣减成功"
+// @Router /wallet/deduct [post]
+func (walletApi *WalletApi) Deduct(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if utils.GetUserAuthorityId(c) != 888 {
+		response.FailWithMessage("仅管理员可以扣减余额", c)
+		return
+	}
+	var req walletReq.WalletChangeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	w, err := walletService.Deduct(ctx, req, utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("扣减失败!", zap.Error(err))
+		response.FailWithMessage("扣减失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(w, "扣减成功", c)
+}
+
+// GetWallet 获取钱包余额
+// @Tags Wallet
+// @Summary 获取钱包余额，管理员可指定用户
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param userId query int false "用户ID，仅管理员有效"
+// @Success 200 {object} response.Response{data=wallet.Wallet,msg=string} "获取成功"
+// @Router /wallet/getWallet [get]
+func (walletApi *WalletApi) GetWallet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := utils.GetUserID(c)
+	if utils.GetUserAuthorityId(c) == 888 {
+		if id, err := strconv.ParseUint(c.Query("userId"), 10, 64); err == nil {
+			userID = uint(id)
+		}
+	}
+	w, err := walletService.GetWallet(ctx, userID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(w, c)
+}
+
+// GetWalletTransactionList 分页获取钱包流水
+// @Tags Wallet
+// @Summary 分页获取钱包流水
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query walletReq.WalletTransactionSearch true "分页获取钱包流水"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /wallet/getWalletTransactionList [get]
+func (walletApi *WalletApi) GetWalletTransactionList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pageInfo walletReq.WalletTransactionSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	isAdmin := utils.GetUserAuthorityId(c) == 888
+	list, total, err := walletService.GetWalletTransactionList(ctx, pageInfo, utils.GetUserID(c), isAdmin)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,9 @@
+package config
+
+// Billing 计费与余额配置
+type Billing struct {
+	Enabled           bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                                     // 是否启用余额校验与扣费
+	MinBalanceHours   int  `mapstructure:"min-balance-hours" json:"min-balance-hours" yaml:"min-balance-hours"`       // 创建/启动实例时余额至少可支付的小时数
+	DeductIntervalMin int  `mapstructure:"deduct-interval-min" json:"deduct-interval-min" yaml:"deduct-interval-min"` // 扣费周期(分钟)
+	GracePeriodMin    int  `mapstructure:"grace-period-min" json:"grace-period-min" yaml:"grace-period-min"`          // 欠费后停止实例前的宽限期(分钟)
+}
@@ -46,4 +46,22 @@ type Server struct {
 
 	// PCDN调度配置
 	PCDN PCDN `mapstructure:"pcdn" json:"pcdn" yaml:"pcdn"`
+
+	// 实例异步创建配置
+	Provision Provision `mapstructure:"provision" json:"provision" yaml:"provision"`
+
+	// 镜像仓库配置
+	Registry Registry `mapstructure:"registry" json:"registry" yaml:"registry"`
+
+	// 计费与余额配置
+	Billing Billing `mapstructure:"billing" json:"billing" yaml:"billing"`
+
+	// 算力节点硬件发现配置
+	Discovery Discovery `mapstructure:"discovery" json:"discovery" yaml:"discovery"`
+
+	// 节点孤儿容器/数据卷对账配置
+	Reconcile Reconcile `mapstructure:"reconcile" json:"reconcile" yaml:"reconcile"`
+
+	// Prometheus 指标接口配置
+	Metrics Metrics `mapstructure:"metrics" json:"metrics" yaml:"metrics"`
 }
@@ -0,0 +1,9 @@
+package config
+
+// Discovery 算力节点硬件发现配置
+type Discovery struct {
+	Enabled       bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // Docker连接正常时是否采集节点硬件信息
+	GpuProbeImage string `mapstructure:"gpu-probe-image" json:"gpu-probe-image" yaml:"gpu-probe-image"` // 运行 nvidia-smi 的辅助容器镜像
+	IntervalMin   int    `mapstructure:"interval-min" json:"interval-min" yaml:"interval-min"`          // 同一节点两次采集的最小间隔(分钟)
+	GpuTelemetry  bool   `mapstructure:"gpu-telemetry" json:"gpu-telemetry" yaml:"gpu-telemetry"`       // 巡检时是否按节点采集显卡遥测（替代逐容器 exec nvidia-smi）
+}
@@ -0,0 +1,7 @@
+package config
+
+// Metrics Prometheus 指标接口配置
+type Metrics struct {
+	Enabled     bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                // 是否开放 /metrics
+	BearerToken string `mapstructure:"bearer-token" json:"bearer-token" yaml:"bearer-token"` // 抓取需携带 Authorization: Bearer <token>，为空时不开放接口
+}
@@ -20,6 +20,8 @@ type PCDN struct {
 	CircuitBreaker CircuitBreak `mapstructure:"circuit-breaker" json:"circuit-breaker" yaml:"circuit-breaker"`
 	ScoreWeight    ScoreWeight  `mapstructure:"score-weight" json:"score-weight" yaml:"score-weight"`
 	StableVersion  string       `mapstructure:"stable-version" json:"stable-version" yaml:"stable-version"`
+	// 策略版本到调度算法(spread/binpack/fragmentation)的映射，未配置时版本名即算法名，否则使用 spread
+	Strategies map[string]string `mapstructure:"strategies" json:"strategies" yaml:"strategies"`
 }
 
 type Canary struct {
@@ -32,11 +34,19 @@ type CircuitBreak struct {
 	FailureRateThreshold float64 `mapstructure:"failure-rate-threshold" json:"failure-rate-threshold" yaml:"failure-rate-threshold"`
 	LatencyThresholdMs   int64   `mapstructure:"latency-threshold-ms" json:"latency-threshold-ms" yaml:"latency-threshold-ms"`
 	MinSamples           int     `mapstructure:"min-samples" json:"min-samples" yaml:"min-samples"`
+	// 滑动窗口：统计最近 WindowSeconds 秒内的请求，按 BucketCount 个时间桶滚动淘汰
+	WindowSeconds int `mapstructure:"window-seconds" json:"window-seconds" yaml:"window-seconds"`
+	BucketCount   int `mapstructure:"bucket-count" json:"bucket-count" yaml:"bucket-count"`
+	// 熔断打开 OpenSeconds 秒后进入半开，按 HalfOpenPercentage% 放行探测，连续 HalfOpenSuccesses 次成功后关闭
+	OpenSeconds        int `mapstructure:"open-seconds" json:"open-seconds" yaml:"open-seconds"`
+	HalfOpenPercentage int `mapstructure:"half-open-percentage" json:"half-open-percentage" yaml:"half-open-percentage"`
+	HalfOpenSuccesses  int `mapstructure:"half-open-successes" json:"half-open-successes" yaml:"half-open-successes"`
 }
 
 type ScoreWeight struct {
-	Gpu    float64 `mapstructure:"gpu" json:"gpu" yaml:"gpu"`
-	CPU    float64 `mapstructure:"cpu" json:"cpu" yaml:"cpu"`
-	Memory float64 `mapstructure:"memory" json:"memory" yaml:"memory"`
-	Disk   float64 `mapstructure:"disk" json:"disk" yaml:"disk"`
+	Gpu    float64  `mapstructure:"gpu" json:"gpu" yaml:"gpu"`
+	CPU    float64  `mapstructure:"cpu" json:"cpu" yaml:"cpu"`
+	Memory float64  `mapstructure:"memory" json:"memory" yaml:"memory"`
+	Disk   float64  `mapstructure:"disk" json:"disk" yaml:"disk"`
+	Image  *float64 `mapstructure:"image" json:"image" yaml:"image"` // 节点已缓存所需镜像时的加分权重，未配置时为0.2，配置为0关闭
 }
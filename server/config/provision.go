@@ -0,0 +1,9 @@
+package config
+
+// Provision 实例异步创建配置
+type Provision struct {
+	Workers          int `mapstructure:"workers" json:"workers" yaml:"workers"`                                  // 后台创建worker数量
+	MaxRetries       int `mapstructure:"max-retries" json:"max-retries" yaml:"max-retries"`                      // 单步骤遇到临时性Docker错误时的最大重试次数
+	RetryIntervalSec int `mapstructure:"retry-interval-sec" json:"retry-interval-sec" yaml:"retry-interval-sec"` // 重试间隔(秒)，按重试次数线性递增
+	PullTimeoutMin   int `mapstructure:"pull-timeout-min" json:"pull-timeout-min" yaml:"pull-timeout-min"`       // 拉取镜像超时(分钟)
+}
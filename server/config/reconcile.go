@@ -0,0 +1,8 @@
+package config
+
+// Reconcile 节点容器/数据卷与实例记录对账配置
+type Reconcile struct {
+	Enabled      bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                   // 是否启用定时对账
+	AutoClean    bool `mapstructure:"auto-clean" json:"auto-clean" yaml:"auto-clean"`          // 定时对账是否自动清理/接管，关闭时只记录报告
+	GraceMinutes int  `mapstructure:"grace-minutes" json:"grace-minutes" yaml:"grace-minutes"` // 新建不足该时长的容器与数据卷不视为孤儿(分钟)
+}
@@ -0,0 +1,13 @@
+package config
+
+// Registry 镜像仓库配置
+type Registry struct {
+	// EncryptionKey 用于加密镜像库凭据的密钥
+	// 未配置时不能保存或使用带密码、令牌的镜像库
+	EncryptionKey string `mapstructure:"encryption-key" json:"encryption-key" yaml:"encryption-key"`
+
+	// 实例保存为镜像时推送的目标仓库前缀，如 harbor.example.com/snapshots；为空则只保存在实例所在节点
+	SnapshotRepository string `mapstructure:"snapshot-repository" json:"snapshot-repository" yaml:"snapshot-repository"`
+	SnapshotUsername   string `mapstructure:"snapshot-username" json:"snapshot-username" yaml:"snapshot-username"`
+	SnapshotPassword   string `mapstructure:"snapshot-password" json:"snapshot-password" yaml:"snapshot-password"`
+}
@@ -2,11 +2,15 @@ package initialize
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/alert"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/instance"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/pcdn"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/quota"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/wallet"
 )
 
 func bizModel() error {
@@ -14,8 +18,28 @@ func bizModel() error {
 	err := db.AutoMigrate(
 		imageregistry.ImageRegistry{},
 		computenode.ComputeNode{},
+		computenode.ComputeNodeImage{},
+		computenode.ComputeNodeInventory{},
+		computenode.NodeGpuTelemetry{},
+		computenode.NodeGpuSample{},
 		product.ProductSpec{},
 		instance.Instance{},
+		instance.GpuAllocation{},
+		instance.ProvisionEvent{},
+		instance.UsageRecord{},
+		instance.SchedulingLog{},
+		instance.MetricSample{},
+		instance.MetricRollup5m{},
+		instance.MetricRollup1h{},
+		wallet.Wallet{},
+		wallet.WalletTransaction{},
+		quota.ResourceQuota{},
+		idlepolicy.IdlePolicy{},
+		idlepolicy.IdleStopRecord{},
+		alert.AlertRule{},
+		alert.AlertChannel{},
+		alert.AlertSilence{},
+		alert.AlertEvent{},
 		pcdn.PcdnNode{},
 		pcdn.PcdnResource{},
 		pcdn.PcdnPolicy{},
@@ -0,0 +1,10 @@
+package initialize
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+)
+
+// InitProvisionWorker 启动实例后台创建worker
+func InitProvisionWorker() {
+	instance.StartProvisionWorker()
+}
@@ -29,4 +29,20 @@ func initBizRouter(routers ...*gin.RouterGroup) {
 		instanceRouter := router.RouterGroupApp.Instance
 		instanceRouter.InitInstanceRouter(privateGroup, publicGroup)
 	}
+	{
+		walletRouter := router.RouterGroupApp.Wallet
+		walletRouter.InitWalletRouter(privateGroup, publicGroup)
+	}
+	{
+		quotaRouter := router.RouterGroupApp.Quota
+		quotaRouter.InitResourceQuotaRouter(privateGroup, publicGroup)
+	}
+	{
+		idlepolicyRouter := router.RouterGroupApp.Idlepolicy
+		idlepolicyRouter.InitIdlePolicyRouter(privateGroup, publicGroup)
+	}
+	{
+		alertRouter := router.RouterGroupApp.Alert
+		alertRouter.InitAlertRouter(privateGroup, publicGroup)
+	}
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	alertSvc "github.com/flipped-aurora/gin-vue-admin/server/service/alert"
 	computeNodeSvc "github.com/flipped-aurora/gin-vue-admin/server/service/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	walletSvc "github.com/flipped-aurora/gin-vue-admin/server/service/wallet"
 	"github.com/flipped-aurora/gin-vue-admin/server/task"
 
 	"github.com/gogf/gf/v2/os/gcron"
@@ -45,12 +47,56 @@ func Timer() {
 	_, err := gcron.AddSingleton(context.Background(), "*/30 * * * * *", func(ctx context.Context) {
 		// 先检查节点 Docker 状态，确保后续容器检查的依赖健康
 		computeNodeSvc.CheckAllNodeDockerStatus(ctx)
+		// 按节点采集显卡遥测，供容器指标刷新使用
+		instance.CollectAllNodeGpuTelemetry(ctx)
 		// 再检查容器状态与指标
 		instance.CheckAllContainerStatusAndMetrics(ctx)
+		// 最后按本轮巡检结果评估告警规则
+		alertSvc.EvaluateAlertRules(ctx)
 	}, "system-health-check")
 	if err != nil {
 		global.GVA_LOG.Error("启动合并定时任务失败", zap.Error(err))
 	} else {
 		global.GVA_LOG.Info("合并定时任务已启动，每30秒执行一次")
 	}
+
+	// 每分钟执行一次周期扣费与欠费处理
+	_, err = gcron.AddSingleton(context.Background(), "0 * * * * *", func(ctx context.Context) {
+		walletSvc.RunBillingCycle(ctx)
+	}, "billing-deduction")
+	if err != nil {
+		global.GVA_LOG.Error("启动扣费定时任务失败", zap.Error(err))
+	}
+
+	// 每分钟处理已到截止时间的节点排空
+	_, err = gcron.AddSingleton(context.Background(), "30 * * * * *", func(ctx context.Context) {
+		computeNodeSvc.ProcessNodeDrains(ctx)
+	}, "node-drain")
+	if err != nil {
+		global.GVA_LOG.Error("启动节点排空定时任务失败", zap.Error(err))
+	}
+
+	// 每5分钟汇总监控历史
+	_, err = gcron.AddSingleton(context.Background(), "0 */5 * * * *", func(ctx context.Context) {
+		instance.RollupMetrics(ctx)
+	}, "metrics-rollup")
+	if err != nil {
+		global.GVA_LOG.Error("启动监控汇总定时任务失败", zap.Error(err))
+	}
+
+	// 每分钟按空闲停止策略提醒并停止空闲GPU实例
+	_, err = gcron.AddSingleton(context.Background(), "15 * * * * *", func(ctx context.Context) {
+		instance.EnforceIdlePolicies(ctx)
+	}, "idle-stop")
+	if err != nil {
+		global.GVA_LOG.Error("启动空闲停止定时任务失败", zap.Error(err))
+	}
+
+	// 每10分钟对账节点上的受管容器、数据卷与实例记录
+	_, err = gcron.AddSingleton(context.Background(), "0 */10 * * * *", func(ctx context.Context) {
+		computeNodeSvc.ReconcileAllNodes(ctx)
+	}, "node-reconcile")
+	if err != nil {
+		global.GVA_LOG.Error("启动节点对账定时任务失败", zap.Error(err))
+	}
 }
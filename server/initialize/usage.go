@@ -0,0 +1,10 @@
+package initialize
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+)
+
+// InitUsageLedger 为已在运行的实例补写计量起始流水
+func InitUsageLedger() {
+	instance.BackfillUsageRecords()
+}
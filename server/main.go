@@ -46,7 +46,9 @@ func initializeSystem() {
 	initialize.DBList()
 	initialize.SetupHandlers() // 注册全局函数
 	if global.GVA_DB != nil {
-		initialize.RegisterTables() // 初始化表
-		initialize.InitJumpbox()    // 初始化SSH跳板机服务
+		initialize.RegisterTables()      // 初始化表
+		initialize.InitJumpbox()         // 初始化SSH跳板机服务
+		initialize.InitProvisionWorker() // 启动实例后台创建worker
+		initialize.InitUsageLedger()     // 补写计量起始流水
 	}
 }
@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 告警规则类型，除布尔类规则外均为“指标值大于阈值”时触发
+const (
+	RuleNodeDockerDown    = "node_docker_down"    // 节点 Docker 连接失败（布尔）
+	RuleNodeDiskUsage     = "node_disk_usage"     // 节点 Docker 数据目录所在磁盘使用率(%)
+	RuleGpuTemperature    = "gpu_temperature"     // 显卡温度(℃)
+	RuleGpuEccUncorrected = "gpu_ecc_uncorrected" // 显卡不可纠正ECC错误数
+	RuleInstanceExited    = "instance_exited"     // 实例容器意外退出（布尔，用户或系统主动停止不算）
+	RuleInstanceGpuMemory = "instance_gpu_memory" // 实例GPU显存使用率(%)
+	RuleInstanceCpu       = "instance_cpu"        // 实例CPU使用率(%)
+	RuleInstanceMemory    = "instance_memory"     // 实例内存使用率(%)
+)
+
+// 告警级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// 告警对象类型
+const (
+	TargetNode     = "node"
+	TargetGpu      = "gpu" // TargetId 为节点ID
+	TargetInstance = "instance"
+)
+
+// 告警状态
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// 通知渠道类型
+const (
+	ChannelEmail    = "email"    // 邮件（email 插件），Target 为逗号分隔的收件人
+	ChannelWebhook  = "webhook"  // 通用 Webhook，POST 告警 JSON
+	ChannelDingTalk = "dingtalk" // 钉钉机器人，Secret 为加签密钥
+	ChannelWeCom    = "wecom"    // 企业微信机器人
+	ChannelFeishu   = "feishu"   // 飞书机器人，Secret 为签名校验密钥
+)
+
+// AlertRule 告警规则
+// 每轮健康检查后评估一次，条件连续满足 ForCycles 轮才触发；同一对象未恢复前只保留一条告警，
+// RepeatMinutes 大于0时按该间隔重复通知。
+type AlertRule struct {
+	global.GVA_MODEL
+	Name           string  `json:"name" form:"name" gorm:"column:name;size:100;not null;comment:规则名称" binding:"required"`
+	Type           string  `json:"type" form:"type" gorm:"column:type;size:32;not null;comment:规则类型" binding:"required,oneof=node_docker_down node_disk_usage gpu_temperature gpu_ecc_uncorrected instance_exited instance_gpu_memory instance_cpu instance_memory"`
+	Threshold      float64 `json:"threshold" form:"threshold" gorm:"column:threshold;not null;default:0;comment:阈值，布尔类规则忽略"`
+	ForCycles      int     `json:"forCycles" form:"forCycles" gorm:"column:for_cycles;not null;default:1;comment:连续满足多少轮后触发" binding:"min=1"`
+	Severity       string  `json:"severity" form:"severity" gorm:"column:severity;size:16;not null;comment:级别 info/warning/critical" binding:"required,oneof=info warning critical"`
+	ChannelIds     []uint  `json:"channelIds" form:"-" gorm:"column:channel_ids;serializer:json;type:text;comment:通知渠道ID"`
+	RepeatMinutes  int     `json:"repeatMinutes" form:"repeatMinutes" gorm:"column:repeat_minutes;not null;default:0;comment:未恢复时重复通知间隔(分钟)，0为只通知一次" binding:"min=0"`
+	NotifyResolved bool    `json:"notifyResolved" form:"notifyResolved" gorm:"column:notify_resolved;not null;comment:恢复时是否通知"`
+	Enabled        bool    `json:"enabled" form:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	Remark         string  `json:"remark" form:"remark" gorm:"column:remark;size:500;comment:备注"`
+}
+
+// TableName 告警规则 AlertRule自定义表名 alert_rule
+func (AlertRule) TableName() string {
+	return "alert_rule"
+}
+
+// AlertChannel 告警通知渠道
+type AlertChannel struct {
+	global.GVA_MODEL
+	Name    string `json:"name" form:"name" gorm:"column:name;size:100;not null;comment:渠道名称" binding:"required"`
+	Type    string `json:"type" form:"type" gorm:"column:type;size:16;not null;comment:渠道类型 email/webhook/dingtalk/wecom/feishu" binding:"required,oneof=email webhook dingtalk wecom feishu"`
+	Target  string `json:"target" form:"target" gorm:"column:target;size:1000;not null;comment:收件人或Webhook地址" binding:"required"`
+	Secret  string `json:"secret" form:"secret" gorm:"column:secret;size:255;comment:机器人签名密钥"`
+	Enabled bool   `json:"enabled" form:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	Remark  string `json:"remark" form:"remark" gorm:"column:remark;size:500;comment:备注"`
+}
+
+// TableName 告警通知渠道 AlertChannel自定义表名 alert_channel
+func (AlertChannel) TableName() string {
+	return "alert_channel"
+}
+
+// AlertSilence 告警静默，时间窗口内匹配的告警照常记录但不发送通知
+// RuleId 为0匹配所有规则，TargetType 为空匹配所有对象类型，TargetId 为0匹配所有对象。
+type AlertSilence struct {
+	global.GVA_MODEL
+	RuleId     uint      `json:"ruleId" form:"ruleId" gorm:"column:rule_id;not null;default:0;comment:规则ID，0为全部"`
+	TargetType string    `json:"targetType" form:"targetType" gorm:"column:target_type;size:16;comment:对象类型，空为全部" binding:"omitempty,oneof=node gpu instance"`
+	TargetId   uint      `json:"targetId" form:"targetId" gorm:"column:target_id;not null;default:0;comment:对象ID，0为全部"`
+	StartsAt   time.Time `json:"startsAt" form:"startsAt" gorm:"column:starts_at;not null;comment:开始时间" binding:"required"`
+	EndsAt     time.Time `json:"endsAt" form:"endsAt" gorm:"column:ends_at;not null;index;comment:结束时间" binding:"required,gtfield=StartsAt"`
+	Comment    string    `json:"comment" form:"comment" gorm:"column:comment;size:500;comment:静默原因"`
+	CreatedBy  uint      `json:"createdBy" form:"-" gorm:"column:created_by;not null;default:0;comment:创建人ID"`
+}
+
+// TableName 告警静默 AlertSilence自定义表名 alert_silence
+func (AlertSilence) TableName() string {
+	return "alert_silence"
+}
+
+// AlertEvent 告警历史，同一指纹（规则+对象）未恢复前只有一条 firing 记录
+type AlertEvent struct {
+	global.GVA_MODEL
+	RuleId         uint       `json:"ruleId" form:"ruleId" gorm:"column:rule_id;not null;index;comment:规则ID"`
+	RuleName       string     `json:"ruleName" form:"ruleName" gorm:"column:rule_name;size:100;comment:规则名称快照"`
+	RuleType       string     `json:"ruleType" form:"ruleType" gorm:"column:rule_type;size:32;comment:规则类型"`
+	Severity       string     `json:"severity" form:"severity" gorm:"column:severity;size:16;comment:级别"`
+	Fingerprint    string     `json:"fingerprint" form:"fingerprint" gorm:"column:fingerprint;size:64;not null;index;comment:去重指纹"`
+	TargetType     string     `json:"targetType" form:"targetType" gorm:"column:target_type;size:16;comment:对象类型"`
+	TargetId       uint       `json:"targetId" form:"targetId" gorm:"column:target_id;comment:对象ID"`
+	TargetName     string     `json:"targetName" form:"targetName" gorm:"column:target_name;size:255;comment:对象名称"`
+	Status         string     `json:"status" form:"status" gorm:"column:status;size:16;not null;index;comment:状态 firing/resolved"`
+	Value          float64    `json:"value" form:"value" gorm:"column:value;comment:最近一次评估的指标值"`
+	Threshold      float64    `json:"threshold" form:"threshold" gorm:"column:threshold;comment:阈值"`
+	Message        string     `json:"message" form:"message" gorm:"column:message;size:1000;comment:告警内容"`
+	StartsAt       time.Time  `json:"startsAt" form:"startsAt" gorm:"column:starts_at;not null;index;comment:触发时间"`
+	EndsAt         *time.Time `json:"endsAt" form:"endsAt" gorm:"column:ends_at;comment:恢复时间"`
+	LastEvalAt     time.Time  `json:"lastEvalAt" form:"lastEvalAt" gorm:"column:last_eval_at;comment:最近一次评估时间"`
+	LastNotifiedAt *time.Time `json:"lastNotifiedAt" form:"lastNotifiedAt" gorm:"column:last_notified_at;comment:最近一次通知时间"`
+	NotifyCount    int        `json:"notifyCount" form:"notifyCount" gorm:"column:notify_count;not null;default:0;comment:通知次数"`
+	Silenced       bool       `json:"silenced" form:"silenced" gorm:"column:silenced;not null;comment:最近一次评估是否被静默"`
+	NotifyError    string     `json:"notifyError" form:"notifyError" gorm:"column:notify_error;size:1000;comment:最近一次通知失败原因"`
+}
+
+// TableName 告警历史 AlertEvent自定义表名 alert_event
+func (AlertEvent) TableName() string {
+	return "alert_event"
+}
@@ -0,0 +1,36 @@
+package request
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// AlertRuleSearch 告警规则查询条件
+type AlertRuleSearch struct {
+	Type *string `json:"type" form:"type"`
+	request.PageInfo
+}
+
+// AlertChannelSearch 通知渠道查询条件
+type AlertChannelSearch struct {
+	Type *string `json:"type" form:"type"`
+	request.PageInfo
+}
+
+// AlertSilenceSearch 告警静默查询条件
+type AlertSilenceSearch struct {
+	Active *bool `json:"active" form:"active"` // true 只返回当前生效的静默
+	request.PageInfo
+}
+
+// AlertEventSearch 告警历史查询条件
+type AlertEventSearch struct {
+	RuleId     *uint       `json:"ruleId" form:"ruleId"`
+	Status     *string     `json:"status" form:"status"`
+	Severity   *string     `json:"severity" form:"severity"`
+	TargetType *string     `json:"targetType" form:"targetType"`
+	TargetId   *uint       `json:"targetId" form:"targetId"`
+	TimeRange  []time.Time `json:"timeRange" form:"timeRange[]"` // 按触发时间过滤
+	request.PageInfo
+}
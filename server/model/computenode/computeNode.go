@@ -2,6 +2,8 @@
 package computenode
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
@@ -31,6 +33,20 @@ type ComputeNode struct {
 	Remark         *string `json:"remark" form:"remark" gorm:"comment:备注信息;column:remark;size:1000;"`                                          //备注
 	DockerStatus   *string `json:"dockerStatus" form:"dockerStatus" gorm:"comment:Docker连接状态;column:docker_status;size:50;default:'unknown';"` //Docker连接状态
 	HamiCore       *string `json:"hamiCore" form:"hamiCore" gorm:"comment:HAMi-core目录路径;column:hami_core;size:500;"`                           //HAMi-core目录路径
+	// 调度标签与污点：规格/镜像的节点选择器按标签匹配，未被容忍的污点会排除新实例
+	Labels map[string]string `json:"labels" form:"-" gorm:"serializer:json;type:text;column:labels;comment:节点标签"`
+	Taints []NodeTaint       `json:"taints" form:"-" gorm:"serializer:json;type:text;column:taints;comment:节点污点"`
+	// 维护状态与排空进度，只通过 cordon/drain/uncordon 接口修改
+	MaintenanceState string     `json:"maintenanceState" form:"-" gorm:"column:maintenance_state;size:16;default:'';comment:维护状态 cordoned/draining/drained"`
+	DrainAction      string     `json:"drainAction" form:"-" gorm:"column:drain_action;size:16;comment:排空方式 stop/migrate"`
+	DrainStartedAt   *time.Time `json:"drainStartedAt" form:"-" gorm:"column:drain_started_at;comment:开始排空时间"`
+	DrainDeadline    *time.Time `json:"drainDeadline" form:"-" gorm:"column:drain_deadline;comment:排空截止时间"`
+	DrainTotal       int        `json:"drainTotal" form:"-" gorm:"column:drain_total;not null;default:0;comment:开始排空时节点上的实例数"`
+	DrainMessage     string     `json:"drainMessage" form:"-" gorm:"column:drain_message;size:1000;comment:排空进度信息"`
+	// Docker 数据目录所在磁盘的使用情况，由健康检查定期采集
+	DiskTotalGb     *float64   `json:"diskTotalGb" form:"-" gorm:"column:disk_total_gb;comment:Docker数据目录所在磁盘容量(GB)"`
+	DiskUsedPercent *float64   `json:"diskUsedPercent" form:"-" gorm:"column:disk_used_percent;comment:Docker数据目录所在磁盘使用率(%)"`
+	DiskCheckedAt   *time.Time `json:"diskCheckedAt" form:"-" gorm:"column:disk_checked_at;comment:磁盘使用率采集时间"`
 }
 
 // TableName 算力节点 ComputeNode自定义表名 compute_node
@@ -0,0 +1,34 @@
+package computenode
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 节点镜像缓存状态
+const (
+	NodeImagePresent = "present" // 节点上已存在
+	NodeImagePulling = "pulling" // 预拉取中
+	NodeImageFailed  = "failed"  // 预拉取失败
+)
+
+// ComputeNodeImage 算力节点镜像缓存清单
+// 记录镜像库中的镜像在各节点上的存在情况，由节点健康检查定时刷新，预拉取任务写入拉取中/失败状态。
+type ComputeNodeImage struct {
+	global.GVA_MODEL
+	NodeId        uint       `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;index:idx_node_image,priority:1;comment:算力节点ID"`
+	ImageId       uint       `json:"imageId" form:"imageId" gorm:"column:image_id;not null;index:idx_node_image,priority:2;index;comment:镜像库ID"`
+	ImageRef      string     `json:"imageRef" form:"imageRef" gorm:"column:image_ref;size:500;not null;comment:镜像地址"`
+	DockerImageId string     `json:"dockerImageId" form:"dockerImageId" gorm:"column:docker_image_id;size:128;comment:节点上的Docker镜像ID"`
+	SizeBytes     int64      `json:"sizeBytes" form:"sizeBytes" gorm:"column:size_bytes;not null;default:0;comment:镜像大小(字节)"`
+	Status        string     `json:"status" form:"status" gorm:"column:status;size:32;not null;index;comment:状态 present/pulling/failed"`
+	Progress      float64    `json:"progress" form:"progress" gorm:"column:progress;not null;default:0;comment:预拉取进度百分比"`
+	Message       string     `json:"message" form:"message" gorm:"column:message;size:500;comment:预拉取信息"`
+	LastSeenAt    *time.Time `json:"lastSeenAt" form:"lastSeenAt" gorm:"column:last_seen_at;comment:最近一次在节点上确认存在的时间"`
+}
+
+// TableName 节点镜像缓存 ComputeNodeImage自定义表名 compute_node_image
+func (ComputeNodeImage) TableName() string {
+	return "compute_node_image"
+}
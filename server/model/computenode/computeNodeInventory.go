@@ -0,0 +1,48 @@
+package computenode
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// GpuCardInfo nvidia-smi 采集到的单张显卡
+type GpuCardInfo struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	MemoryMb int64  `json:"memoryMb"`
+	UUID     string `json:"uuid"`
+}
+
+// InventoryMismatch 手工录入值与实际采集值不一致的字段
+type InventoryMismatch struct {
+	Field      string `json:"field"`      // cpu/memory/gpuName/gpuCount/memoryCapacity
+	Entered    string `json:"entered"`    // 节点上手工录入的值
+	Discovered string `json:"discovered"` // 实际采集的值
+	Over       bool   `json:"over"`       // 录入值大于实际值，可能导致超卖
+}
+
+// ComputeNodeInventory 算力节点硬件清单
+// 节点 Docker 连接正常时定时采集：Docker Info 提供 CPU 与内存，nvidia-smi 辅助容器提供显卡型号、数量与每卡显存。
+type ComputeNodeInventory struct {
+	global.GVA_MODEL
+	NodeId        uint                `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;uniqueIndex;comment:算力节点ID"`
+	CpuCores      int64               `json:"cpuCores" form:"cpuCores" gorm:"column:cpu_cores;not null;default:0;comment:CPU核数"`
+	MemoryGb      int64               `json:"memoryGb" form:"memoryGb" gorm:"column:memory_gb;not null;default:0;comment:内存(GB)"`
+	GpuModel      string              `json:"gpuModel" form:"gpuModel" gorm:"column:gpu_model;size:255;comment:显卡型号"`
+	GpuCount      int64               `json:"gpuCount" form:"gpuCount" gorm:"column:gpu_count;not null;default:0;comment:显卡数量"`
+	GpuMemoryGb   int64               `json:"gpuMemoryGb" form:"gpuMemoryGb" gorm:"column:gpu_memory_gb;not null;default:0;comment:每卡显存(GB)，取各卡最小值"`
+	GpuCards      []GpuCardInfo       `json:"gpuCards" form:"-" gorm:"serializer:json;type:text;column:gpu_cards;comment:显卡明细"`
+	GpuError      string              `json:"gpuError" form:"gpuError" gorm:"column:gpu_error;size:1000;comment:显卡采集失败原因"`
+	DockerVersion string              `json:"dockerVersion" form:"dockerVersion" gorm:"column:docker_version;size:64;comment:Docker版本"`
+	OS            string              `json:"os" form:"os" gorm:"column:os;size:255;comment:操作系统"`
+	KernelVersion string              `json:"kernelVersion" form:"kernelVersion" gorm:"column:kernel_version;size:255;comment:内核版本"`
+	Mismatches    []InventoryMismatch `json:"mismatches" form:"-" gorm:"serializer:json;type:text;column:mismatches;comment:与录入值不一致的字段"`
+	HasMismatch   bool                `json:"hasMismatch" form:"hasMismatch" gorm:"column:has_mismatch;not null;default:false;index;comment:是否存在不一致"`
+	CollectedAt   time.Time           `json:"collectedAt" form:"collectedAt" gorm:"column:collected_at;comment:采集时间"`
+}
+
+// TableName 算力节点硬件清单 ComputeNodeInventory自定义表名 compute_node_inventory
+func (ComputeNodeInventory) TableName() string {
+	return "compute_node_inventory"
+}
@@ -0,0 +1,62 @@
+package computenode
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// GpuProcessInfo 显卡上的计算进程
+type GpuProcessInfo struct {
+	Pid          int    `json:"pid"`
+	Name         string `json:"name"`
+	UsedMemoryMb int64  `json:"usedMemoryMb"`
+	ContainerId  string `json:"containerId"` // 由进程 cgroup 解析，宿主机进程为空
+	InstanceId   uint   `json:"instanceId"`  // 按容器ID映射到的实例，未识别为0
+}
+
+// GpuTelemetryMetrics 单张显卡的一次采样，缺失值（如不支持ECC）为 nil
+type GpuTelemetryMetrics struct {
+	SmUtil         *float64 `json:"smUtil" form:"smUtil" gorm:"column:sm_util;comment:SM利用率(%)"`
+	MemUtil        *float64 `json:"memUtil" form:"memUtil" gorm:"column:mem_util;comment:显存带宽利用率(%)"`
+	MemoryUsedMb   *int64   `json:"memoryUsedMb" form:"memoryUsedMb" gorm:"column:memory_used_mb;comment:已用显存(MiB)"`
+	MemoryTotalMb  *int64   `json:"memoryTotalMb" form:"memoryTotalMb" gorm:"column:memory_total_mb;comment:显存总量(MiB)"`
+	TemperatureC   *float64 `json:"temperatureC" form:"temperatureC" gorm:"column:temperature_c;comment:温度(℃)"`
+	PowerDrawW     *float64 `json:"powerDrawW" form:"powerDrawW" gorm:"column:power_draw_w;comment:功耗(W)"`
+	PowerLimitW    *float64 `json:"powerLimitW" form:"powerLimitW" gorm:"column:power_limit_w;comment:功耗上限(W)"`
+	EccCorrected   *int64   `json:"eccCorrected" form:"eccCorrected" gorm:"column:ecc_corrected;comment:可纠正ECC错误数(自驱动加载起)"`
+	EccUncorrected *int64   `json:"eccUncorrected" form:"eccUncorrected" gorm:"column:ecc_uncorrected;comment:不可纠正ECC错误数(自驱动加载起)"`
+}
+
+// NodeGpuTelemetry 节点显卡最新遥测，每节点每卡一行
+// 节点级采集器每轮通过辅助容器执行一次 nvidia-smi 刷新，进程按容器映射到实例。
+type NodeGpuTelemetry struct {
+	global.GVA_MODEL
+	NodeId   uint   `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;uniqueIndex:idx_gpu_telemetry_node_gpu,priority:1;comment:算力节点ID"`
+	GpuIndex int    `json:"gpuIndex" form:"gpuIndex" gorm:"column:gpu_index;not null;uniqueIndex:idx_gpu_telemetry_node_gpu,priority:2;comment:GPU设备索引"`
+	UUID     string `json:"uuid" form:"uuid" gorm:"column:uuid;size:64;comment:GPU UUID"`
+	Name     string `json:"name" form:"name" gorm:"column:name;size:128;comment:显卡型号"`
+	GpuTelemetryMetrics
+	Processes   []GpuProcessInfo `json:"processes" form:"-" gorm:"column:processes;serializer:json;type:text;comment:计算进程"`
+	InstanceIds []uint           `json:"instanceIds" form:"-" gorm:"column:instance_ids;serializer:json;type:text;comment:使用该卡的实例(分配记录与进程合并)"`
+	CollectedAt time.Time        `json:"collectedAt" form:"collectedAt" gorm:"column:collected_at;not null;comment:采集时间"`
+}
+
+// TableName 节点显卡遥测 NodeGpuTelemetry自定义表名 compute_node_gpu_telemetry
+func (NodeGpuTelemetry) TableName() string {
+	return "compute_node_gpu_telemetry"
+}
+
+// NodeGpuSample 显卡遥测历史采样（保留7天）
+type NodeGpuSample struct {
+	global.GVA_MODEL
+	NodeId   uint `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;index;comment:算力节点ID"`
+	GpuIndex int  `json:"gpuIndex" form:"gpuIndex" gorm:"column:gpu_index;not null;comment:GPU设备索引"`
+	GpuTelemetryMetrics
+	CollectedAt time.Time `json:"collectedAt" form:"collectedAt" gorm:"column:collected_at;not null;index;comment:采集时间"`
+}
+
+// TableName 显卡遥测历史 NodeGpuSample自定义表名 compute_node_gpu_sample
+func (NodeGpuSample) TableName() string {
+	return "compute_node_gpu_sample"
+}
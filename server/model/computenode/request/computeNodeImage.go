@@ -0,0 +1,19 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// PrePullImageReq 预拉取镜像到指定节点
+type PrePullImageReq struct {
+	ImageId uint   `json:"imageId" form:"imageId" binding:"required"`
+	NodeIds []uint `json:"nodeIds" form:"nodeIds" binding:"required"`
+}
+
+// NodeImageSearch 节点镜像缓存查询条件
+type NodeImageSearch struct {
+	NodeId  *uint   `json:"nodeId" form:"nodeId"`
+	ImageId *uint   `json:"imageId" form:"imageId"`
+	Status  *string `json:"status" form:"status"`
+	request.PageInfo
+}
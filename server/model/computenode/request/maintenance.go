@@ -0,0 +1,13 @@
+package request
+
+// NodeMaintenanceReq 隔离/解除隔离节点
+type NodeMaintenanceReq struct {
+	ID uint `json:"ID" form:"ID" binding:"required"`
+}
+
+// DrainNodeReq 排空节点：通知实例所有者，到期后停止或迁移节点上的实例
+type DrainNodeReq struct {
+	ID              uint   `json:"ID" form:"ID" binding:"required"`
+	Action          string `json:"action" form:"action" binding:"required,oneof=stop migrate"` // stop/migrate
+	DeadlineMinutes int    `json:"deadlineMinutes" form:"deadlineMinutes" binding:"min=0"`     // 通知后等待的分钟数，0 表示立即处理
+}
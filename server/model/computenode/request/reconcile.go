@@ -0,0 +1,7 @@
+package request
+
+// ReconcileNodesReq 节点孤儿容器/数据卷对账
+type ReconcileNodesReq struct {
+	ID      uint `json:"ID" form:"ID"`           // 节点ID，为空时对账全部 Docker 连接正常的节点
+	Execute bool `json:"execute" form:"execute"` // 为 false 时只演练，返回将要执行的处理
+}
@@ -0,0 +1,111 @@
+package computenode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 污点效果
+const (
+	TaintNoSchedule = "NoSchedule" // 不接受新的实例，已有实例不受影响
+)
+
+// 节点维护状态，为空表示正常接受调度
+const (
+	MaintenanceCordoned = "cordoned" // 不接受新实例，已有实例照常运行
+	MaintenanceDraining = "draining" // 已通知实例所有者，截止后停止或迁移实例
+	MaintenanceDrained  = "drained"  // 节点上已没有需要处理的实例
+)
+
+// 排空方式
+const (
+	DrainActionStop    = "stop"    // 停止实例，保留数据
+	DrainActionMigrate = "migrate" // 迁移到其他可用节点
+)
+
+// 容忍匹配方式
+const (
+	TolerationEqual  = "Equal"  // 键与值都相同
+	TolerationExists = "Exists" // 只要求键存在
+)
+
+// NodeTaint 节点污点，未被规格或镜像容忍时调度器不会把新实例放到该节点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Effect string `json:"effect"` // 为空时按 NoSchedule 处理
+}
+
+// Toleration 规格/镜像对节点污点的容忍
+type Toleration struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"` // Equal(默认)/Exists
+	Value    string `json:"value"`
+	Effect   string `json:"effect"` // 为空时容忍所有效果
+}
+
+// Tolerates 是否容忍该污点
+func (t Toleration) Tolerates(taint NodeTaint) bool {
+	if t.Key != taint.Key {
+		return false
+	}
+	if t.Effect != "" && t.Effect != taint.EffectOrDefault() {
+		return false
+	}
+	if t.Operator == TolerationExists {
+		return true
+	}
+	return t.Value == taint.Value
+}
+
+// EffectOrDefault 污点效果，未填写时为 NoSchedule
+func (t NodeTaint) EffectOrDefault() string {
+	if t.Effect == "" {
+		return TaintNoSchedule
+	}
+	return t.Effect
+}
+
+// ValidateLabels 校验标签或节点选择器的键
+func ValidateLabels(labels map[string]string) error {
+	for k := range labels {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("标签键不能为空")
+		}
+	}
+	return nil
+}
+
+// ValidateTaints 校验节点污点
+func ValidateTaints(taints []NodeTaint) error {
+	for _, t := range taints {
+		if strings.TrimSpace(t.Key) == "" {
+			return fmt.Errorf("污点键不能为空")
+		}
+		if t.EffectOrDefault() != TaintNoSchedule {
+			return fmt.Errorf("不支持的污点效果: %s", t.Effect)
+		}
+	}
+	return nil
+}
+
+// ValidateTolerations 校验容忍规则
+func ValidateTolerations(tolerations []Toleration) error {
+	for _, t := range tolerations {
+		if strings.TrimSpace(t.Key) == "" {
+			return fmt.Errorf("容忍的污点键不能为空")
+		}
+		if t.Operator != "" && t.Operator != TolerationEqual && t.Operator != TolerationExists {
+			return fmt.Errorf("不支持的容忍匹配方式: %s", t.Operator)
+		}
+	}
+	return nil
+}
+
+// ValidateSchedulingRules 校验规格/镜像的节点选择器与容忍规则
+func ValidateSchedulingRules(selector map[string]string, tolerations []Toleration) error {
+	if err := ValidateLabels(selector); err != nil {
+		return err
+	}
+	return ValidateTolerations(tolerations)
+}
@@ -0,0 +1,71 @@
+package idlepolicy
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 策略适用对象
+const (
+	SubjectSpec      = "spec"      // 按产品规格
+	SubjectAuthority = "authority" // 按实例所属用户的角色
+)
+
+// 空闲判定指标
+const (
+	MetricGpuUtil   = "gpu_util"   // 显卡SM利用率，取自节点显卡遥测
+	MetricGpuMemory = "gpu_memory" // 显存使用率，取自巡检任务写入实例的值
+)
+
+// IdlePolicy 空闲实例自动停止策略
+// 指标持续低于阈值 IdleMinutes 分钟后邮件提醒实例所有者，提醒后仍空闲 GraceMinutes 分钟则停止容器；
+// 同一实例同时命中规格策略与角色策略时规格策略优先。
+type IdlePolicy struct {
+	global.GVA_MODEL
+	Name         string  `json:"name" form:"name" gorm:"column:name;size:100;comment:策略名称" binding:"required"`
+	SubjectType  string  `json:"subjectType" form:"subjectType" gorm:"column:subject_type;size:16;not null;uniqueIndex:idx_idle_policy_subject,priority:1;comment:适用类型 spec/authority" binding:"required,oneof=spec authority"`
+	SubjectId    uint    `json:"subjectId" form:"subjectId" gorm:"column:subject_id;not null;uniqueIndex:idx_idle_policy_subject,priority:2;comment:产品规格ID或角色ID" binding:"required"`
+	Metric       string  `json:"metric" form:"metric" gorm:"column:metric;size:16;not null;comment:判定指标 gpu_util/gpu_memory" binding:"required,oneof=gpu_util gpu_memory"`
+	Threshold    float64 `json:"threshold" form:"threshold" gorm:"column:threshold;not null;comment:空闲阈值(%)，低于该值视为空闲" binding:"gt=0,lte=100"`
+	IdleMinutes  int     `json:"idleMinutes" form:"idleMinutes" gorm:"column:idle_minutes;not null;comment:持续空闲多久后提醒(分钟)" binding:"min=1"`
+	GraceMinutes int     `json:"graceMinutes" form:"graceMinutes" gorm:"column:grace_minutes;not null;default:0;comment:提醒后仍空闲多久停止(分钟)" binding:"min=0"`
+	AllowOptOut  bool    `json:"allowOptOut" form:"allowOptOut" gorm:"column:allow_opt_out;not null;comment:是否允许实例单独豁免"`
+	Enabled      bool    `json:"enabled" form:"enabled" gorm:"column:enabled;not null;comment:是否启用"`
+	Remark       string  `json:"remark" form:"remark" gorm:"column:remark;size:500;comment:备注"`
+}
+
+// TableName 空闲停止策略 IdlePolicy自定义表名 idle_policy
+func (IdlePolicy) TableName() string {
+	return "idle_policy"
+}
+
+// 审计动作
+const (
+	ActionWarn       = "warn"        // 已提醒
+	ActionStop       = "stop"        // 已停止
+	ActionStopFailed = "stop_failed" // 停止失败
+	ActionOptOut     = "opt_out"     // 实例设为豁免
+	ActionOptIn      = "opt_in"      // 实例取消豁免
+)
+
+// IdleStopRecord 空闲停止审计记录（只追加）
+type IdleStopRecord struct {
+	global.GVA_MODEL
+	InstanceId uint       `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;index;comment:实例ID"`
+	UserId     int64      `json:"userId" form:"userId" gorm:"column:user_id;not null;default:0;index;comment:实例所属用户ID"`
+	PolicyId   uint       `json:"policyId" form:"policyId" gorm:"column:policy_id;not null;default:0;comment:命中的策略ID"`
+	Action     string     `json:"action" form:"action" gorm:"column:action;size:16;not null;comment:动作 warn/stop/stop_failed/opt_out/opt_in"`
+	Metric     string     `json:"metric" form:"metric" gorm:"column:metric;size:16;comment:判定指标"`
+	Value      float64    `json:"value" form:"value" gorm:"column:value;comment:判定时的指标值(%)"`
+	Threshold  float64    `json:"threshold" form:"threshold" gorm:"column:threshold;comment:策略阈值(%)"`
+	IdleSince  *time.Time `json:"idleSince" form:"idleSince" gorm:"column:idle_since;comment:开始空闲时间"`
+	OperatorId uint       `json:"operatorId" form:"operatorId" gorm:"column:operator_id;not null;default:0;comment:操作人ID，定时任务为0"`
+	Detail     string     `json:"detail" form:"detail" gorm:"column:detail;size:500;comment:说明"`
+	OccurredAt time.Time  `json:"occurredAt" form:"occurredAt" gorm:"column:occurred_at;not null;index;comment:发生时间"`
+}
+
+// TableName 空闲停止审计 IdleStopRecord自定义表名 idle_stop_record
+func (IdleStopRecord) TableName() string {
+	return "idle_stop_record"
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// IdlePolicySearch 空闲停止策略查询条件
+type IdlePolicySearch struct {
+	SubjectType *string `json:"subjectType" form:"subjectType"`
+	SubjectId   *uint   `json:"subjectId" form:"subjectId"`
+	request.PageInfo
+}
+
+// IdleStopRecordSearch 空闲停止审计查询条件
+type IdleStopRecordSearch struct {
+	InstanceId *uint   `json:"instanceId" form:"instanceId"`
+	UserId     *int64  `json:"userId" form:"userId"`
+	Action     *string `json:"action" form:"action"`
+	request.PageInfo
+}
@@ -3,6 +3,7 @@
 package imageregistry
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
 )
 
 // 镜像库 结构体  ImageRegistry
@@ -15,6 +16,13 @@ type ImageRegistry struct {
   IsOnShelf  *bool `json:"isOnShelf" form:"isOnShelf" gorm:"default:true;comment:是否上架;column:is_on_shelf;" binding:"required"`  //是否上架
   SupportMemorySplit  *bool `json:"supportMemorySplit" form:"supportMemorySplit" gorm:"default:false;comment:是否支持显存切分;column:support_memory_split;" binding:"required"`  //是否支持显存切分
   Remark  *string `json:"remark" form:"remark" gorm:"comment:备注信息;column:remark;size:1000;"`  //备注
+  Username  *string `json:"username" form:"username" gorm:"comment:仓库用户名;column:username;size:255;"`  //仓库用户名
+  Password  *string `json:"password,omitempty" form:"password" gorm:"comment:仓库密码(加密存储);column:password;size:1000;"`  //仓库密码
+  Token  *string `json:"token,omitempty" form:"token" gorm:"comment:仓库访问令牌(加密存储);column:token;size:4000;"`  //仓库访问令牌
+  OwnerId  *int64 `json:"ownerId" form:"ownerId" gorm:"comment:所属用户ID(为空表示平台镜像);column:owner_id;index;"`  //所属用户
+  IsPrivate  *bool `json:"isPrivate" form:"isPrivate" gorm:"default:false;comment:是否私有(仅所属用户可用);column:is_private;"`  //是否私有
+  NodeSelector  map[string]string `json:"nodeSelector" form:"-" gorm:"serializer:json;type:text;column:node_selector;comment:节点选择器;"`  //节点选择器
+  Tolerations  []computenode.Toleration `json:"tolerations" form:"-" gorm:"serializer:json;type:text;column:tolerations;comment:污点容忍;"`  //污点容忍
 }
 
 
@@ -0,0 +1,20 @@
+package instance
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// GpuAllocation 实例GPU卡分配记录
+// 每条记录表示某实例占用了节点上的一张物理卡（按设备索引），实例删除时释放。
+type GpuAllocation struct {
+	global.GVA_MODEL
+	NodeId      int64 `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;index:idx_gpu_alloc_node_device,priority:1;comment:算力节点ID"`
+	InstanceId  uint  `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;index;comment:实例ID"`
+	DeviceIndex int   `json:"deviceIndex" form:"deviceIndex" gorm:"column:device_index;not null;index:idx_gpu_alloc_node_device,priority:2;comment:GPU设备索引"`
+	MemoryGb    int64 `json:"memoryGb" form:"memoryGb" gorm:"column:memory_gb;not null;default:0;comment:该卡上分配的显存(GB)"`
+}
+
+// TableName GPU卡分配 GpuAllocation自定义表名 instance_gpu_allocation
+func (GpuAllocation) TableName() string {
+	return "instance_gpu_allocation"
+}
@@ -2,6 +2,8 @@
 package instance
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
@@ -11,16 +13,33 @@ type Instance struct {
 	ImageId         *int64  `json:"imageId" form:"imageId" gorm:"comment:镜像ID;column:image_id;" binding:"required"`               //镜像
 	SpecId          *int64  `json:"specId" form:"specId" gorm:"comment:产品规格ID;column:spec_id;" binding:"required"`                //产品规格
 	UserId          *int64  `json:"userId" form:"userId" gorm:"comment:用户ID;column:user_id;"`                                     //用户
-	NodeId          *int64  `json:"nodeId" form:"nodeId" gorm:"comment:算力节点ID;column:node_id;" binding:"required"`                //算力节点
+	NodeId          *int64  `json:"nodeId" form:"nodeId" gorm:"comment:算力节点ID;column:node_id;"`                                   //算力节点，为空时自动调度
 	ContainerId     *string `json:"containerId" form:"containerId" gorm:"comment:Docker容器ID;column:container_id;size:255;"`       //Docker容器
 	ContainerName   *string `json:"containerName" form:"containerName" gorm:"comment:Docker容器名称;column:container_name;size:255;"` //Docker容器名称
 	Name            *string `json:"name" form:"name" gorm:"comment:实例名称;column:name;size:255;" binding:"required"`                //实例名称
 	ContainerStatus *string `json:"containerStatus" form:"containerStatus" gorm:"comment:容器状态;column:container_status;size:50;"`  //容器状态
+	// 异步创建状态机（pending → pulling → creating → starting → running / failed）
+	ProvisionState *string `json:"provisionState" form:"provisionState" gorm:"comment:创建进度状态;column:provision_state;size:32;index;"`
+	ProvisionError *string `json:"provisionError" form:"provisionError" gorm:"comment:创建失败原因;column:provision_error;type:text;"`
+	// 自动调度：未指定节点时按调度打分选择，容器创建失败时依次回退到备选节点
+	PlacementCandidates *string `json:"placementCandidates" form:"placementCandidates" gorm:"comment:自动调度备选节点ID(JSON数组);column:placement_candidates;size:500;"`
+	// 迁移目标节点：迁移期间资源同时计入源节点与目标节点，完成或回滚后清空
+	MigrateTargetNodeId *int64 `json:"migrateTargetNodeId" form:"-" gorm:"comment:迁移目标节点ID;column:migrate_target_node_id;index;"`
+	Region              string `json:"region,omitempty" form:"region" gorm:"-"` // 自动调度的区域偏好，不落库
+	// 自动调度的节点标签要求，候选节点须全部匹配（含内置 region/gpu 标签），不落库
+	Labels map[string]string `json:"labels,omitempty" form:"-" gorm:"-"`
+	// 镜像拉取进度（创建过程中由后台worker刷新）
+	PullProgress *float64 `json:"pullProgress" form:"pullProgress" gorm:"comment:镜像拉取进度百分比;column:pull_progress;"`
+	PullDetail   *string  `json:"pullDetail" form:"pullDetail" gorm:"comment:镜像拉取进度详情;column:pull_detail;size:500;"`
 	// 监控度量字段（定时任务每30秒刷新）
 	CpuUsagePercent    *float64 `json:"cpuUsagePercent" form:"cpuUsagePercent" gorm:"comment:CPU使用率百分比;column:cpu_usage_percent;"`
 	MemoryUsagePercent *float64 `json:"memoryUsagePercent" form:"memoryUsagePercent" gorm:"comment:内存使用率百分比;column:memory_usage_percent;"`
 	GpuMemoryUsageRate *float64 `json:"gpuMemoryUsageRate" form:"gpuMemoryUsageRate" gorm:"comment:GPU显存使用率百分比;column:gpu_memory_usage_rate;"`
 	Remark             *string  `json:"remark" form:"remark" gorm:"comment:备注信息;column:remark;size:1000;"` //备注
+	// 空闲自动停止：豁免标记只能通过 setIdleStopExempt 修改，空闲计时由定时任务维护
+	IdleStopExempt *bool      `json:"idleStopExempt" form:"-" gorm:"comment:是否豁免空闲自动停止;column:idle_stop_exempt;"`
+	IdleSince      *time.Time `json:"idleSince" form:"-" gorm:"comment:开始空闲时间;column:idle_since;"`
+	IdleWarnedAt   *time.Time `json:"idleWarnedAt" form:"-" gorm:"comment:空闲提醒时间;column:idle_warned_at;"`
 }
 
 // TableName 实例管理 Instance自定义表名 instance
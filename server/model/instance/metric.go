@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// MetricPoint 监控采样点，原始采样与各级汇总共用同一结构
+// InstanceId 为 0 的行是节点汇总（节点上各实例的平均值）。汇总点的 Avg 按 Samples 加权，Max 取区间最大值。
+type MetricPoint struct {
+	global.GVA_MODEL
+	InstanceId uint      `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;default:0;index;comment:实例ID，节点汇总为0"`
+	NodeId     uint      `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;default:0;index;comment:算力节点ID"`
+	BucketAt   time.Time `json:"bucketAt" form:"bucketAt" gorm:"column:bucket_at;not null;index;comment:采样时间或汇总区间开始时间"`
+	CpuAvg     float64   `json:"cpuAvg" form:"cpuAvg" gorm:"column:cpu_avg;not null;default:0;comment:CPU使用率均值"`
+	CpuMax     float64   `json:"cpuMax" form:"cpuMax" gorm:"column:cpu_max;not null;default:0;comment:CPU使用率最大值"`
+	MemoryAvg  float64   `json:"memoryAvg" form:"memoryAvg" gorm:"column:memory_avg;not null;default:0;comment:内存使用率均值"`
+	MemoryMax  float64   `json:"memoryMax" form:"memoryMax" gorm:"column:memory_max;not null;default:0;comment:内存使用率最大值"`
+	GpuAvg     float64   `json:"gpuAvg" form:"gpuAvg" gorm:"column:gpu_avg;not null;default:0;comment:GPU显存使用率均值"`
+	GpuMax     float64   `json:"gpuMax" form:"gpuMax" gorm:"column:gpu_max;not null;default:0;comment:GPU显存使用率最大值"`
+	Samples    int       `json:"samples" form:"samples" gorm:"column:samples;not null;default:1;comment:包含的原始采样数"`
+}
+
+// MetricSample 原始采样（每30秒一条，保留1天）
+type MetricSample struct {
+	MetricPoint
+}
+
+// TableName 原始采样 MetricSample自定义表名 instance_metric_raw
+func (MetricSample) TableName() string {
+	return "instance_metric_raw"
+}
+
+// MetricRollup5m 5分钟汇总（保留30天）
+type MetricRollup5m struct {
+	MetricPoint
+}
+
+// TableName 5分钟汇总 MetricRollup5m自定义表名 instance_metric_5m
+func (MetricRollup5m) TableName() string {
+	return "instance_metric_5m"
+}
+
+// MetricRollup1h 1小时汇总（保留1年）
+type MetricRollup1h struct {
+	MetricPoint
+}
+
+// TableName 1小时汇总 MetricRollup1h自定义表名 instance_metric_1h
+func (MetricRollup1h) TableName() string {
+	return "instance_metric_1h"
+}
@@ -0,0 +1,22 @@
+package instance
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// ProvisionEvent 实例创建步骤事件
+// 记录后台创建流程中每个步骤每次尝试的结果，用于前端轮询进度与问题排查。
+type ProvisionEvent struct {
+	global.GVA_MODEL
+	InstanceId uint   `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;index;comment:实例ID"`
+	Step       string `json:"step" form:"step" gorm:"column:step;size:32;not null;comment:步骤 pending/pulling/creating/starting/running/failed"`
+	Status     string `json:"status" form:"status" gorm:"column:status;size:32;not null;comment:结果 started/succeeded/retrying/failed"`
+	Attempt    int    `json:"attempt" form:"attempt" gorm:"column:attempt;not null;default:1;comment:第几次尝试"`
+	Message    string `json:"message" form:"message" gorm:"column:message;type:text;comment:事件信息"`
+	DurationMs int64  `json:"durationMs" form:"durationMs" gorm:"column:duration_ms;not null;default:0;comment:耗时毫秒"`
+}
+
+// TableName 实例创建事件 ProvisionEvent自定义表名 instance_provision_event
+func (ProvisionEvent) TableName() string {
+	return "instance_provision_event"
+}
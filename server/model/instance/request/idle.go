@@ -0,0 +1,7 @@
+package request
+
+// SetIdleStopExemptReq 设置实例空闲自动停止豁免
+type SetIdleStopExemptReq struct {
+	ID     uint `json:"ID" binding:"required"` // 实例ID
+	Exempt bool `json:"exempt"`                // true 为豁免
+}
@@ -0,0 +1,17 @@
+package request
+
+import "time"
+
+// MetricsQueryReq 实例/节点监控历史查询条件
+type MetricsQueryReq struct {
+	ID         uint        `json:"ID" form:"ID" binding:"required"`                                  // 实例ID或节点ID
+	TimeRange  []time.Time `json:"timeRange" form:"timeRange[]"`                                     // 查询区间，默认最近24小时
+	Resolution string      `json:"resolution" form:"resolution" binding:"omitempty,oneof=raw 5m 1h"` // 为空时按区间长度自动选择
+}
+
+// GpuHistoryReq 节点显卡遥测历史查询条件
+type GpuHistoryReq struct {
+	ID        uint        `json:"ID" form:"ID" binding:"required"` // 节点ID
+	TimeRange []time.Time `json:"timeRange" form:"timeRange[]"`    // 查询区间，默认最近24小时
+	GpuIndex  *int        `json:"gpuIndex" form:"gpuIndex"`        // 为空时返回所有卡
+}
@@ -0,0 +1,7 @@
+package request
+
+// MigrateInstanceReq 实例迁移到其他算力节点
+type MigrateInstanceReq struct {
+	ID           uint `json:"ID" binding:"required"`           // 实例ID
+	TargetNodeId uint `json:"targetNodeId" binding:"required"` // 目标算力节点ID
+}
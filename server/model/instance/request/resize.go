@@ -0,0 +1,7 @@
+package request
+
+// ResizeInstanceReq 实例变更规格
+type ResizeInstanceReq struct {
+	ID     uint  `json:"ID" binding:"required"`     // 实例ID
+	SpecId int64 `json:"specId" binding:"required"` // 目标产品规格ID
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// SchedulingLogSearch 调度决策记录查询条件
+type SchedulingLogSearch struct {
+	CreatedAtRange  []time.Time `json:"createdAtRange" form:"createdAtRange[]"`
+	TraceId         string      `json:"traceId" form:"traceId"`
+	Source          string      `json:"source" form:"source"`
+	InstanceId      uint        `json:"instanceId" form:"instanceId"`
+	UserId          uint        `json:"userId" form:"userId"`
+	ChosenNodeId    uint        `json:"chosenNodeId" form:"chosenNodeId"`
+	StrategyVersion string      `json:"strategyVersion" form:"strategyVersion"`
+	HitRule         string      `json:"hitRule" form:"hitRule"`
+	request.PageInfo
+}
@@ -0,0 +1,15 @@
+package request
+
+// SimulateScheduleReq 调度模拟：按顺序重放一批假设的创建请求，不写库
+type SimulateScheduleReq struct {
+	Items   []SimulateScheduleItem `json:"items" binding:"required"` // 假设的创建请求
+	Version string                 `json:"version"`                  // 指定策略版本用于对比，为空时按 chooseStrategy 选择
+}
+
+// SimulateScheduleItem 一组同规格的假设请求
+type SimulateScheduleItem struct {
+	SpecId  int64  `json:"specId" binding:"required"` // 产品规格ID
+	Count   int    `json:"count"`                     // 实例数量，默认1
+	Region  string `json:"region"`                    // 限定区域
+	ImageId uint   `json:"imageId"`                   // 镜像ID，用于镜像缓存加分
+}
@@ -0,0 +1,9 @@
+package request
+
+// SaveInstanceImageReq 实例保存为镜像
+type SaveInstanceImageReq struct {
+	ID          uint   `json:"ID" binding:"required"`   // 实例ID
+	Name        string `json:"name" binding:"required"` // 新镜像名称
+	Description string `json:"description"`             // 新镜像描述
+	Push        bool   `json:"push"`                    // 是否推送到配置的快照仓库
+}
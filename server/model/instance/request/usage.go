@@ -0,0 +1,7 @@
+package request
+
+// UsageReportReq 月度用量报表查询条件
+type UsageReportReq struct {
+	Month   string `json:"month" form:"month"`     // 统计月份，格式 2006-01，默认当月
+	GroupBy string `json:"groupBy" form:"groupBy"` // 汇总维度 user/spec，默认 user
+}
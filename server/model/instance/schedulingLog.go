@@ -0,0 +1,38 @@
+package instance
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SchedulingLog 调度决策记录
+// 每次调度打分写入一条：请求信息、命中的策略版本与规则、候选节点得分、选中节点与耗时；
+// 自动调度创建实例后回填实例ID与落位尝试，用于解释实例为何落在某个节点。
+type SchedulingLog struct {
+	global.GVA_MODEL
+	TraceId          string `json:"traceId" form:"traceId" gorm:"column:trace_id;size:64;index;comment:调度追踪ID"`
+	Source           string `json:"source" form:"source" gorm:"column:source;size:32;index;comment:来源 placement/placement_fallback/resize/migrate"`
+	InstanceId       uint   `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;default:0;index;comment:实例ID"`
+	UserId           uint   `json:"userId" form:"userId" gorm:"column:user_id;not null;default:0;index;comment:请求用户ID"`
+	SpecId           int64  `json:"specId" form:"specId" gorm:"column:spec_id;not null;default:0;comment:产品规格ID"`
+	ImageId          uint   `json:"imageId" form:"imageId" gorm:"column:image_id;not null;default:0;comment:镜像ID"`
+	Region           string `json:"region" form:"region" gorm:"column:region;size:64;comment:请求区域"`
+	ISP              string `json:"isp" form:"isp" gorm:"column:isp;size:64;comment:请求运营商"`
+	UserHash         string `json:"userHash" form:"userHash" gorm:"column:user_hash;size:64;comment:用户哈希"`
+	StrategyVersion  string `json:"strategyVersion" form:"strategyVersion" gorm:"column:strategy_version;size:64;index;comment:策略版本"`
+	StrategyState    string `json:"strategyState" form:"strategyState" gorm:"column:strategy_state;size:16;comment:发布状态"`
+	HitRule          string `json:"hitRule" form:"hitRule" gorm:"column:hit_rule;size:64;comment:命中规则"`
+	Strategy         string `json:"strategy" form:"strategy" gorm:"column:strategy;size:32;comment:调度算法"`
+	CircuitBreakerOn bool   `json:"circuitBreakerOn" form:"circuitBreakerOn" gorm:"column:circuit_breaker_on;not null;default:false;comment:熔断是否打开"`
+	CandidateCount   int    `json:"candidateCount" form:"candidateCount" gorm:"column:candidate_count;not null;default:0;comment:候选节点数"`
+	ChosenNodeId     uint   `json:"chosenNodeId" form:"chosenNodeId" gorm:"column:chosen_node_id;not null;default:0;index;comment:选中节点ID"`
+	ChosenNodeName   string `json:"chosenNodeName" form:"chosenNodeName" gorm:"column:chosen_node_name;size:255;comment:选中节点名称"`
+	Candidates       string `json:"candidates" form:"candidates" gorm:"column:candidates;type:text;comment:候选节点得分(JSON)"`
+	Attempts         string `json:"attempts" form:"attempts" gorm:"column:attempts;type:text;comment:落位尝试(JSON)"`
+	LatencyMs        int64  `json:"latencyMs" form:"latencyMs" gorm:"column:latency_ms;not null;default:0;comment:调度耗时(毫秒)"`
+	Error            string `json:"error" form:"error" gorm:"column:error;type:text;comment:调度错误"`
+}
+
+// TableName 调度决策记录 SchedulingLog自定义表名 instance_scheduling_log
+func (SchedulingLog) TableName() string {
+	return "instance_scheduling_log"
+}
@@ -0,0 +1,27 @@
+package instance
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// UsageRecord 实例计量流水（只追加，不修改不删除）
+// 每次容器状态变化写入一条，计费报表按相邻流水之间的运行区间累计时长，价格取区间开始时的快照。
+type UsageRecord struct {
+	global.GVA_MODEL
+	InstanceId   uint      `json:"instanceId" form:"instanceId" gorm:"column:instance_id;not null;index;comment:实例ID"`
+	UserId       int64     `json:"userId" form:"userId" gorm:"column:user_id;not null;default:0;index;comment:用户ID"`
+	SpecId       int64     `json:"specId" form:"specId" gorm:"column:spec_id;not null;default:0;index;comment:产品规格ID"`
+	NodeId       int64     `json:"nodeId" form:"nodeId" gorm:"column:node_id;not null;default:0;comment:算力节点ID"`
+	Event        string    `json:"event" form:"event" gorm:"column:event;size:32;not null;comment:事件 provisioned/start/stop/restart/delete/status_change"`
+	FromStatus   string    `json:"fromStatus" form:"fromStatus" gorm:"column:from_status;size:50;comment:变化前容器状态"`
+	ToStatus     string    `json:"toStatus" form:"toStatus" gorm:"column:to_status;size:50;not null;comment:变化后容器状态"`
+	PricePerHour float64   `json:"pricePerHour" form:"pricePerHour" gorm:"column:price_per_hour;not null;default:0;comment:发生时规格每小时价格快照"`
+	OccurredAt   time.Time `json:"occurredAt" form:"occurredAt" gorm:"column:occurred_at;not null;index;comment:发生时间"`
+}
+
+// TableName 实例计量流水 UsageRecord自定义表名 instance_usage_record
+func (UsageRecord) TableName() string {
+	return "instance_usage_record"
+}
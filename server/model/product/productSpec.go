@@ -3,6 +3,7 @@ package product
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
 )
 
 // 产品规格 结构体  ProductSpec
@@ -20,6 +21,9 @@ type ProductSpec struct {
 	IsOnShelf          *bool    `json:"isOnShelf" form:"isOnShelf" gorm:"default:true;comment:是否上架;column:is_on_shelf;" binding:"required"`                                 //是否上架
 	SupportMemorySplit *bool    `json:"supportMemorySplit" form:"supportMemorySplit" gorm:"default:false;comment:是否支持显存分割;column:support_memory_split;" binding:"required"` //是否支持显存分割
 	Remark             *string  `json:"remark" form:"remark" gorm:"comment:备注信息;column:remark;size:1000;"`                                                                  //备注
+	// 调度约束：只放到标签全部匹配的节点，并容忍指定的节点污点
+	NodeSelector map[string]string        `json:"nodeSelector" form:"-" gorm:"serializer:json;type:text;column:node_selector;comment:节点选择器"`
+	Tolerations  []computenode.Toleration `json:"tolerations" form:"-" gorm:"serializer:json;type:text;column:tolerations;comment:污点容忍"`
 }
 
 // TableName 产品规格 ProductSpec自定义表名 product_spec
@@ -0,0 +1,12 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// ResourceQuotaSearch 资源配额查询条件
+type ResourceQuotaSearch struct {
+	SubjectType *string `json:"subjectType" form:"subjectType"`
+	SubjectId   *uint   `json:"subjectId" form:"subjectId"`
+	request.PageInfo
+}
@@ -0,0 +1,31 @@
+package quota
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 配额归属对象
+const (
+	SubjectUser      = "user"      // 用户级配额
+	SubjectAuthority = "authority" // 角色级配额
+)
+
+// ResourceQuota 资源配额
+// 可挂在用户或角色上，各项上限为0表示不限制；用户级配额的非零项优先于其所属角色的配额。
+type ResourceQuota struct {
+	global.GVA_MODEL
+	SubjectType    string `json:"subjectType" form:"subjectType" gorm:"column:subject_type;size:16;not null;uniqueIndex:idx_quota_subject,priority:1;comment:归属类型 user/authority" binding:"required,oneof=user authority"`
+	SubjectId      uint   `json:"subjectId" form:"subjectId" gorm:"column:subject_id;not null;uniqueIndex:idx_quota_subject,priority:2;comment:用户ID或角色ID" binding:"required"`
+	MaxInstances   int64  `json:"maxInstances" form:"maxInstances" gorm:"column:max_instances;not null;default:0;comment:最大实例数"`
+	MaxGpus        int64  `json:"maxGpus" form:"maxGpus" gorm:"column:max_gpus;not null;default:0;comment:最大GPU卡数"`
+	MaxGpuMemoryGb int64  `json:"maxGpuMemoryGb" form:"maxGpuMemoryGb" gorm:"column:max_gpu_memory_gb;not null;default:0;comment:最大显存(GB)"`
+	MaxCpuCores    int64  `json:"maxCpuCores" form:"maxCpuCores" gorm:"column:max_cpu_cores;not null;default:0;comment:最大CPU核心数"`
+	MaxMemoryGb    int64  `json:"maxMemoryGb" form:"maxMemoryGb" gorm:"column:max_memory_gb;not null;default:0;comment:最大内存(GB)"`
+	MaxDataDiskGb  int64  `json:"maxDataDiskGb" form:"maxDataDiskGb" gorm:"column:max_data_disk_gb;not null;default:0;comment:最大数据盘(GB)"`
+	Remark         string `json:"remark" form:"remark" gorm:"column:remark;size:500;comment:备注"`
+}
+
+// TableName 资源配额 ResourceQuota自定义表名 resource_quota
+func (ResourceQuota) TableName() string {
+	return "resource_quota"
+}
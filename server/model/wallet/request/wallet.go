@@ -0,0 +1,19 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// WalletChangeReq 管理员充值/扣减
+type WalletChangeReq struct {
+	UserId uint    `json:"userId" binding:"required"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Remark string  `json:"remark"`
+}
+
+// WalletTransactionSearch 钱包流水查询条件
+type WalletTransactionSearch struct {
+	UserId *uint   `json:"userId" form:"userId"`
+	Type   *string `json:"type" form:"type"`
+	request.PageInfo
+}
@@ -0,0 +1,45 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 钱包流水类型
+const (
+	TransactionRecharge = "recharge" // 充值
+	TransactionDeduct   = "deduct"   // 扣费（周期扣费或管理员手工扣减）
+)
+
+// Wallet 用户余额账户
+type Wallet struct {
+	global.GVA_MODEL
+	UserId       uint       `json:"userId" form:"userId" gorm:"column:user_id;not null;uniqueIndex;comment:用户ID"`
+	Balance      float64    `json:"balance" form:"balance" gorm:"column:balance;not null;default:0;comment:余额"`
+	BilledUntil  time.Time  `json:"billedUntil" form:"billedUntil" gorm:"column:billed_until;not null;comment:已扣费截止时间"`
+	ArrearsSince *time.Time `json:"arrearsSince" form:"arrearsSince" gorm:"column:arrears_since;comment:开始欠费时间，余额恢复为正后清空"`
+}
+
+// TableName 用户钱包 Wallet自定义表名 user_wallet
+func (Wallet) TableName() string {
+	return "user_wallet"
+}
+
+// WalletTransaction 钱包流水
+type WalletTransaction struct {
+	global.GVA_MODEL
+	UserId       uint       `json:"userId" form:"userId" gorm:"column:user_id;not null;index;comment:用户ID"`
+	Type         string     `json:"type" form:"type" gorm:"column:type;size:32;not null;comment:类型 recharge/deduct"`
+	Amount       float64    `json:"amount" form:"amount" gorm:"column:amount;not null;comment:金额（正数）"`
+	BalanceAfter float64    `json:"balanceAfter" form:"balanceAfter" gorm:"column:balance_after;not null;comment:变动后余额"`
+	PeriodStart  *time.Time `json:"periodStart" form:"periodStart" gorm:"column:period_start;comment:扣费周期开始"`
+	PeriodEnd    *time.Time `json:"periodEnd" form:"periodEnd" gorm:"column:period_end;comment:扣费周期结束"`
+	OperatorId   uint       `json:"operatorId" form:"operatorId" gorm:"column:operator_id;not null;default:0;comment:操作人ID，周期扣费为0"`
+	Remark       string     `json:"remark" form:"remark" gorm:"column:remark;size:500;comment:备注"`
+}
+
+// TableName 钱包流水 WalletTransaction自定义表名 user_wallet_transaction
+func (WalletTransaction) TableName() string {
+	return "user_wallet_transaction"
+}
@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AlertRouter struct{}
+
+// InitAlertRouter 初始化 告警 路由信息
+func (s *AlertRouter) InitAlertRouter(Router *gin.RouterGroup, PublicRouter *gin.RouterGroup) {
+	alertRouter := Router.Group("alert").Use(middleware.OperationRecord())
+	alertRouterWithoutRecord := Router.Group("alert")
+	{
+		alertRouter.POST("createAlertRule", alertApi.CreateAlertRule)         // 新建告警规则
+		alertRouter.PUT("updateAlertRule", alertApi.UpdateAlertRule)          // 更新告警规则
+		alertRouter.DELETE("deleteAlertRule", alertApi.DeleteAlertRule)       // 删除告警规则
+		alertRouter.POST("createAlertChannel", alertApi.CreateAlertChannel)   // 新建通知渠道
+		alertRouter.PUT("updateAlertChannel", alertApi.UpdateAlertChannel)    // 更新通知渠道
+		alertRouter.DELETE("deleteAlertChannel", alertApi.DeleteAlertChannel) // 删除通知渠道
+		alertRouter.POST("testAlertChannel", alertApi.TestAlertChannel)       // 发送测试通知
+		alertRouter.POST("createAlertSilence", alertApi.CreateAlertSilence)   // 新建静默
+		alertRouter.DELETE("deleteAlertSilence", alertApi.DeleteAlertSilence) // 删除静默
+	}
+	{
+		alertRouterWithoutRecord.GET("getAlertRuleList", alertApi.GetAlertRuleList)       // 获取告警规则列表
+		alertRouterWithoutRecord.GET("getAlertChannelList", alertApi.GetAlertChannelList) // 获取通知渠道列表
+		alertRouterWithoutRecord.GET("getAlertSilenceList", alertApi.GetAlertSilenceList) // 获取静默列表
+		alertRouterWithoutRecord.GET("getAlertEventList", alertApi.GetAlertEventList)     // 获取告警历史
+	}
+}
@@ -0,0 +1,7 @@
+package alert
+
+import api "github.com/flipped-aurora/gin-vue-admin/server/api/v1"
+
+type RouterGroup struct{ AlertRouter }
+
+var alertApi = api.ApiGroupApp.AlertApiGroup.AlertApi
@@ -17,10 +17,19 @@ func (s *ComputeNodeRouter) InitComputeNodeRouter(Router *gin.RouterGroup,Public
 		computeNodeRouter.DELETE("deleteComputeNode", computeNodeApi.DeleteComputeNode) // 删除算力节点
 		computeNodeRouter.DELETE("deleteComputeNodeByIds", computeNodeApi.DeleteComputeNodeByIds) // 批量删除算力节点
 		computeNodeRouter.PUT("updateComputeNode", computeNodeApi.UpdateComputeNode)    // 更新算力节点
+		computeNodeRouter.POST("prePullImage", computeNodeApi.PrePullImage)             // 预拉取镜像到节点
+		computeNodeRouter.POST("cordonNode", computeNodeApi.CordonNode)                 // 隔离节点
+		computeNodeRouter.POST("uncordonNode", computeNodeApi.UncordonNode)             // 解除节点隔离
+		computeNodeRouter.POST("drainNode", computeNodeApi.DrainNode)                   // 排空节点
+		computeNodeRouter.POST("refreshNodeInventory", computeNodeApi.RefreshNodeInventory) // 立即采集节点硬件信息
+		computeNodeRouter.POST("reconcileNodes", computeNodeApi.ReconcileNodes)         // 对账节点孤儿容器与数据卷
 	}
 	{
 		computeNodeRouterWithoutRecord.GET("findComputeNode", computeNodeApi.FindComputeNode)        // 根据ID获取算力节点
 		computeNodeRouterWithoutRecord.GET("getComputeNodeList", computeNodeApi.GetComputeNodeList)  // 获取算力节点列表
+		computeNodeRouterWithoutRecord.GET("getNodeImageList", computeNodeApi.GetNodeImageList)      // 获取节点镜像缓存清单
+		computeNodeRouterWithoutRecord.GET("getNodeMaintenance", computeNodeApi.GetNodeMaintenance)  // 查询节点维护状态
+		computeNodeRouterWithoutRecord.GET("getNodeInventory", computeNodeApi.GetNodeInventory)      // 查询节点硬件清单
 	}
 	{
 	    computeNodeRouterWithoutAuth.GET("getComputeNodePublic", computeNodeApi.GetComputeNodePublic)  // 算力节点开放接口
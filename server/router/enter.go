@@ -1,12 +1,16 @@
 package router
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/router/alert"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/router/idlepolicy"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/imageregistry"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/instance"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/router/quota"
 	"github.com/flipped-aurora/gin-vue-admin/server/router/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/router/wallet"
 )
 
 var RouterGroupApp = new(RouterGroup)
@@ -18,4 +22,8 @@ type RouterGroup struct {
 	Computenode   computenode.RouterGroup
 	Product       product.RouterGroup
 	Instance      instance.RouterGroup
+	Wallet        wallet.RouterGroup
+	Quota         quota.RouterGroup
+	Idlepolicy    idlepolicy.RouterGroup
+	Alert         alert.RouterGroup
 }
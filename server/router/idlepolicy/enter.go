@@ -0,0 +1,7 @@
+package idlepolicy
+
+import api "github.com/flipped-aurora/gin-vue-admin/server/api/v1"
+
+type RouterGroup struct{ IdlePolicyRouter }
+
+var idlePolicyApi = api.ApiGroupApp.IdlepolicyApiGroup.IdlePolicyApi
@@ -0,0 +1,22 @@
+package idlepolicy
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type IdlePolicyRouter struct{}
+
+// InitIdlePolicyRouter 初始化 空闲停止策略 路由信息
+func (s *IdlePolicyRouter) InitIdlePolicyRouter(Router *gin.RouterGroup, PublicRouter *gin.RouterGroup) {
+	idlePolicyRouter := Router.Group("idlePolicy").Use(middleware.OperationRecord())
+	idlePolicyRouterWithoutRecord := Router.Group("idlePolicy")
+	{
+		idlePolicyRouter.POST("setIdlePolicy", idlePolicyApi.SetIdlePolicy)         // 设置空闲停止策略
+		idlePolicyRouter.DELETE("deleteIdlePolicy", idlePolicyApi.DeleteIdlePolicy) // 删除空闲停止策略
+	}
+	{
+		idlePolicyRouterWithoutRecord.GET("getIdlePolicyList", idlePolicyApi.GetIdlePolicyList)         // 获取空闲停止策略列表
+		idlePolicyRouterWithoutRecord.GET("getIdleStopRecordList", idlePolicyApi.GetIdleStopRecordList) // 获取空闲停止审计记录
+	}
+}
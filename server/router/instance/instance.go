@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
 	"github.com/gin-gonic/gin"
 )
@@ -17,19 +18,42 @@ func (s *InstanceRouter) InitInstanceRouter(Router *gin.RouterGroup, PublicRoute
 		instanceRouter.DELETE("deleteInstance", instanceApi.DeleteInstance)           // 删除实例管理
 		instanceRouter.DELETE("deleteInstanceByIds", instanceApi.DeleteInstanceByIds) // 批量删除实例管理
 		instanceRouter.PUT("updateInstance", instanceApi.UpdateInstance)              // 更新实例管理
+		instanceRouter.POST("saveInstanceImage", instanceApi.SaveInstanceImage)       // 实例保存为镜像
+		instanceRouter.POST("resizeInstance", instanceApi.ResizeInstance)             // 实例变更规格
+		instanceRouter.POST("setIdleStopExempt", instanceApi.SetIdleStopExempt)       // 设置空闲自动停止豁免
+		instanceRouter.POST("migrateInstance", instanceApi.MigrateInstance)           // 实例迁移到其他节点
 	}
 	{
-		instanceRouterWithoutRecord.GET("findInstance", instanceApi.FindInstance)           // 根据ID获取实例管理
-		instanceRouterWithoutRecord.GET("getInstanceList", instanceApi.GetInstanceList)     // 获取实例管理列表
-		instanceRouterWithoutRecord.GET("getAvailableNodes", instanceApi.GetAvailableNodes) // 根据产品规格获取可用节点
-		instanceRouterWithoutRecord.POST("startContainer", instanceApi.StartContainer)      // 启动容器
-		instanceRouterWithoutRecord.POST("stopContainer", instanceApi.StopContainer)        // 停止容器
-		instanceRouterWithoutRecord.POST("restartContainer", instanceApi.RestartContainer)  // 重启容器
-		instanceRouterWithoutRecord.GET("getContainerLogs", instanceApi.GetContainerLogs)   // 获取容器日志
-		instanceRouterWithoutRecord.GET("terminal", instanceApi.ContainerTerminal)          // 容器终端WebSocket
+		instanceRouterWithoutRecord.GET("findInstance", instanceApi.FindInstance)                       // 根据ID获取实例管理
+		instanceRouterWithoutRecord.GET("getInstanceList", instanceApi.GetInstanceList)                 // 获取实例管理列表
+		instanceRouterWithoutRecord.GET("getAvailableNodes", instanceApi.GetAvailableNodes)             // 根据产品规格获取可用节点
+		instanceRouterWithoutRecord.POST("simulateSchedule", instanceApi.SimulateSchedule)              // 调度模拟（不写库）
+		instanceRouterWithoutRecord.GET("getSchedulingLogList", instanceApi.GetSchedulingLogList)       // 查询调度决策记录
+		instanceRouterWithoutRecord.GET("getSchedulerBreaker", instanceApi.GetSchedulerBreaker)         // 查询调度熔断状态
+		instanceRouterWithoutRecord.POST("startContainer", instanceApi.StartContainer)                  // 启动容器
+		instanceRouterWithoutRecord.POST("stopContainer", instanceApi.StopContainer)                    // 停止容器
+		instanceRouterWithoutRecord.POST("restartContainer", instanceApi.RestartContainer)              // 重启容器
+		instanceRouterWithoutRecord.GET("getContainerLogs", instanceApi.GetContainerLogs)               // 获取容器日志
+		instanceRouterWithoutRecord.GET("terminal", instanceApi.ContainerTerminal)                      // 容器终端WebSocket
+		instanceRouterWithoutRecord.GET("getProvisionProgress", instanceApi.GetProvisionProgress)       // 查询实例创建进度
+		instanceRouterWithoutRecord.GET("getUsageReport", instanceApi.GetUsageReport)                   // 获取月度用量报表
+		instanceRouterWithoutRecord.GET("exportUsageReport", instanceApi.ExportUsageReport)             // 导出月度用量报表
+		instanceRouterWithoutRecord.GET("getInstanceMetrics", instanceApi.GetInstanceMetrics)           // 查询实例监控历史
+		instanceRouterWithoutRecord.GET("getNodeMetrics", instanceApi.GetNodeMetrics)                   // 查询节点监控历史
+		instanceRouterWithoutRecord.GET("getNodeGpuTelemetry", instanceApi.GetNodeGpuTelemetry)         // 查询节点显卡最新遥测
+		instanceRouterWithoutRecord.GET("getNodeGpuHistory", instanceApi.GetNodeGpuHistory)             // 查询节点显卡遥测历史
+		instanceRouterWithoutRecord.GET("getInstanceGpuTelemetry", instanceApi.GetInstanceGpuTelemetry) // 查询实例显卡遥测
 	}
 	{
 		instanceRouterWithoutAuth.GET("getInstanceDataSource", instanceApi.GetInstanceDataSource) // 获取实例管理数据源
 		instanceRouterWithoutAuth.GET("getInstancePublic", instanceApi.GetInstancePublic)         // 实例管理开放接口
 	}
+	// Prometheus 指标包含用户名、实例名与节点名，未配置 Bearer Token 时不注册
+	if cfg := global.GVA_CONFIG.Metrics; cfg.Enabled {
+		if cfg.BearerToken == "" {
+			global.GVA_LOG.Warn("metrics.bearer-token 未配置，/metrics 接口不开放")
+		} else {
+			PublicRouter.GET("metrics", instanceApi.PrometheusMetrics) // Prometheus 指标，需 Bearer Token
+		}
+	}
 }
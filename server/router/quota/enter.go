@@ -0,0 +1,7 @@
+package quota
+
+import api "github.com/flipped-aurora/gin-vue-admin/server/api/v1"
+
+type RouterGroup struct{ ResourceQuotaRouter }
+
+var resourceQuotaApi = api.ApiGroupApp.QuotaApiGroup.ResourceQuotaApi
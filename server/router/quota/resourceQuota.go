@@ -0,0 +1,22 @@
+package quota
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type ResourceQuotaRouter struct{}
+
+// InitResourceQuotaRouter 初始化 资源配额 路由信息
+func (s *ResourceQuotaRouter) InitResourceQuotaRouter(Router *gin.RouterGroup, PublicRouter *gin.RouterGroup) {
+	quotaRouter := Router.Group("quota").Use(middleware.OperationRecord())
+	quotaRouterWithoutRecord := Router.Group("quota")
+	{
+		quotaRouter.POST("setResourceQuota", resourceQuotaApi.SetResourceQuota)         // 设置资源配额
+		quotaRouter.DELETE("deleteResourceQuota", resourceQuotaApi.DeleteResourceQuota) // 删除资源配额
+	}
+	{
+		quotaRouterWithoutRecord.GET("getResourceQuotaList", resourceQuotaApi.GetResourceQuotaList) // 获取资源配额列表
+		quotaRouterWithoutRecord.GET("getQuotaUsage", resourceQuotaApi.GetQuotaUsage)               // 获取配额与当前用量
+	}
+}
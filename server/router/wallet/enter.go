@@ -0,0 +1,7 @@
+package wallet
+
+import api "github.com/flipped-aurora/gin-vue-admin/server/api/v1"
+
+type RouterGroup struct{ WalletRouter }
+
+var walletApi = api.ApiGroupApp.WalletApiGroup.WalletApi
@@ -0,0 +1,22 @@
+package wallet
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type WalletRouter struct{}
+
+// InitWalletRouter 初始化 用户钱包 路由信息
+func (s *WalletRouter) InitWalletRouter(Router *gin.RouterGroup, PublicRouter *gin.RouterGroup) {
+	walletRouter := Router.Group("wallet").Use(middleware.OperationRecord())
+	walletRouterWithoutRecord := Router.Group("wallet")
+	{
+		walletRouter.POST("recharge", walletApi.Recharge) // 充值
+		walletRouter.POST("deduct", walletApi.Deduct)     // 手工扣减
+	}
+	{
+		walletRouterWithoutRecord.GET("getWallet", walletApi.GetWallet)                               // 获取钱包余额
+		walletRouterWithoutRecord.GET("getWalletTransactionList", walletApi.GetWalletTransactionList) // 获取钱包流水
+	}
+}
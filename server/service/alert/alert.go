@@ -0,0 +1,193 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	alertReq "github.com/flipped-aurora/gin-vue-admin/server/model/alert/request"
+	"gorm.io/gorm"
+)
+
+type AlertService struct{}
+
+// checkChannelIds 校验规则引用的通知渠道存在
+func checkChannelIds(db *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&alertModel.AlertChannel{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(ids) {
+		return errors.New("通知渠道不存在")
+	}
+	return nil
+}
+
+// CreateAlertRule 创建告警规则
+func (alertService *AlertService) CreateAlertRule(ctx context.Context, rule *alertModel.AlertRule) (err error) {
+	if err = checkChannelIds(global.GVA_DB, rule.ChannelIds); err != nil {
+		return err
+	}
+	return global.GVA_DB.Create(rule).Error
+}
+
+// UpdateAlertRule 更新告警规则（全量覆盖，便于关闭布尔开关）
+func (alertService *AlertService) UpdateAlertRule(ctx context.Context, rule alertModel.AlertRule) (err error) {
+	if err = checkChannelIds(global.GVA_DB, rule.ChannelIds); err != nil {
+		return err
+	}
+	return global.GVA_DB.Model(&alertModel.AlertRule{}).Where("id = ?", rule.ID).
+		Select("*").Omit("id", "created_at", "deleted_at").Updates(&rule).Error
+}
+
+// DeleteAlertRule 删除告警规则，该规则未恢复的告警一并标记恢复
+func (alertService *AlertService) DeleteAlertRule(ctx context.Context, ID string) (err error) {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&alertModel.AlertRule{}, "id = ?", ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&alertModel.AlertEvent{}).Where("rule_id = ? AND status = ?", ID, alertModel.StatusFiring).
+			Updates(map[string]any{"status": alertModel.StatusResolved, "ends_at": time.Now()}).Error
+	})
+}
+
+// GetAlertRuleList 分页获取告警规则
+func (alertService *AlertService) GetAlertRuleList(ctx context.Context, info alertReq.AlertRuleSearch) (list []alertModel.AlertRule, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&alertModel.AlertRule{})
+	if info.Type != nil && *info.Type != "" {
+		db = db.Where("type = ?", *info.Type)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id").Find(&list).Error
+	return
+}
+
+// CreateAlertChannel 创建通知渠道
+func (alertService *AlertService) CreateAlertChannel(ctx context.Context, ch *alertModel.AlertChannel) (err error) {
+	return global.GVA_DB.Create(ch).Error
+}
+
+// UpdateAlertChannel 更新通知渠道（全量覆盖）
+func (alertService *AlertService) UpdateAlertChannel(ctx context.Context, ch alertModel.AlertChannel) (err error) {
+	return global.GVA_DB.Model(&alertModel.AlertChannel{}).Where("id = ?", ch.ID).
+		Select("*").Omit("id", "created_at", "deleted_at").Updates(&ch).Error
+}
+
+// DeleteAlertChannel 删除通知渠道，仍被规则引用时拒绝删除
+func (alertService *AlertService) DeleteAlertChannel(ctx context.Context, ID string) (err error) {
+	var ch alertModel.AlertChannel
+	if err = global.GVA_DB.Where("id = ?", ID).First(&ch).Error; err != nil {
+		return err
+	}
+	var rules []alertModel.AlertRule
+	if err = global.GVA_DB.Select("id", "name", "channel_ids").Find(&rules).Error; err != nil {
+		return err
+	}
+	for _, r := range rules {
+		for _, id := range r.ChannelIds {
+			if id == ch.ID {
+				return fmt.Errorf("渠道仍被告警规则 %s 使用", r.Name)
+			}
+		}
+	}
+	return global.GVA_DB.Delete(&ch).Error
+}
+
+// GetAlertChannel 根据ID获取通知渠道
+func (alertService *AlertService) GetAlertChannel(ctx context.Context, ID string) (ch alertModel.AlertChannel, err error) {
+	err = global.GVA_DB.Where("id = ?", ID).First(&ch).Error
+	return
+}
+
+// GetAlertChannelList 分页获取通知渠道
+func (alertService *AlertService) GetAlertChannelList(ctx context.Context, info alertReq.AlertChannelSearch) (list []alertModel.AlertChannel, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&alertModel.AlertChannel{})
+	if info.Type != nil && *info.Type != "" {
+		db = db.Where("type = ?", *info.Type)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id").Find(&list).Error
+	return
+}
+
+// CreateAlertSilence 创建告警静默
+func (alertService *AlertService) CreateAlertSilence(ctx context.Context, s *alertModel.AlertSilence) (err error) {
+	return global.GVA_DB.Create(s).Error
+}
+
+// DeleteAlertSilence 删除告警静默
+func (alertService *AlertService) DeleteAlertSilence(ctx context.Context, ID string) (err error) {
+	return global.GVA_DB.Delete(&alertModel.AlertSilence{}, "id = ?", ID).Error
+}
+
+// GetAlertSilenceList 分页获取告警静默
+func (alertService *AlertService) GetAlertSilenceList(ctx context.Context, info alertReq.AlertSilenceSearch) (list []alertModel.AlertSilence, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&alertModel.AlertSilence{})
+	if info.Active != nil && *info.Active {
+		now := time.Now()
+		db = db.Where("starts_at <= ? AND ends_at > ?", now, now)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("ends_at desc").Find(&list).Error
+	return
+}
+
+// GetAlertEventList 分页获取告警历史，按触发时间倒序
+func (alertService *AlertService) GetAlertEventList(ctx context.Context, info alertReq.AlertEventSearch) (list []alertModel.AlertEvent, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&alertModel.AlertEvent{})
+	if info.RuleId != nil {
+		db = db.Where("rule_id = ?", *info.RuleId)
+	}
+	if info.Status != nil && *info.Status != "" {
+		db = db.Where("status = ?", *info.Status)
+	}
+	if info.Severity != nil && *info.Severity != "" {
+		db = db.Where("severity = ?", *info.Severity)
+	}
+	if info.TargetType != nil && *info.TargetType != "" {
+		db = db.Where("target_type = ?", *info.TargetType)
+	}
+	if info.TargetId != nil {
+		db = db.Where("target_id = ?", *info.TargetId)
+	}
+	if len(info.TimeRange) == 2 {
+		db = db.Where("starts_at BETWEEN ? AND ?", info.TimeRange[0], info.TimeRange[1])
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("starts_at desc, id desc").Find(&list).Error
+	return
+}
@@ -0,0 +1,103 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	notifyQueueSize = 256
+	// notifyJobTimeout 一条告警向全部渠道发送的总时长上限
+	notifyJobTimeout = 30 * time.Second
+	// notifyChannelTimeout 单个渠道的发送超时
+	notifyChannelTimeout = 10 * time.Second
+)
+
+// notifyJob 一条待发送的告警通知
+type notifyJob struct {
+	eventID  uint
+	msg      AlertMessage
+	channels []alertModel.AlertChannel
+	at       time.Time
+}
+
+var (
+	notifyQueue     = make(chan notifyJob, notifyQueueSize)
+	notifyStartOnce sync.Once
+	// notifyInFlight 排队或发送中的告警ID，同一告警不重复入队
+	notifyInFlight sync.Map
+)
+
+// enqueueNotify 将告警通知交给后台协程发送，评估与健康检查不等待网络请求
+// 队列已满或该告警已在发送中时放弃本次，未记录通知时间的告警下一轮评估会重新入队。
+func enqueueNotify(rule *alertModel.AlertRule, ev *alertModel.AlertEvent, channels map[uint]*alertModel.AlertChannel, now time.Time) {
+	notifyStartOnce.Do(func() { go notifyWorker() })
+	if _, loaded := notifyInFlight.LoadOrStore(ev.ID, struct{}{}); loaded {
+		return
+	}
+	job := notifyJob{eventID: ev.ID, msg: alertMessage(ev), at: now}
+	for _, id := range rule.ChannelIds {
+		if ch, ok := channels[id]; ok {
+			job.channels = append(job.channels, *ch)
+		}
+	}
+	select {
+	case notifyQueue <- job:
+	default:
+		notifyInFlight.Delete(ev.ID)
+		global.GVA_LOG.Warn("告警通知队列已满，本次通知延后", zap.Uint("eventId", ev.ID))
+	}
+}
+
+func notifyWorker() {
+	for job := range notifyQueue {
+		deliverNotify(job)
+		notifyInFlight.Delete(job.eventID)
+	}
+}
+
+// deliverNotify 向各渠道发送通知并记录结果；全部失败时不记录通知时间，下一轮评估重试
+func deliverNotify(job notifyJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyJobTimeout)
+	defer cancel()
+	var errs []error
+	sent := 0
+	for i := range job.channels {
+		ch := &job.channels[i]
+		sendCtx, sendCancel := context.WithTimeout(ctx, notifyChannelTimeout)
+		err := sendToChannel(sendCtx, ch, job.msg)
+		sendCancel()
+		if err != nil {
+			global.GVA_LOG.Warn("发送告警通知失败", zap.String("channel", ch.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %v", ch.Name, err))
+			continue
+		}
+		sent++
+	}
+
+	notifyErr := ""
+	if err := errors.Join(errs...); err != nil {
+		notifyErr = err.Error()
+		if len(notifyErr) > 1000 {
+			notifyErr = notifyErr[:1000]
+		}
+	}
+	updates := map[string]any{"notify_error": notifyErr}
+	if sent > 0 || len(errs) == 0 {
+		updates["last_notified_at"] = job.at
+	}
+	if sent > 0 {
+		updates["notify_count"] = gorm.Expr("notify_count + ?", 1)
+	}
+	if err := global.GVA_DB.Model(&alertModel.AlertEvent{}).Where("id = ?", job.eventID).Updates(updates).Error; err != nil {
+		global.GVA_LOG.Error("记录告警通知结果失败", zap.Uint("id", job.eventID), zap.Error(err))
+	}
+}
@@ -0,0 +1,404 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"go.uber.org/zap"
+)
+
+const (
+	// diskDataFresh 节点磁盘使用率在该时长内视为有效（每5分钟采集一次）
+	diskDataFresh = 30 * time.Minute
+	// gpuDataFresh 显卡遥测在该时长内视为有效（每轮健康检查采集）
+	gpuDataFresh = 5 * time.Minute
+)
+
+// 单个指纹本轮的处理动作
+const (
+	alertActionNone    = ""
+	alertActionFire    = "fire"    // 新触发，创建告警并通知
+	alertActionNotify  = "notify"  // 未恢复，到达重复通知间隔或此前被静默未通知
+	alertActionUpdate  = "update"  // 未恢复，只刷新指标值
+	alertActionResolve = "resolve" // 已恢复
+)
+
+// alertSignal 一条规则对一个对象的本轮评估结果
+type alertSignal struct {
+	targetType string
+	targetId   uint
+	subKey     string // 同一对象下的细分，如显卡序号
+	targetName string
+	value      float64
+	firing     bool
+	message    string
+}
+
+func fingerprint(ruleID uint, s alertSignal) string {
+	return fmt.Sprintf("%d:%s:%d:%s", ruleID, s.targetType, s.targetId, s.subKey)
+}
+
+// alertSnapshot 一轮评估所需的节点、显卡与实例状态
+type alertSnapshot struct {
+	now       time.Time
+	nodes     []computenode.ComputeNode
+	gpus      []computenode.NodeGpuTelemetry
+	instances []instanceModel.Instance
+	// unexpectedExit 已退出且最近一次状态变化由巡检发现（而不是用户或系统主动停止）的实例
+	unexpectedExit map[uint]bool
+}
+
+// pendingCycles 各指纹连续满足条件的轮数，只保存在内存中，重启后重新计数
+var pendingCycles = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+func safeStr(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// buildSignals 按规则类型生成各对象的评估结果，没有有效数据的对象不生成
+func buildSignals(rule *alertModel.AlertRule, snap *alertSnapshot) []alertSignal {
+	var sigs []alertSignal
+	over := func(v float64) bool { return v > rule.Threshold }
+	nodeNames := make(map[uint]string, len(snap.nodes))
+	for _, n := range snap.nodes {
+		nodeNames[n.ID] = safeStr(n.Name)
+	}
+
+	switch rule.Type {
+	case alertModel.RuleNodeDockerDown:
+		for _, n := range snap.nodes {
+			down := safeStr(n.DockerStatus) == "failed"
+			s := alertSignal{targetType: alertModel.TargetNode, targetId: n.ID, targetName: safeStr(n.Name), firing: down,
+				message: fmt.Sprintf("节点 %s Docker 连接失败", safeStr(n.Name))}
+			if down {
+				s.value = 1
+			}
+			sigs = append(sigs, s)
+		}
+	case alertModel.RuleNodeDiskUsage:
+		for _, n := range snap.nodes {
+			if n.DiskUsedPercent == nil || n.DiskCheckedAt == nil || snap.now.Sub(*n.DiskCheckedAt) > diskDataFresh {
+				continue
+			}
+			v := *n.DiskUsedPercent
+			sigs = append(sigs, alertSignal{targetType: alertModel.TargetNode, targetId: n.ID, targetName: safeStr(n.Name), value: v, firing: over(v),
+				message: fmt.Sprintf("节点 %s Docker 数据盘使用率 %.1f%%，阈值 %.1f%%", safeStr(n.Name), v, rule.Threshold)})
+		}
+	case alertModel.RuleGpuTemperature, alertModel.RuleGpuEccUncorrected:
+		for _, g := range snap.gpus {
+			if snap.now.Sub(g.CollectedAt) > gpuDataFresh {
+				continue
+			}
+			name := fmt.Sprintf("%s GPU#%d", nodeNames[g.NodeId], g.GpuIndex)
+			s := alertSignal{targetType: alertModel.TargetGpu, targetId: g.NodeId, subKey: strconv.Itoa(g.GpuIndex), targetName: name}
+			if rule.Type == alertModel.RuleGpuTemperature {
+				if g.TemperatureC == nil {
+					continue
+				}
+				s.value = *g.TemperatureC
+				s.message = fmt.Sprintf("%s 温度 %.0f℃，阈值 %.0f℃", name, s.value, rule.Threshold)
+			} else {
+				if g.EccUncorrected == nil {
+					continue
+				}
+				s.value = float64(*g.EccUncorrected)
+				s.message = fmt.Sprintf("%s 不可纠正ECC错误 %.0f 个，阈值 %.0f", name, s.value, rule.Threshold)
+			}
+			s.firing = over(s.value)
+			sigs = append(sigs, s)
+		}
+	case alertModel.RuleInstanceExited:
+		for _, inst := range snap.instances {
+			exited := snap.unexpectedExit[inst.ID]
+			s := alertSignal{targetType: alertModel.TargetInstance, targetId: inst.ID, targetName: safeStr(inst.Name), firing: exited,
+				message: fmt.Sprintf("实例 %s 容器意外退出（状态 %s）", safeStr(inst.Name), safeStr(inst.ContainerStatus))}
+			if exited {
+				s.value = 1
+			}
+			sigs = append(sigs, s)
+		}
+	case alertModel.RuleInstanceGpuMemory, alertModel.RuleInstanceCpu, alertModel.RuleInstanceMemory:
+		label := map[string]string{
+			alertModel.RuleInstanceGpuMemory: "GPU显存使用率",
+			alertModel.RuleInstanceCpu:       "CPU使用率",
+			alertModel.RuleInstanceMemory:    "内存使用率",
+		}[rule.Type]
+		for _, inst := range snap.instances {
+			if safeStr(inst.ContainerStatus) != "running" {
+				continue
+			}
+			var p *float64
+			switch rule.Type {
+			case alertModel.RuleInstanceGpuMemory:
+				p = inst.GpuMemoryUsageRate
+			case alertModel.RuleInstanceCpu:
+				p = inst.CpuUsagePercent
+			default:
+				p = inst.MemoryUsagePercent
+			}
+			if p == nil {
+				continue
+			}
+			sigs = append(sigs, alertSignal{targetType: alertModel.TargetInstance, targetId: inst.ID, targetName: safeStr(inst.Name), value: *p, firing: over(*p),
+				message: fmt.Sprintf("实例 %s %s %.1f%%，阈值 %.1f%%", safeStr(inst.Name), label, *p, rule.Threshold)})
+		}
+	}
+	return sigs
+}
+
+// decideAlert 推进一个指纹的状态：sig 为 nil 表示本轮没有该对象的数据，open 为未恢复的告警
+// 返回新的连续满足轮数与本轮动作。
+func decideAlert(rule *alertModel.AlertRule, sig *alertSignal, open *alertModel.AlertEvent, pending int, now time.Time) (int, string) {
+	if sig == nil || !sig.firing {
+		if open != nil {
+			return 0, alertActionResolve
+		}
+		return 0, alertActionNone
+	}
+	pending++
+	if open == nil {
+		if pending >= rule.ForCycles {
+			return pending, alertActionFire
+		}
+		return pending, alertActionNone
+	}
+	if open.LastNotifiedAt == nil ||
+		(rule.RepeatMinutes > 0 && now.Sub(*open.LastNotifiedAt) >= time.Duration(rule.RepeatMinutes)*time.Minute) {
+		return pending, alertActionNotify
+	}
+	return pending, alertActionUpdate
+}
+
+// isSilenced 告警是否命中生效中的静默
+func isSilenced(silences []alertModel.AlertSilence, ruleID uint, sig alertSignal, now time.Time) bool {
+	for _, s := range silences {
+		if now.Before(s.StartsAt) || !now.Before(s.EndsAt) {
+			continue
+		}
+		if (s.RuleId == 0 || s.RuleId == ruleID) &&
+			(s.TargetType == "" || s.TargetType == sig.targetType) &&
+			(s.TargetId == 0 || s.TargetId == sig.targetId) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAlertSnapshot 读取本轮评估需要的状态
+func loadAlertSnapshot(now time.Time) (*alertSnapshot, error) {
+	snap := &alertSnapshot{now: now, unexpectedExit: make(map[uint]bool)}
+	if err := global.GVA_DB.Order("id").Find(&snap.nodes).Error; err != nil {
+		return nil, fmt.Errorf("查询算力节点失败: %v", err)
+	}
+	if err := global.GVA_DB.Order("node_id, gpu_index").Find(&snap.gpus).Error; err != nil {
+		return nil, fmt.Errorf("查询显卡遥测失败: %v", err)
+	}
+	if err := global.GVA_DB.Where("container_id IS NOT NULL AND container_id <> ''").Order("id").Find(&snap.instances).Error; err != nil {
+		return nil, fmt.Errorf("查询实例失败: %v", err)
+	}
+
+	var exitedIDs []uint
+	for _, inst := range snap.instances {
+		switch safeStr(inst.ContainerStatus) {
+		case "exited", "dead":
+			exitedIDs = append(exitedIDs, inst.ID)
+		case "missing":
+			// 对账发现容器已不存在
+			snap.unexpectedExit[inst.ID] = true
+		}
+	}
+	if len(exitedIDs) > 0 {
+		// 最近一次计量流水为巡检发现的状态变化时视为意外退出；主动停止会写入 stop 流水
+		var last []instanceModel.UsageRecord
+		if err := global.GVA_DB.Where("id IN (?)", global.GVA_DB.Model(&instanceModel.UsageRecord{}).
+			Select("MAX(id)").Where("instance_id IN ?", exitedIDs).Group("instance_id")).
+			Find(&last).Error; err != nil {
+			return nil, fmt.Errorf("查询计量流水失败: %v", err)
+		}
+		for _, r := range last {
+			if r.Event == "status_change" {
+				snap.unexpectedExit[r.InstanceId] = true
+			}
+		}
+	}
+	return snap, nil
+}
+
+// EvaluateAlertRules 评估所有启用的告警规则，去重后发送通知并记录告警历史，由健康检查定时任务在每轮巡检后调用
+func EvaluateAlertRules(ctx context.Context) {
+	now := time.Now()
+	var rules []alertModel.AlertRule
+	if err := global.GVA_DB.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		global.GVA_LOG.Error("查询告警规则失败", zap.Error(err))
+		return
+	}
+	var openEvents []alertModel.AlertEvent
+	if err := global.GVA_DB.Where("status = ?", alertModel.StatusFiring).Find(&openEvents).Error; err != nil {
+		global.GVA_LOG.Error("查询未恢复告警失败", zap.Error(err))
+		return
+	}
+	if len(rules) == 0 && len(openEvents) == 0 {
+		return
+	}
+	snap, err := loadAlertSnapshot(now)
+	if err != nil {
+		global.GVA_LOG.Error("读取告警评估数据失败", zap.Error(err))
+		return
+	}
+	var silences []alertModel.AlertSilence
+	global.GVA_DB.Where("starts_at <= ? AND ends_at > ?", now, now).Find(&silences)
+	var channels []alertModel.AlertChannel
+	global.GVA_DB.Where("enabled = ?", true).Find(&channels)
+	channelByID := make(map[uint]*alertModel.AlertChannel, len(channels))
+	for i := range channels {
+		channelByID[channels[i].ID] = &channels[i]
+	}
+
+	open := make(map[string]*alertModel.AlertEvent, len(openEvents))
+	for i := range openEvents {
+		open[openEvents[i].Fingerprint] = &openEvents[i]
+	}
+	ruleByID := make(map[uint]*alertModel.AlertRule, len(rules))
+
+	pendingCycles.Lock()
+	defer pendingCycles.Unlock()
+	seen := make(map[string]bool)
+	for i := range rules {
+		rule := &rules[i]
+		ruleByID[rule.ID] = rule
+		for _, sig := range buildSignals(rule, snap) {
+			fp := fingerprint(rule.ID, sig)
+			seen[fp] = true
+			pending, action := decideAlert(rule, &sig, open[fp], pendingCycles.counts[fp], now)
+			if pending > 0 {
+				pendingCycles.counts[fp] = pending
+			} else {
+				delete(pendingCycles.counts, fp)
+			}
+			silenced := isSilenced(silences, rule.ID, sig, now)
+			applyAlertAction(rule, sig, fp, open[fp], action, silenced, channelByID, now)
+		}
+	}
+
+	// 本轮没有数据的对象（已删除或数据过期）与规则已停用/删除的告警视为恢复
+	for fp, ev := range open {
+		if seen[fp] {
+			continue
+		}
+		rule := ruleByID[ev.RuleId]
+		sig := alertSignal{targetType: ev.TargetType, targetId: ev.TargetId, targetName: ev.TargetName, value: ev.Value}
+		if rule == nil {
+			// 规则已停用或删除，静默恢复不再通知
+			rule = &alertModel.AlertRule{}
+		}
+		applyAlertAction(rule, sig, fp, ev, alertActionResolve, isSilenced(silences, ev.RuleId, sig, now), channelByID, now)
+	}
+	for fp := range pendingCycles.counts {
+		if !seen[fp] {
+			delete(pendingCycles.counts, fp)
+		}
+	}
+}
+
+func applyAlertAction(rule *alertModel.AlertRule, sig alertSignal, fp string, ev *alertModel.AlertEvent,
+	action string, silenced bool, channels map[uint]*alertModel.AlertChannel, now time.Time) {
+	switch action {
+	case alertActionFire:
+		ev = &alertModel.AlertEvent{
+			RuleId:      rule.ID,
+			RuleName:    rule.Name,
+			RuleType:    rule.Type,
+			Severity:    rule.Severity,
+			Fingerprint: fp,
+			TargetType:  sig.targetType,
+			TargetId:    sig.targetId,
+			TargetName:  sig.targetName,
+			Status:      alertModel.StatusFiring,
+			Value:       sig.value,
+			Threshold:   rule.Threshold,
+			Message:     sig.message,
+			StartsAt:    now,
+			LastEvalAt:  now,
+			Silenced:    silenced,
+		}
+		// 先落库再通知；写入失败时不通知，下一轮重新触发
+		if err := global.GVA_DB.Create(ev).Error; err != nil {
+			global.GVA_LOG.Error("写入告警历史失败", zap.String("fingerprint", fp), zap.Error(err))
+			return
+		}
+		global.GVA_LOG.Warn("告警触发", zap.String("rule", rule.Name), zap.String("target", sig.targetName), zap.String("message", sig.message))
+		if !silenced {
+			enqueueNotify(rule, ev, channels, now)
+		}
+	case alertActionNotify, alertActionUpdate:
+		ev.Value, ev.Message, ev.LastEvalAt, ev.Silenced = sig.value, sig.message, now, silenced
+		saveAlertEvent(ev)
+		if action == alertActionNotify && !silenced {
+			enqueueNotify(rule, ev, channels, now)
+		}
+	case alertActionResolve:
+		ev.Status, ev.EndsAt, ev.LastEvalAt, ev.Silenced = alertModel.StatusResolved, &now, now, silenced
+		saveAlertEvent(ev)
+		// 只有通知过触发的告警才发送恢复通知
+		if rule.NotifyResolved && ev.LastNotifiedAt != nil && !silenced {
+			enqueueNotify(rule, ev, channels, now)
+		}
+		global.GVA_LOG.Info("告警恢复", zap.String("rule", ev.RuleName), zap.String("target", ev.TargetName))
+	}
+}
+
+// saveAlertEvent 保存评估结果，通知相关字段由通知协程写入
+func saveAlertEvent(ev *alertModel.AlertEvent) {
+	if err := global.GVA_DB.Model(&alertModel.AlertEvent{}).Where("id = ?", ev.ID).Updates(map[string]any{
+		"status":       ev.Status,
+		"value":        ev.Value,
+		"message":      ev.Message,
+		"ends_at":      ev.EndsAt,
+		"last_eval_at": ev.LastEvalAt,
+		"silenced":     ev.Silenced,
+	}).Error; err != nil {
+		global.GVA_LOG.Error("更新告警历史失败", zap.Uint("id", ev.ID), zap.Error(err))
+	}
+}
+
+var severityNames = map[string]string{
+	alertModel.SeverityInfo:     "提示",
+	alertModel.SeverityWarning:  "警告",
+	alertModel.SeverityCritical: "严重",
+}
+
+// alertMessage 生成通知内容
+func alertMessage(ev *alertModel.AlertEvent) AlertMessage {
+	tag := severityNames[ev.Severity]
+	if ev.Status == alertModel.StatusResolved {
+		tag = "已恢复"
+	}
+	lines := []string{
+		"对象：" + ev.TargetName,
+		"级别：" + severityNames[ev.Severity],
+		"内容：" + ev.Message,
+		"触发时间：" + ev.StartsAt.Format("2006-01-02 15:04:05"),
+	}
+	if ev.EndsAt != nil {
+		lines = append(lines, "恢复时间："+ev.EndsAt.Format("2006-01-02 15:04:05"))
+	}
+	return AlertMessage{
+		Title: fmt.Sprintf("[%s] %s: %s", tag, ev.RuleName, ev.TargetName),
+		Text:  strings.Join(lines, "\n"),
+		Event: *ev,
+	}
+}
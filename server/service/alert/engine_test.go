@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+func strPtr(s string) *string     { return &s }
+func floatPtr(f float64) *float64 { return &f }
+
+func TestDecideAlert(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	rule := &alertModel.AlertRule{ForCycles: 3, RepeatMinutes: 60}
+	firing := &alertSignal{firing: true}
+	normal := &alertSignal{firing: false}
+	notified := now.Add(-10 * time.Minute)
+	longAgo := now.Add(-2 * time.Hour)
+	tests := []struct {
+		name        string
+		sig         *alertSignal
+		open        *alertModel.AlertEvent
+		pending     int
+		wantPending int
+		wantAction  string
+	}{
+		{"未达到持续轮数", firing, nil, 1, 2, alertActionNone},
+		{"达到持续轮数触发", firing, nil, 2, 3, alertActionFire},
+		{"恢复正常清零", normal, nil, 2, 0, alertActionNone},
+		{"已触发未到重复间隔", firing, &alertModel.AlertEvent{LastNotifiedAt: &notified}, 3, 4, alertActionUpdate},
+		{"已触发到达重复间隔", firing, &alertModel.AlertEvent{LastNotifiedAt: &longAgo}, 3, 4, alertActionNotify},
+		{"已触发但尚未通知", firing, &alertModel.AlertEvent{}, 3, 4, alertActionNotify},
+		{"指标回落恢复", normal, &alertModel.AlertEvent{}, 5, 0, alertActionResolve},
+		{"对象无数据恢复", nil, &alertModel.AlertEvent{}, 5, 0, alertActionResolve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, action := decideAlert(rule, tt.sig, tt.open, tt.pending, now)
+			if pending != tt.wantPending || action != tt.wantAction {
+				t.Fatalf("got (%d, %q), want (%d, %q)", pending, action, tt.wantPending, tt.wantAction)
+			}
+		})
+	}
+
+	// 不重复通知
+	noRepeat := &alertModel.AlertRule{ForCycles: 1}
+	if _, action := decideAlert(noRepeat, firing, &alertModel.AlertEvent{LastNotifiedAt: &longAgo}, 1, now); action != alertActionUpdate {
+		t.Fatalf("RepeatMinutes=0 action = %q, want update", action)
+	}
+}
+
+func TestIsSilenced(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	active := func(s alertModel.AlertSilence) alertModel.AlertSilence {
+		s.StartsAt, s.EndsAt = now.Add(-time.Hour), now.Add(time.Hour)
+		return s
+	}
+	sig := alertSignal{targetType: alertModel.TargetNode, targetId: 5}
+	tests := []struct {
+		name    string
+		silence alertModel.AlertSilence
+		want    bool
+	}{
+		{"全部静默", active(alertModel.AlertSilence{}), true},
+		{"匹配规则", active(alertModel.AlertSilence{RuleId: 1}), true},
+		{"其他规则", active(alertModel.AlertSilence{RuleId: 2}), false},
+		{"匹配对象", active(alertModel.AlertSilence{TargetType: alertModel.TargetNode, TargetId: 5}), true},
+		{"对象类型不同", active(alertModel.AlertSilence{TargetType: alertModel.TargetInstance, TargetId: 5}), false},
+		{"对象ID不同", active(alertModel.AlertSilence{TargetType: alertModel.TargetNode, TargetId: 6}), false},
+		{"已过期", alertModel.AlertSilence{StartsAt: now.Add(-2 * time.Hour), EndsAt: now}, false},
+		{"未开始", alertModel.AlertSilence{StartsAt: now.Add(time.Minute), EndsAt: now.Add(time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSilenced([]alertModel.AlertSilence{tt.silence}, 1, sig, now); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSignals(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	fresh, stale := now.Add(-time.Minute), now.Add(-time.Hour)
+	node := func(id uint, name, docker string, disk *float64, checked *time.Time) computenode.ComputeNode {
+		n := computenode.ComputeNode{Name: strPtr(name), DockerStatus: strPtr(docker), DiskUsedPercent: disk, DiskCheckedAt: checked}
+		n.ID = id
+		return n
+	}
+	inst := func(id uint, status string, gpuMem *float64) instanceModel.Instance {
+		i := instanceModel.Instance{Name: strPtr("inst"), ContainerStatus: strPtr(status), GpuMemoryUsageRate: gpuMem}
+		i.ID = id
+		return i
+	}
+	temp := func(nodeID uint, idx int, c float64, at time.Time) computenode.NodeGpuTelemetry {
+		g := computenode.NodeGpuTelemetry{NodeId: nodeID, GpuIndex: idx, CollectedAt: at}
+		g.TemperatureC = &c
+		return g
+	}
+	snap := &alertSnapshot{
+		now: now,
+		nodes: []computenode.ComputeNode{
+			node(1, "n1", "failed", floatPtr(96), &fresh),
+			node(2, "n2", "connected", floatPtr(99), &stale),
+		},
+		gpus: []computenode.NodeGpuTelemetry{temp(1, 0, 90, fresh), temp(1, 1, 60, fresh), temp(2, 0, 95, stale)},
+		instances: []instanceModel.Instance{
+			inst(10, "running", floatPtr(97)),
+			inst(11, "running", nil),
+			inst(12, "exited", floatPtr(99)),
+		},
+		unexpectedExit: map[uint]bool{12: true},
+	}
+
+	tests := []struct {
+		ruleType  string
+		threshold float64
+		want      map[string]bool // 指纹 -> 是否触发
+	}{
+		{alertModel.RuleNodeDockerDown, 0, map[string]bool{"1:node:1:": true, "1:node:2:": false}},
+		// 节点2磁盘数据已过期，不参与评估
+		{alertModel.RuleNodeDiskUsage, 90, map[string]bool{"1:node:1:": true}},
+		{alertModel.RuleGpuTemperature, 85, map[string]bool{"1:gpu:1:0": true, "1:gpu:1:1": false}},
+		// 只评估运行中且有指标的实例
+		{alertModel.RuleInstanceGpuMemory, 95, map[string]bool{"1:instance:10:": true}},
+		{alertModel.RuleInstanceExited, 0, map[string]bool{"1:instance:10:": false, "1:instance:11:": false, "1:instance:12:": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ruleType, func(t *testing.T) {
+			rule := &alertModel.AlertRule{Type: tt.ruleType, Threshold: tt.threshold}
+			rule.ID = 1
+			sigs := buildSignals(rule, snap)
+			if len(sigs) != len(tt.want) {
+				t.Fatalf("signals = %d, want %d: %+v", len(sigs), len(tt.want), sigs)
+			}
+			for _, s := range sigs {
+				want, ok := tt.want[fingerprint(rule.ID, s)]
+				if !ok {
+					t.Fatalf("unexpected fingerprint %s", fingerprint(rule.ID, s))
+				}
+				if s.firing != want {
+					t.Fatalf("%s firing = %v, want %v", fingerprint(rule.ID, s), s.firing, want)
+				}
+			}
+		})
+	}
+}
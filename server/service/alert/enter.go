@@ -0,0 +1,3 @@
+package alert
+
+type ServiceGroup struct{ AlertService }
@@ -0,0 +1,196 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	alertModel "github.com/flipped-aurora/gin-vue-admin/server/model/alert"
+	emailUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/utils"
+)
+
+// AlertMessage 发送给通知渠道的告警内容
+type AlertMessage struct {
+	Title string                `json:"title"`
+	Text  string                `json:"text"`
+	Event alertModel.AlertEvent `json:"event"`
+}
+
+// Notifier 通知渠道实现，按渠道类型注册
+type Notifier interface {
+	Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error
+}
+
+var notifiers = map[string]Notifier{
+	alertModel.ChannelEmail:    emailNotifier{},
+	alertModel.ChannelWebhook:  webhookNotifier{},
+	alertModel.ChannelDingTalk: dingTalkNotifier{},
+	alertModel.ChannelWeCom:    weComNotifier{},
+	alertModel.ChannelFeishu:   feishuNotifier{},
+}
+
+// RegisterNotifier 注册或替换某类渠道的通知实现
+func RegisterNotifier(channelType string, n Notifier) {
+	notifiers[channelType] = n
+}
+
+func sendToChannel(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	n, ok := notifiers[ch.Type]
+	if !ok {
+		return fmt.Errorf("不支持的通知渠道类型: %s", ch.Type)
+	}
+	return n.Send(ctx, ch, msg)
+}
+
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON 发送 JSON 请求；机器人接口即使出错也返回200，需检查响应中的错误码
+func postJSON(ctx context.Context, target string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("构造通知请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送通知失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("通知接口返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    int    `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil {
+		if result.ErrCode != 0 {
+			return fmt.Errorf("通知接口返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+		}
+		if result.Code != 0 {
+			return fmt.Errorf("通知接口返回错误 %d: %s", result.Code, result.Msg)
+		}
+	}
+	return nil
+}
+
+type emailNotifier struct{}
+
+// Send emailUtils.Email 不支持超时，超时后放弃等待，发送协程在 SMTP 返回后自行退出
+func (emailNotifier) Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- emailUtils.Email(ch.Target, msg.Title, strings.ReplaceAll(html.EscapeString(msg.Text), "\n", "<br/>"))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("发送邮件超时: %v", ctx.Err())
+	}
+}
+
+// webhookNotifier 通用 Webhook，请求体为 AlertMessage
+type webhookNotifier struct{}
+
+func (webhookNotifier) Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	return postJSON(ctx, ch.Target, msg)
+}
+
+type dingTalkNotifier struct{}
+
+func (dingTalkNotifier) Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	target := ch.Target
+	if ch.Secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sep := "&"
+		if !strings.Contains(target, "?") {
+			sep = "?"
+		}
+		target += sep + "timestamp=" + ts + "&sign=" + url.QueryEscape(dingTalkSign(ts, ch.Secret))
+	}
+	return postJSON(ctx, target, map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": msg.Title, "text": "### " + msg.Title + "\n\n" + markdownLines(msg.Text)},
+	})
+}
+
+// dingTalkSign 钉钉机器人加签：HmacSHA256(key=secret, timestamp+"\n"+secret) 后 Base64
+func dingTalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type weComNotifier struct{}
+
+func (weComNotifier) Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	return postJSON(ctx, ch.Target, map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": "### " + msg.Title + "\n" + markdownLines(msg.Text)},
+	})
+}
+
+type feishuNotifier struct{}
+
+func (feishuNotifier) Send(ctx context.Context, ch *alertModel.AlertChannel, msg AlertMessage) error {
+	payload := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": msg.Title + "\n" + msg.Text},
+	}
+	if ch.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = ts
+		payload["sign"] = feishuSign(ts, ch.Secret)
+	}
+	return postJSON(ctx, ch.Target, payload)
+}
+
+// feishuSign 飞书机器人签名：以 timestamp+"\n"+secret 为密钥对空串做 HmacSHA256 后 Base64
+func feishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// markdownLines 机器人 markdown 需要空行或行尾两个空格才换行
+func markdownLines(text string) string {
+	return strings.ReplaceAll(text, "\n", "  \n")
+}
+
+// TestAlertChannel 向渠道发送一条测试消息
+func (alertService *AlertService) TestAlertChannel(ctx context.Context, ID string) error {
+	ch, err := alertService.GetAlertChannel(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if ch.Target == "" {
+		return errors.New("渠道未配置地址")
+	}
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, notifyChannelTimeout)
+	defer cancel()
+	return sendToChannel(ctx, &ch, AlertMessage{
+		Title: "[测试] 告警通知渠道测试",
+		Text:  fmt.Sprintf("渠道：%s\n时间：%s\n收到此消息说明通知渠道配置正确。", ch.Name, now.Format("2006-01-02 15:04:05")),
+		Event: alertModel.AlertEvent{RuleName: "测试", Status: alertModel.StatusFiring, StartsAt: now},
+	})
+}
@@ -15,6 +15,9 @@ type ComputeNodeService struct {}
 // CreateComputeNode 创建算力节点记录
 // Author [yourname](https://github.com/yourname)
 func (computeNodeService *ComputeNodeService) CreateComputeNode(ctx context.Context, computeNode *computenode.ComputeNode) (err error) {
+	if err = validateNodeScheduling(computeNode); err != nil {
+		return err
+	}
 	// 测试Docker连接
 	dockerService := instanceService.DockerService{}
 	testCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -43,6 +46,14 @@ func (computeNodeService *ComputeNodeService) CreateComputeNode(ctx context.Cont
 	return err
 }
 
+// validateNodeScheduling 校验节点标签与污点
+func validateNodeScheduling(computeNode *computenode.ComputeNode) error {
+	if err := computenode.ValidateLabels(computeNode.Labels); err != nil {
+		return err
+	}
+	return computenode.ValidateTaints(computeNode.Taints)
+}
+
 // DeleteComputeNode 删除算力节点记录
 // Author [yourname](https://github.com/yourname)
 func (computeNodeService *ComputeNodeService)DeleteComputeNode(ctx context.Context, ID string) (err error) {
@@ -60,6 +71,9 @@ func (computeNodeService *ComputeNodeService)DeleteComputeNodeByIds(ctx context.
 // UpdateComputeNode 更新算力节点记录
 // Author [yourname](https://github.com/yourname)
 func (computeNodeService *ComputeNodeService)UpdateComputeNode(ctx context.Context, computeNode computenode.ComputeNode) (err error) {
+	if err = validateNodeScheduling(&computeNode); err != nil {
+		return err
+	}
 	// 如果Docker相关配置有变化，测试Docker连接
 	if computeNode.DockerAddress != nil && *computeNode.DockerAddress != "" {
 		dockerService := instanceService.DockerService{}
@@ -78,7 +92,10 @@ func (computeNodeService *ComputeNodeService)UpdateComputeNode(ctx context.Conte
 		}
 	}
 	
-	err = global.GVA_DB.Model(&computenode.ComputeNode{}).Where("id = ?",computeNode.ID).Updates(&computeNode).Error
+	// 维护状态只能通过 cordon/drain/uncordon 修改
+	err = global.GVA_DB.Model(&computenode.ComputeNode{}).Where("id = ?",computeNode.ID).
+		Omit("maintenance_state", "drain_action", "drain_started_at", "drain_deadline", "drain_total", "drain_message").
+		Updates(&computeNode).Error
 	return err
 }
 
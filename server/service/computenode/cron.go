@@ -21,6 +21,12 @@ func CheckAllNodeDockerStatus(ctx context.Context) {
 	dockerSvc := instanceSvc.DockerService{}
 	var success, failed int
 
+	// 镜像库索引，用于刷新各节点的镜像缓存清单
+	imageIndex, err := loadRegistryImageIndex()
+	if err != nil {
+		global.GVA_LOG.Warn("读取镜像库失败，跳过节点镜像清单刷新", zap.Error(err))
+	}
+
 	for i := range nodes {
 		n := &nodes[i]
 
@@ -33,6 +39,11 @@ func CheckAllNodeDockerStatus(ctx context.Context) {
 			status := "connected"
 			_ = global.GVA_DB.Model(n).Where("id = ?", n.ID).Update("docker_status", status).Error
 			// global.GVA_LOG.Debug("Docker连接正常", zap.Uint("nodeId", n.ID), zap.String("name", safeStr(n.Name)))
+			if imageIndex != nil {
+				refreshNodeImages(ctx, n, imageIndex)
+			}
+			maybeRefreshNodeInventory(n)
+			maybeRefreshNodeDisk(n)
 			success++
 		} else {
 			status := "failed"
@@ -0,0 +1,48 @@
+package computenode
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+)
+
+// diskLastAttempt 节点最近一次发起磁盘采集的时间（含失败），用于限制采集频率
+var diskLastAttempt sync.Map
+
+// diskCheckInterval 同一节点两次采集磁盘使用率的最小间隔
+const diskCheckInterval = 5 * time.Minute
+
+// maybeRefreshNodeDisk 距上次尝试超过 diskCheckInterval 时，在后台采集节点 Docker 数据目录所在磁盘的使用率
+func maybeRefreshNodeDisk(node *model.ComputeNode) {
+	cfg := global.GVA_CONFIG.Discovery
+	if !cfg.Enabled || cfg.GpuProbeImage == "" {
+		return
+	}
+	if last, ok := diskLastAttempt.Load(node.ID); ok && time.Since(last.(time.Time)) < diskCheckInterval {
+		return
+	}
+	diskLastAttempt.Store(node.ID, time.Now())
+	n := *node
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		dockerSvc := instanceSvc.DockerService{}
+		usage, err := dockerSvc.CollectDockerDiskUsage(ctx, &n, cfg.GpuProbeImage)
+		if err != nil {
+			global.GVA_LOG.Warn("采集节点磁盘使用率失败", zap.Uint("nodeId", n.ID), zap.Error(err))
+			return
+		}
+		if err = global.GVA_DB.Model(&model.ComputeNode{}).Where("id = ?", n.ID).Updates(map[string]any{
+			"disk_total_gb":     usage.TotalGb,
+			"disk_used_percent": usage.UsedPercent,
+			"disk_checked_at":   time.Now(),
+		}).Error; err != nil {
+			global.GVA_LOG.Warn("保存节点磁盘使用率失败", zap.Uint("nodeId", n.ID), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,190 @@
+package computenode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// inventoryInFlight 正在采集硬件信息的节点，避免健康检查重复发起
+var inventoryInFlight sync.Map
+
+// memoryTolerance 内核与固件会占用部分内存，Docker 报告的内存允许比录入值少 5%
+const memoryTolerance = 0.05
+
+// maybeRefreshNodeInventory 节点 Docker 连接正常且距上次采集超过配置间隔时，在后台采集硬件信息
+func maybeRefreshNodeInventory(node *model.ComputeNode) {
+	cfg := global.GVA_CONFIG.Discovery
+	if !cfg.Enabled {
+		return
+	}
+	interval := time.Duration(cfg.IntervalMin) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	var fresh int64
+	if err := global.GVA_DB.Model(&model.ComputeNodeInventory{}).
+		Where("node_id = ? AND collected_at > ?", node.ID, time.Now().Add(-interval)).
+		Count(&fresh).Error; err != nil || fresh > 0 {
+		return
+	}
+	if _, loaded := inventoryInFlight.LoadOrStore(node.ID, struct{}{}); loaded {
+		return
+	}
+	n := *node
+	go func() {
+		defer inventoryInFlight.Delete(n.ID)
+		if _, err := collectNodeInventory(context.Background(), &n); err != nil {
+			global.GVA_LOG.Warn("采集节点硬件信息失败", zap.Uint("nodeId", n.ID), zap.Error(err))
+		}
+	}()
+}
+
+// collectNodeInventory 采集节点硬件信息，与录入值比对后写入硬件清单
+func collectNodeInventory(ctx context.Context, node *model.ComputeNode) (*model.ComputeNodeInventory, error) {
+	discoverCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	dockerSvc := instanceSvc.DockerService{}
+	hw, err := dockerSvc.DiscoverHardware(discoverCtx, node, global.GVA_CONFIG.Discovery.GpuProbeImage)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := model.ComputeNodeInventory{
+		NodeId:        node.ID,
+		CpuCores:      hw.CpuCores,
+		MemoryGb:      int64(math.Round(float64(hw.MemoryBytes) / (1 << 30))),
+		DockerVersion: hw.DockerVersion,
+		OS:            hw.OS,
+		KernelVersion: hw.KernelVersion,
+		GpuCards:      hw.GpuCards,
+		CollectedAt:   time.Now(),
+	}
+	if hw.GpuError != nil {
+		inv.GpuError = truncateRunes(hw.GpuError.Error(), 1000)
+	} else {
+		summarizeGpuCards(&inv)
+	}
+	inv.Mismatches = compareInventory(node, &inv)
+	inv.HasMismatch = len(inv.Mismatches) > 0
+
+	var existing model.ComputeNodeInventory
+	err = global.GVA_DB.Where("node_id = ?", node.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		inv.ID = existing.ID
+		inv.CreatedAt = existing.CreatedAt
+		err = global.GVA_DB.Save(&inv).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = global.GVA_DB.Create(&inv).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("保存节点硬件清单失败: %v", err)
+	}
+	for _, m := range inv.Mismatches {
+		global.GVA_LOG.Warn("节点录入配置与实际硬件不一致",
+			zap.Uint("nodeId", node.ID),
+			zap.String("field", m.Field),
+			zap.String("entered", m.Entered),
+			zap.String("discovered", m.Discovered),
+			zap.Bool("over", m.Over))
+	}
+	return &inv, nil
+}
+
+// summarizeGpuCards 由显卡明细汇总型号、数量与每卡显存（取最小值，保守核算）
+func summarizeGpuCards(inv *model.ComputeNodeInventory) {
+	inv.GpuCount = int64(len(inv.GpuCards))
+	var minMb int64
+	for i, c := range inv.GpuCards {
+		if i == 0 || c.MemoryMb < minMb {
+			minMb = c.MemoryMb
+		}
+		if inv.GpuModel == "" {
+			inv.GpuModel = c.Name
+		} else if !strings.Contains(inv.GpuModel, c.Name) {
+			inv.GpuModel += "," + c.Name
+		}
+	}
+	inv.GpuMemoryGb = int64(math.Round(float64(minMb) / 1024))
+}
+
+// compareInventory 比对录入值与采集值，未录入的字段和采集失败的显卡信息不参与比对
+func compareInventory(node *model.ComputeNode, inv *model.ComputeNodeInventory) []model.InventoryMismatch {
+	var res []model.InventoryMismatch
+	exact := func(field string, entered *int64, discovered int64) {
+		if entered == nil || *entered == discovered {
+			return
+		}
+		res = append(res, model.InventoryMismatch{
+			Field:      field,
+			Entered:    strconv.FormatInt(*entered, 10),
+			Discovered: strconv.FormatInt(discovered, 10),
+			Over:       *entered > discovered,
+		})
+	}
+	exact("cpu", node.Cpu, inv.CpuCores)
+	if node.Memory != nil && inv.MemoryGb > 0 {
+		tol := math.Max(1, float64(*node.Memory)*memoryTolerance)
+		if math.Abs(float64(*node.Memory-inv.MemoryGb)) > tol {
+			res = append(res, model.InventoryMismatch{
+				Field:      "memory",
+				Entered:    strconv.FormatInt(*node.Memory, 10),
+				Discovered: strconv.FormatInt(inv.MemoryGb, 10),
+				Over:       *node.Memory > inv.MemoryGb,
+			})
+		}
+	}
+	if inv.GpuError != "" {
+		return res
+	}
+	exact("gpuCount", node.GpuCount, inv.GpuCount)
+	if inv.GpuCount > 0 {
+		exact("memoryCapacity", node.MemoryCapacity, inv.GpuMemoryGb)
+	}
+	if name := strings.TrimSpace(safeStr(node.GpuName)); name != "" && inv.GpuModel != "" &&
+		!strings.Contains(strings.ToLower(inv.GpuModel), strings.ToLower(name)) {
+		res = append(res, model.InventoryMismatch{Field: "gpuName", Entered: name, Discovered: inv.GpuModel})
+	}
+	return res
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// GetNodeInventory 获取节点硬件清单
+func (computeNodeService *ComputeNodeService) GetNodeInventory(ctx context.Context, ID uint) (inv model.ComputeNodeInventory, err error) {
+	err = global.GVA_DB.Where("node_id = ?", ID).First(&inv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return inv, errors.New("尚未采集该节点的硬件信息")
+	}
+	return inv, err
+}
+
+// RefreshNodeInventory 立即采集节点硬件信息
+func (computeNodeService *ComputeNodeService) RefreshNodeInventory(ctx context.Context, ID uint) (*model.ComputeNodeInventory, error) {
+	var node model.ComputeNode
+	if err := global.GVA_DB.Where("id = ?", ID).First(&node).Error; err != nil {
+		return nil, fmt.Errorf("获取算力节点信息失败: %v", err)
+	}
+	if _, loaded := inventoryInFlight.LoadOrStore(node.ID, struct{}{}); loaded {
+		return nil, errors.New("该节点正在采集硬件信息，请稍后查看")
+	}
+	defer inventoryInFlight.Delete(node.ID)
+	return collectNodeInventory(ctx, &node)
+}
@@ -0,0 +1,62 @@
+package computenode
+
+import (
+	"testing"
+
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+)
+
+func TestCompareInventory(t *testing.T) {
+	i64 := func(v int64) *int64 { return &v }
+	a100 := "A100"
+	cards := []model.GpuCardInfo{{Name: "NVIDIA A100-SXM4-80GB", MemoryMb: 81920}, {Index: 1, Name: "NVIDIA A100-SXM4-80GB", MemoryMb: 81920}}
+
+	tests := []struct {
+		name     string
+		node     model.ComputeNode
+		gpuErr   string
+		wantKeys []string
+	}{
+		{
+			name: "一致",
+			node: model.ComputeNode{Cpu: i64(64), Memory: i64(512), GpuName: &a100, GpuCount: i64(2), MemoryCapacity: i64(80)},
+		},
+		{
+			name:     "录入显卡数量偏多",
+			node:     model.ComputeNode{GpuCount: i64(4), MemoryCapacity: i64(80)},
+			wantKeys: []string{"gpuCount"},
+		},
+		{
+			name:     "内存超出容差",
+			node:     model.ComputeNode{Cpu: i64(32), Memory: i64(600)},
+			wantKeys: []string{"cpu", "memory"},
+		},
+		{
+			name:     "型号不符",
+			node:     model.ComputeNode{GpuName: func() *string { s := "H100"; return &s }()},
+			wantKeys: []string{"gpuName"},
+		},
+		{
+			name:   "显卡采集失败时跳过显卡比对",
+			node:   model.ComputeNode{GpuCount: i64(8)},
+			gpuErr: "nvidia-smi not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := model.ComputeNodeInventory{CpuCores: 64, MemoryGb: 503, GpuCards: cards, GpuError: tt.gpuErr}
+			if tt.gpuErr == "" {
+				summarizeGpuCards(&inv)
+			}
+			got := compareInventory(&tt.node, &inv)
+			if len(got) != len(tt.wantKeys) {
+				t.Fatalf("mismatches = %+v, want %v", got, tt.wantKeys)
+			}
+			for i, k := range tt.wantKeys {
+				if got[i].Field != k {
+					t.Fatalf("mismatch[%d] = %s, want %s", i, got[i].Field, k)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package computenode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	computenodeReq "github.com/flipped-aurora/gin-vue-admin/server/model/computenode/request"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+)
+
+// NodeMaintenanceInstance 维护中节点上的实例
+type NodeMaintenanceInstance struct {
+	ID              uint   `json:"ID"`
+	Name            string `json:"name"`
+	UserId          int64  `json:"userId"`
+	ContainerStatus string `json:"containerStatus"`
+	ProvisionState  string `json:"provisionState"`
+}
+
+// NodeMaintenanceStatus 节点维护状态与排空进度
+type NodeMaintenanceStatus struct {
+	NodeId         uint                      `json:"nodeId"`
+	State          string                    `json:"state"` // 为空表示正常
+	DrainAction    string                    `json:"drainAction"`
+	DrainStartedAt *time.Time                `json:"drainStartedAt"`
+	DrainDeadline  *time.Time                `json:"drainDeadline"`
+	DrainTotal     int                       `json:"drainTotal"`
+	Remaining      int                       `json:"remaining"` // 仍需处理的实例数
+	Message        string                    `json:"message"`
+	Instances      []NodeMaintenanceInstance `json:"instances"`
+}
+
+// CordonNode 隔离节点：不再接受新实例，已有实例不受影响
+func (computeNodeService *ComputeNodeService) CordonNode(ctx context.Context, ID uint) error {
+	res := global.GVA_DB.Model(&model.ComputeNode{}).
+		Where("id = ? AND (maintenance_state = '' OR maintenance_state IS NULL OR maintenance_state = ?)", ID, model.MaintenanceDrained).
+		Update("maintenance_state", model.MaintenanceCordoned)
+	if res.Error != nil {
+		return fmt.Errorf("更新节点维护状态失败: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		var node model.ComputeNode
+		if err := global.GVA_DB.Select("id", "maintenance_state").Where("id = ?", ID).First(&node).Error; err != nil {
+			return fmt.Errorf("获取算力节点信息失败: %v", err)
+		}
+		if node.MaintenanceState == model.MaintenanceDraining {
+			return errors.New("节点正在排空，请先解除隔离")
+		}
+	}
+	global.GVA_LOG.Info("节点已隔离", zap.Uint("nodeId", ID))
+	return nil
+}
+
+// UncordonNode 解除隔离，同时取消尚未完成的排空
+func (computeNodeService *ComputeNodeService) UncordonNode(ctx context.Context, ID uint) error {
+	err := global.GVA_DB.Model(&model.ComputeNode{}).Where("id = ?", ID).Updates(map[string]interface{}{
+		"maintenance_state": "",
+		"drain_action":      "",
+		"drain_started_at":  nil,
+		"drain_deadline":    nil,
+		"drain_total":       0,
+		"drain_message":     "",
+	}).Error
+	if err != nil {
+		return fmt.Errorf("更新节点维护状态失败: %v", err)
+	}
+	global.GVA_LOG.Info("节点已解除隔离", zap.Uint("nodeId", ID))
+	return nil
+}
+
+// DrainNode 排空节点：隔离节点并通知实例所有者，截止时间后由 ProcessNodeDrains 停止或迁移剩余实例
+func (computeNodeService *ComputeNodeService) DrainNode(ctx context.Context, req computenodeReq.DrainNodeReq) error {
+	var node model.ComputeNode
+	if err := global.GVA_DB.Where("id = ?", req.ID).First(&node).Error; err != nil {
+		return fmt.Errorf("获取算力节点信息失败: %v", err)
+	}
+	if node.MaintenanceState == model.MaintenanceDraining {
+		return errors.New("节点正在排空")
+	}
+	instances, err := drainPendingInstances(&node, req.Action)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deadline := now.Add(time.Duration(req.DeadlineMinutes) * time.Minute)
+	// 条件更新，避免并发的排空请求重复开始
+	res := global.GVA_DB.Model(&model.ComputeNode{}).
+		Where("id = ? AND (maintenance_state IS NULL OR maintenance_state <> ?)", node.ID, model.MaintenanceDraining).
+		Updates(map[string]interface{}{
+			"maintenance_state": model.MaintenanceDraining,
+			"drain_action":      req.Action,
+			"drain_started_at":  now,
+			"drain_deadline":    deadline,
+			"drain_total":       len(instances),
+			"drain_message":     fmt.Sprintf("等待截止时间后处理 %d 个实例", len(instances)),
+		})
+	if res.Error != nil {
+		return fmt.Errorf("更新节点维护状态失败: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("节点正在排空")
+	}
+
+	actionText := "停止（数据不会删除）"
+	if req.Action == model.DrainActionMigrate {
+		actionText = "迁移到其他节点（迁移期间实例会短暂停止）"
+	}
+	byUser := make(map[int64][]string)
+	for _, inst := range instances {
+		if inst.UserId != nil {
+			byUser[*inst.UserId] = append(byUser[*inst.UserId], safeStr(inst.Name))
+		}
+	}
+	// 逐个发送邮件耗时较长，在后台发送，不阻塞请求
+	go func() {
+		for userID, names := range byUser {
+			instanceSvc.NotifyUser(uint(userID), "实例所在节点即将维护",
+				fmt.Sprintf("您的实例 %s 所在节点将进行维护，将于 %s 后被%s。如需自行处理，请在此之前保存数据或停止实例。",
+					strings.Join(names, "、"), deadline.Format("2006-01-02 15:04"), actionText))
+		}
+	}()
+	global.GVA_LOG.Info("节点开始排空",
+		zap.Uint("nodeId", node.ID),
+		zap.String("action", req.Action),
+		zap.Int("instances", len(instances)),
+		zap.Time("deadline", deadline))
+	return nil
+}
+
+// drainPendingInstances 排空仍需处理的实例：停止方式处理运行中的实例，迁移方式处理节点上所有已创建容器的实例
+// 两种方式都包含仍在创建、迁移或变更规格的实例，等其结束后再处理。
+func drainPendingInstances(node *model.ComputeNode, action string) ([]instanceModel.Instance, error) {
+	busy := instanceSvc.ProvisionInProgressStates()
+	db := global.GVA_DB.Where("node_id = ?", node.ID)
+	if action == model.DrainActionStop {
+		db = db.Where("(container_status = ? OR provision_state IN ?)", "running", busy)
+	} else {
+		db = db.Where("((container_id IS NOT NULL AND container_id <> '') OR provision_state IN ?)", busy)
+	}
+	var instances []instanceModel.Instance
+	if err := db.Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("查询节点实例失败: %v", err)
+	}
+	return instances, nil
+}
+
+// GetNodeMaintenance 查询节点维护状态与排空进度
+func (computeNodeService *ComputeNodeService) GetNodeMaintenance(ctx context.Context, ID uint) (res NodeMaintenanceStatus, err error) {
+	var node model.ComputeNode
+	if err = global.GVA_DB.Where("id = ?", ID).First(&node).Error; err != nil {
+		return res, fmt.Errorf("获取算力节点信息失败: %v", err)
+	}
+	res = NodeMaintenanceStatus{
+		NodeId:         node.ID,
+		State:          node.MaintenanceState,
+		DrainAction:    node.DrainAction,
+		DrainStartedAt: node.DrainStartedAt,
+		DrainDeadline:  node.DrainDeadline,
+		DrainTotal:     node.DrainTotal,
+		Message:        node.DrainMessage,
+	}
+	action := node.DrainAction
+	if action == "" {
+		action = model.DrainActionMigrate
+	}
+	instances, err := drainPendingInstances(&node, action)
+	if err != nil {
+		return res, err
+	}
+	if node.MaintenanceState == model.MaintenanceDraining {
+		res.Remaining = len(instances)
+	}
+	for _, inst := range instances {
+		item := NodeMaintenanceInstance{
+			ID:              inst.ID,
+			Name:            safeStr(inst.Name),
+			ContainerStatus: safeStr(inst.ContainerStatus),
+			ProvisionState:  safeStr(inst.ProvisionState),
+		}
+		if inst.UserId != nil {
+			item.UserId = *inst.UserId
+		}
+		res.Instances = append(res.Instances, item)
+	}
+	return res, nil
+}
+
+// ProcessNodeDrains 处理已到截止时间的排空节点，由定时任务调用
+// 单个实例处理失败时记录原因，下一轮继续重试；全部处理完后节点进入 drained 状态。
+func ProcessNodeDrains(ctx context.Context) {
+	var nodes []model.ComputeNode
+	if err := global.GVA_DB.Where("maintenance_state = ? AND drain_deadline <= ?", model.MaintenanceDraining, time.Now()).
+		Find(&nodes).Error; err != nil {
+		global.GVA_LOG.Error("查询排空节点失败", zap.Error(err))
+		return
+	}
+	for i := range nodes {
+		drainNode(ctx, &nodes[i])
+	}
+}
+
+func drainNode(ctx context.Context, node *model.ComputeNode) {
+	instances, err := drainPendingInstances(node, node.DrainAction)
+	if err != nil {
+		global.GVA_LOG.Error("查询排空节点实例失败", zap.Uint("nodeId", node.ID), zap.Error(err))
+		return
+	}
+	instanceService := instanceSvc.InstanceService{}
+	busy := make(map[string]bool)
+	for _, s := range instanceSvc.ProvisionInProgressStates() {
+		busy[s] = true
+	}
+	var failures []string
+	done, waiting := 0, 0
+	for _, inst := range instances {
+		var actErr error
+		switch drainStepFor(&inst, node.DrainAction, busy) {
+		case drainStepWait:
+			waiting++
+			continue
+		case drainStepMigrate:
+			actErr = migrateForDrain(ctx, &instanceService, node, &inst)
+		default:
+			actErr = instanceService.StopContainer(ctx, strconv.FormatUint(uint64(inst.ID), 10))
+		}
+		if actErr != nil {
+			global.GVA_LOG.Warn("排空节点处理实例失败", zap.Uint("nodeId", node.ID), zap.Uint("实例ID", inst.ID), zap.Error(actErr))
+			failures = append(failures, fmt.Sprintf("实例%d: %v", inst.ID, actErr))
+			continue
+		}
+		done++
+		if inst.UserId != nil {
+			result := "已停止，可在节点维护结束后重新启动"
+			if node.DrainAction == model.DrainActionMigrate {
+				result = "已迁移到其他节点"
+			}
+			instanceSvc.NotifyUser(uint(*inst.UserId), "实例已因节点维护处理", fmt.Sprintf("您的实例 %s %s。", safeStr(inst.Name), result))
+		}
+	}
+
+	state, msg := drainProgress(done, waiting, failures)
+	updates := map[string]interface{}{"drain_message": msg}
+	if state != "" {
+		updates["maintenance_state"] = state
+		global.GVA_LOG.Info("节点排空完成", zap.Uint("nodeId", node.ID), zap.Int("instances", done))
+	}
+	if err = global.GVA_DB.Model(&model.ComputeNode{}).Where("id = ? AND maintenance_state = ?", node.ID, model.MaintenanceDraining).
+		Updates(updates).Error; err != nil {
+		global.GVA_LOG.Error("更新节点排空进度失败", zap.Uint("nodeId", node.ID), zap.Error(err))
+	}
+}
+
+// 排空时对单个实例的处理方式
+const (
+	drainStepStop    = "stop"
+	drainStepMigrate = "migrate"
+	drainStepWait    = "wait" // 创建、迁移或变更规格尚未结束，等待下一轮
+)
+
+// drainStepFor 决定排空时如何处理实例，busy 为进行中的创建状态
+func drainStepFor(inst *instanceModel.Instance, action string, busy map[string]bool) string {
+	if busy[safeStr(inst.ProvisionState)] {
+		return drainStepWait
+	}
+	if action == model.DrainActionMigrate {
+		return drainStepMigrate
+	}
+	return drainStepStop
+}
+
+// drainProgress 汇总一轮排空结果：没有等待与失败的实例时节点进入 drained，否则保持排空（state 为空）并说明进度
+func drainProgress(done int, waiting int, failures []string) (state string, message string) {
+	if waiting == 0 && len(failures) == 0 {
+		return model.MaintenanceDrained, fmt.Sprintf("已处理 %d 个实例", done)
+	}
+	parts := []string{fmt.Sprintf("本轮已处理 %d 个实例", done)}
+	if waiting > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个实例正在创建或变更，完成后处理", waiting))
+	}
+	if len(failures) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个实例处理失败，将继续重试: %s", len(failures), strings.Join(failures, "; ")))
+	}
+	message = strings.Join(parts, "，")
+	if len([]rune(message)) > 1000 {
+		message = string([]rune(message)[:1000])
+	}
+	return "", message
+}
+
+// migrateForDrain 按调度打分把实例迁移到得分最高的其他节点
+func migrateForDrain(ctx context.Context, instanceService *instanceSvc.InstanceService, node *model.ComputeNode, inst *instanceModel.Instance) error {
+	if inst.SpecId == nil {
+		return errors.New("实例缺少规格信息")
+	}
+	meta := instanceSvc.SchedulingRequestMeta{Source: instanceSvc.ScheduleSourceMigrate, InstanceId: inst.ID}
+	if inst.ImageId != nil {
+		meta.ImageId = uint(*inst.ImageId)
+	}
+	nodes, err := instanceService.GetAvailableNodes(ctx, strconv.FormatInt(*inst.SpecId, 10), meta)
+	if err != nil {
+		return fmt.Errorf("查询可用节点失败: %v", err)
+	}
+	for _, n := range nodes {
+		if n.ID == node.ID {
+			continue
+		}
+		_, err = instanceService.MigrateInstance(ctx, instanceReq.MigrateInstanceReq{ID: inst.ID, TargetNodeId: n.ID})
+		return err
+	}
+	return errors.New("没有可迁移的目标节点")
+}
@@ -0,0 +1,55 @@
+package computenode
+
+import (
+	"testing"
+
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+func TestDrainStepFor(t *testing.T) {
+	str := func(s string) *string { return &s }
+	busy := map[string]bool{"pulling": true, "migrating": true}
+	tests := []struct {
+		name   string
+		inst   instanceModel.Instance
+		action string
+		want   string
+	}{
+		{"运行中停止", instanceModel.Instance{ProvisionState: str("running")}, model.DrainActionStop, drainStepStop},
+		{"运行中迁移", instanceModel.Instance{ProvisionState: str("running")}, model.DrainActionMigrate, drainStepMigrate},
+		{"无创建状态", instanceModel.Instance{}, model.DrainActionStop, drainStepStop},
+		{"拉取镜像中等待", instanceModel.Instance{ProvisionState: str("pulling")}, model.DrainActionStop, drainStepWait},
+		{"迁移中等待", instanceModel.Instance{ProvisionState: str("migrating")}, model.DrainActionMigrate, drainStepWait},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := drainStepFor(&tt.inst, tt.action, busy); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrainProgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		done      int
+		waiting   int
+		failures  []string
+		wantState string
+	}{
+		{"全部处理完成", 3, 0, nil, model.MaintenanceDrained},
+		{"节点上无实例", 0, 0, nil, model.MaintenanceDrained},
+		{"仍有实例在创建", 2, 1, nil, ""},
+		{"有实例处理失败", 2, 0, []string{"a: 超时"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg := drainProgress(tt.done, tt.waiting, tt.failures)
+			if state != tt.wantState || msg == "" {
+				t.Fatalf("got (%q, %q), want state %q", state, msg, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package computenode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	computenodeReq "github.com/flipped-aurora/gin-vue-admin/server/model/computenode/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	imageregistrySvc "github.com/flipped-aurora/gin-vue-admin/server/service/imageregistry"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// prePullSlots 限制同时进行的预拉取数量，避免镜像仓库与节点带宽被打满
+var prePullSlots = make(chan struct{}, 4)
+
+// loadRegistryImageIndex 读取镜像库并按规范化地址建立索引
+func loadRegistryImageIndex() (map[string][]imageregistry.ImageRegistry, error) {
+	var images []imageregistry.ImageRegistry
+	if err := global.GVA_DB.Where("address IS NOT NULL AND address <> ''").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	index := make(map[string][]imageregistry.ImageRegistry, len(images))
+	for _, img := range images {
+		ref := instanceSvc.NormalizeImageRef(*img.Address)
+		index[ref] = append(index[ref], img)
+	}
+	return index, nil
+}
+
+// syncNodeImageInventory 根据节点上的镜像列表刷新该节点的镜像缓存清单
+// 只记录镜像库中登记过的镜像；预拉取中/失败的记录不会被清理，由预拉取任务维护。
+func syncNodeImageInventory(nodeID uint, summaries []image.Summary, index map[string][]imageregistry.ImageRegistry) error {
+	type found struct {
+		imageRef      string
+		dockerImageID string
+		size          int64
+	}
+	present := make(map[uint]found)
+	for _, s := range summaries {
+		refs := append(append([]string{}, s.RepoTags...), s.RepoDigests...)
+		for _, ref := range refs {
+			if ref == "<none>:<none>" || ref == "<none>@<none>" {
+				continue
+			}
+			for _, img := range index[instanceSvc.NormalizeImageRef(ref)] {
+				present[img.ID] = found{imageRef: *img.Address, dockerImageID: s.ID, size: s.Size}
+			}
+		}
+	}
+
+	var rows []model.ComputeNodeImage
+	if err := global.GVA_DB.Where("node_id = ?", nodeID).Find(&rows).Error; err != nil {
+		return err
+	}
+	existing := make(map[uint]model.ComputeNodeImage, len(rows))
+	for _, r := range rows {
+		existing[r.ImageId] = r
+	}
+
+	now := time.Now()
+	for imageID, f := range present {
+		row, ok := existing[imageID]
+		if !ok {
+			row = model.ComputeNodeImage{NodeId: nodeID, ImageId: imageID}
+		}
+		row.ImageRef = f.imageRef
+		row.DockerImageId = f.dockerImageID
+		row.SizeBytes = f.size
+		row.Status = model.NodeImagePresent
+		row.Progress = 100
+		row.Message = ""
+		row.LastSeenAt = &now
+		if err := global.GVA_DB.Save(&row).Error; err != nil {
+			return err
+		}
+	}
+	// 节点上已不存在的镜像直接删除记录
+	for imageID, row := range existing {
+		if _, ok := present[imageID]; ok || row.Status != model.NodeImagePresent {
+			continue
+		}
+		if err := global.GVA_DB.Unscoped().Delete(&model.ComputeNodeImage{}, row.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshNodeImages 拉取节点镜像列表并刷新清单，失败只记录日志
+func refreshNodeImages(ctx context.Context, node *model.ComputeNode, index map[string][]imageregistry.ImageRegistry) {
+	dockerSvc := instanceSvc.DockerService{}
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	summaries, err := dockerSvc.ListImages(listCtx, node)
+	cancel()
+	if err != nil {
+		global.GVA_LOG.Warn("获取节点镜像列表失败", zap.Uint("nodeId", node.ID), zap.Error(err))
+		return
+	}
+	if err = syncNodeImageInventory(node.ID, summaries, index); err != nil {
+		global.GVA_LOG.Warn("刷新节点镜像清单失败", zap.Uint("nodeId", node.ID), zap.Error(err))
+	}
+}
+
+// PrePullImage 将镜像预拉取到指定节点，异步执行，进度写入节点镜像清单
+func (computeNodeService *ComputeNodeService) PrePullImage(ctx context.Context, req computenodeReq.PrePullImageReq) (err error) {
+	if len(req.NodeIds) == 0 {
+		return errors.New("请选择节点")
+	}
+	var img imageregistry.ImageRegistry
+	if err = global.GVA_DB.Where("id = ?", req.ImageId).First(&img).Error; err != nil {
+		return fmt.Errorf("镜像不存在: %v", err)
+	}
+	if img.Address == nil || strings.TrimSpace(*img.Address) == "" {
+		return errors.New("镜像地址为空")
+	}
+	username, password, token, err := imageregistrySvc.DecryptCredentials(&img)
+	if err != nil {
+		return err
+	}
+	var nodes []model.ComputeNode
+	if err = global.GVA_DB.Where("id IN ?", req.NodeIds).Find(&nodes).Error; err != nil {
+		return err
+	}
+	if len(nodes) != len(req.NodeIds) {
+		return errors.New("部分节点不存在")
+	}
+
+	imageRef := *img.Address
+	auth := instanceSvc.RegistryAuthFor(imageRef, username, password, token)
+	for i := range nodes {
+		node := nodes[i]
+		row, started, markErr := markPrePulling(node.ID, img.ID, imageRef)
+		if markErr != nil {
+			return markErr
+		}
+		if !started {
+			continue
+		}
+		go func() {
+			prePullSlots <- struct{}{}
+			defer func() { <-prePullSlots }()
+			runPrePull(&node, row, imageRef, auth)
+		}()
+	}
+	return nil
+}
+
+// markPrePulling 将清单记录置为拉取中；已在拉取中的记录返回 started=false
+func markPrePulling(nodeID, imageID uint, imageRef string) (row model.ComputeNodeImage, started bool, err error) {
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		findErr := tx.Where("node_id = ? AND image_id = ?", nodeID, imageID).First(&row).Error
+		if findErr != nil && !errors.Is(findErr, gorm.ErrRecordNotFound) {
+			return findErr
+		}
+		if findErr == nil && row.Status == model.NodeImagePulling {
+			return nil
+		}
+		row.NodeId = nodeID
+		row.ImageId = imageID
+		row.ImageRef = imageRef
+		row.Status = model.NodeImagePulling
+		row.Progress = 0
+		row.Message = ""
+		started = true
+		return tx.Save(&row).Error
+	})
+	return
+}
+
+// runPrePull 执行单个节点的预拉取
+func runPrePull(node *model.ComputeNode, row model.ComputeNodeImage, imageRef string, auth *registry.AuthConfig) {
+	timeoutMin := global.GVA_CONFIG.Provision.PullTimeoutMin
+	if timeoutMin <= 0 {
+		timeoutMin = 60
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMin)*time.Minute)
+	defer cancel()
+
+	var mu sync.Mutex
+	var lastWrite time.Time
+	onProgress := func(p instanceSvc.ImagePullProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Percent < 100 && time.Since(lastWrite) < 2*time.Second {
+			return
+		}
+		lastWrite = time.Now()
+		_ = global.GVA_DB.Model(&model.ComputeNodeImage{}).Where("id = ?", row.ID).Update("progress", p.Percent).Error
+	}
+
+	dockerSvc := instanceSvc.DockerService{}
+	if err := dockerSvc.ImagePull(ctx, node, imageRef, auth, onProgress); err != nil {
+		global.GVA_LOG.Warn("节点镜像预拉取失败", zap.Uint("nodeId", node.ID), zap.String("image", imageRef), zap.Error(err))
+		msg := err.Error()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		_ = global.GVA_DB.Model(&model.ComputeNodeImage{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"status":  model.NodeImageFailed,
+			"message": msg,
+		}).Error
+		return
+	}
+
+	now := time.Now()
+	_ = global.GVA_DB.Model(&model.ComputeNodeImage{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+		"status":       model.NodeImagePresent,
+		"progress":     100,
+		"message":      "",
+		"last_seen_at": now,
+	}).Error
+	global.GVA_LOG.Info("节点镜像预拉取完成", zap.Uint("nodeId", node.ID), zap.String("image", imageRef))
+}
+
+// GetNodeImageList 分页获取节点镜像缓存清单
+func (computeNodeService *ComputeNodeService) GetNodeImageList(ctx context.Context, info computenodeReq.NodeImageSearch) (list []model.ComputeNodeImage, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&model.ComputeNodeImage{})
+	if info.NodeId != nil {
+		db = db.Where("node_id = ?", *info.NodeId)
+	}
+	if info.ImageId != nil {
+		db = db.Where("image_id = ?", *info.ImageId)
+	}
+	if info.Status != nil && *info.Status != "" {
+		db = db.Where("status = ?", *info.Status)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("node_id, image_id").Find(&list).Error
+	return
+}
@@ -0,0 +1,73 @@
+package computenode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	model "github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	computenodeReq "github.com/flipped-aurora/gin-vue-admin/server/model/computenode/request"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+)
+
+// ReconcileNodes 对账节点上的受管容器、数据卷与实例记录，默认只演练
+func (computeNodeService *ComputeNodeService) ReconcileNodes(ctx context.Context, req computenodeReq.ReconcileNodesReq) ([]instanceSvc.ReconcileReport, error) {
+	var nodes []model.ComputeNode
+	db := global.GVA_DB
+	if req.ID != 0 {
+		db = db.Where("id = ?", req.ID)
+	} else {
+		db = db.Where("docker_status = ?", "connected")
+	}
+	if err := db.Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("查询算力节点失败: %v", err)
+	}
+	if req.ID != 0 && len(nodes) == 0 {
+		return nil, errors.New("算力节点不存在")
+	}
+	return reconcileNodes(ctx, nodes, req.Execute), nil
+}
+
+// ReconcileAllNodes 定时对账全部 Docker 连接正常的节点，未开启自动清理时只记录发现的孤儿
+func ReconcileAllNodes(ctx context.Context) {
+	cfg := global.GVA_CONFIG.Reconcile
+	if !cfg.Enabled {
+		return
+	}
+	var nodes []model.ComputeNode
+	if err := global.GVA_DB.Where("docker_status = ?", "connected").Find(&nodes).Error; err != nil {
+		global.GVA_LOG.Error("查询算力节点失败", zap.Error(err))
+		return
+	}
+	for _, report := range reconcileNodes(ctx, nodes, cfg.AutoClean) {
+		if report.Error != "" {
+			global.GVA_LOG.Warn("节点对账失败", zap.Uint("nodeId", report.NodeId), zap.String("error", report.Error))
+			continue
+		}
+		if report.DryRun {
+			for _, item := range report.Items {
+				if item.Action == instanceSvc.ReconcileActionSkip {
+					continue
+				}
+				global.GVA_LOG.Warn("节点对账发现不一致，未开启自动清理",
+					zap.Uint("nodeId", report.NodeId),
+					zap.String("kind", item.Kind),
+					zap.String("action", item.Action),
+					zap.Uint("实例ID", item.InstanceId),
+					zap.String("容器ID", item.ContainerId),
+					zap.String("volume", item.VolumeName))
+			}
+		}
+	}
+}
+
+func reconcileNodes(ctx context.Context, nodes []model.ComputeNode, execute bool) []instanceSvc.ReconcileReport {
+	instanceService := instanceSvc.InstanceService{}
+	reports := make([]instanceSvc.ReconcileReport, 0, len(nodes))
+	for i := range nodes {
+		reports = append(reports, instanceService.ReconcileNode(ctx, &nodes[i], execute))
+	}
+	return reports
+}
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/service/alert"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/idlepolicy"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/imageregistry"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/instance"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/quota"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/wallet"
 )
 
 var ServiceGroupApp = new(ServiceGroup)
@@ -18,4 +22,8 @@ type ServiceGroup struct {
 	ComputenodeServiceGroup   computenode.ServiceGroup
 	ProductServiceGroup       product.ServiceGroup
 	InstanceServiceGroup      instance.ServiceGroup
+	WalletServiceGroup        wallet.ServiceGroup
+	QuotaServiceGroup         quota.ServiceGroup
+	IdlepolicyServiceGroup    idlepolicy.ServiceGroup
+	AlertServiceGroup         alert.ServiceGroup
 }
@@ -0,0 +1,3 @@
+package idlepolicy
+
+type ServiceGroup struct{ IdlePolicyService }
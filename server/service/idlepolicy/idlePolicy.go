@@ -0,0 +1,81 @@
+package idlepolicy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	idleModel "github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy"
+	idleReq "github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy/request"
+	"gorm.io/gorm"
+)
+
+type IdlePolicyService struct{}
+
+// SetIdlePolicy 设置规格或角色的空闲停止策略，已存在则覆盖
+func (idlePolicyService *IdlePolicyService) SetIdlePolicy(ctx context.Context, p *idleModel.IdlePolicy) (err error) {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var existing idleModel.IdlePolicy
+		findErr := tx.Where("subject_type = ? AND subject_id = ?", p.SubjectType, p.SubjectId).First(&existing).Error
+		if errors.Is(findErr, gorm.ErrRecordNotFound) {
+			return tx.Create(p).Error
+		}
+		if findErr != nil {
+			return findErr
+		}
+		p.ID = existing.ID
+		p.CreatedAt = existing.CreatedAt
+		return tx.Save(p).Error
+	})
+}
+
+// DeleteIdlePolicy 删除空闲停止策略
+func (idlePolicyService *IdlePolicyService) DeleteIdlePolicy(ctx context.Context, ID string) (err error) {
+	// 物理删除，避免唯一索引阻止同一对象重新设置策略
+	return global.GVA_DB.Unscoped().Delete(&idleModel.IdlePolicy{}, "id = ?", ID).Error
+}
+
+// GetIdlePolicyList 分页获取空闲停止策略
+func (idlePolicyService *IdlePolicyService) GetIdlePolicyList(ctx context.Context, info idleReq.IdlePolicySearch) (list []idleModel.IdlePolicy, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&idleModel.IdlePolicy{})
+	if info.SubjectType != nil && *info.SubjectType != "" {
+		db = db.Where("subject_type = ?", *info.SubjectType)
+	}
+	if info.SubjectId != nil {
+		db = db.Where("subject_id = ?", *info.SubjectId)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("subject_type, subject_id").Find(&list).Error
+	return
+}
+
+// GetIdleStopRecordList 分页获取空闲停止审计记录，按时间倒序
+func (idlePolicyService *IdlePolicyService) GetIdleStopRecordList(ctx context.Context, info idleReq.IdleStopRecordSearch) (list []idleModel.IdleStopRecord, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&idleModel.IdleStopRecord{})
+	if info.InstanceId != nil {
+		db = db.Where("instance_id = ?", *info.InstanceId)
+	}
+	if info.UserId != nil {
+		db = db.Where("user_id = ?", *info.UserId)
+	}
+	if info.Action != nil && *info.Action != "" {
+		db = db.Where("action = ?", *info.Action)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("occurred_at desc, id desc").Find(&list).Error
+	return
+}
@@ -0,0 +1,99 @@
+package imageregistry
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// errCredentialKeyMissing 未配置加密密钥时拒绝加解密凭据，不退回内置密钥
+var errCredentialKeyMissing = errors.New("未配置 registry.encryption-key，无法保存或使用镜像库凭据")
+
+// credentialKey 获取镜像库凭据加密密钥，配置值经 SHA-256 派生为32字节
+func credentialKey() ([]byte, error) {
+	key := global.GVA_CONFIG.Registry.EncryptionKey
+	if key == "" {
+		return nil, errCredentialKeyMissing
+	}
+	sum := sha256.Sum256([]byte(key))
+	return sum[:], nil
+}
+
+// CheckCredentialKey 保存带密码或令牌的镜像前校验加密密钥已配置
+func CheckCredentialKey() error {
+	_, err := credentialKey()
+	return err
+}
+
+// sealCredentials 加密待写入的密码和令牌；空值表示保持原值不变
+func sealCredentials(imageRegistry *imageregistry.ImageRegistry) error {
+	var key []byte
+	if imageRegistry.Password != nil {
+		if *imageRegistry.Password == "" {
+			imageRegistry.Password = nil
+		} else {
+			var err error
+			if key, err = credentialKey(); err != nil {
+				return err
+			}
+			sealed, err := utils.AesGcmEncrypt(key, *imageRegistry.Password)
+			if err != nil {
+				return fmt.Errorf("加密仓库密码失败: %v", err)
+			}
+			imageRegistry.Password = &sealed
+		}
+	}
+	if imageRegistry.Token != nil {
+		if *imageRegistry.Token == "" {
+			imageRegistry.Token = nil
+		} else {
+			if key == nil {
+				var err error
+				if key, err = credentialKey(); err != nil {
+					return err
+				}
+			}
+			sealed, err := utils.AesGcmEncrypt(key, *imageRegistry.Token)
+			if err != nil {
+				return fmt.Errorf("加密仓库令牌失败: %v", err)
+			}
+			imageRegistry.Token = &sealed
+		}
+	}
+	return nil
+}
+
+// maskCredentials 对外返回前隐藏密码和令牌
+func maskCredentials(imageRegistry *imageregistry.ImageRegistry) {
+	imageRegistry.Password = nil
+	imageRegistry.Token = nil
+}
+
+// DecryptCredentials 解密镜像库凭据，供拉取/推送镜像时使用
+func DecryptCredentials(imageRegistry *imageregistry.ImageRegistry) (username, password, token string, err error) {
+	if imageRegistry.Username != nil {
+		username = *imageRegistry.Username
+	}
+	if imageRegistry.Password == nil && imageRegistry.Token == nil {
+		return username, "", "", nil
+	}
+	key, err := credentialKey()
+	if err != nil {
+		return "", "", "", err
+	}
+	if imageRegistry.Password != nil {
+		if password, err = utils.AesGcmDecrypt(key, *imageRegistry.Password); err != nil {
+			return "", "", "", fmt.Errorf("解密仓库密码失败: %v", err)
+		}
+	}
+	if imageRegistry.Token != nil {
+		if token, err = utils.AesGcmDecrypt(key, *imageRegistry.Token); err != nil {
+			return "", "", "", fmt.Errorf("解密仓库令牌失败: %v", err)
+		}
+	}
+	return username, password, token, nil
+}
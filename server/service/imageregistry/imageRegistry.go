@@ -4,15 +4,24 @@ package imageregistry
 import (
 	"context"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
     imageregistryReq "github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry/request"
+	"gorm.io/gorm"
 )
 
 type ImageRegistryService struct {}
 // CreateImageRegistry 创建镜像库记录
 // Author [yourname](https://github.com/yourname)
 func (imageRegistryService *ImageRegistryService) CreateImageRegistry(ctx context.Context, imageRegistry *imageregistry.ImageRegistry) (err error) {
+	if err = computenode.ValidateSchedulingRules(imageRegistry.NodeSelector, imageRegistry.Tolerations); err != nil {
+		return err
+	}
+	if err = sealCredentials(imageRegistry); err != nil {
+		return err
+	}
 	err = global.GVA_DB.Create(imageRegistry).Error
+	maskCredentials(imageRegistry)
 	return err
 }
 
@@ -33,14 +42,30 @@ func (imageRegistryService *ImageRegistryService)DeleteImageRegistryByIds(ctx co
 // UpdateImageRegistry 更新镜像库记录
 // Author [yourname](https://github.com/yourname)
 func (imageRegistryService *ImageRegistryService)UpdateImageRegistry(ctx context.Context, imageRegistry imageregistry.ImageRegistry) (err error) {
-	err = global.GVA_DB.Model(&imageregistry.ImageRegistry{}).Where("id = ?",imageRegistry.ID).Updates(&imageRegistry).Error
-	return err
+	if err = computenode.ValidateSchedulingRules(imageRegistry.NodeSelector, imageRegistry.Tolerations); err != nil {
+		return err
+	}
+	// 密码/令牌留空表示不修改；用户名清空时一并清除凭据
+	if err = sealCredentials(&imageRegistry); err != nil {
+		return err
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := tx.Model(&imageregistry.ImageRegistry{}).Where("id = ?",imageRegistry.ID).Updates(&imageRegistry).Error; txErr != nil {
+			return txErr
+		}
+		if imageRegistry.Username != nil && *imageRegistry.Username == "" {
+			return tx.Model(&imageregistry.ImageRegistry{}).Where("id = ?",imageRegistry.ID).
+				Updates(map[string]interface{}{"password": nil, "token": nil}).Error
+		}
+		return nil
+	})
 }
 
 // GetImageRegistry 根据ID获取镜像库记录
 // Author [yourname](https://github.com/yourname)
 func (imageRegistryService *ImageRegistryService)GetImageRegistry(ctx context.Context, ID string) (imageRegistry imageregistry.ImageRegistry, err error) {
 	err = global.GVA_DB.Where("id = ?", ID).First(&imageRegistry).Error
+	maskCredentials(&imageRegistry)
 	return
 }
 // GetImageRegistryInfoList 分页获取镜像库记录
@@ -78,6 +103,9 @@ func (imageRegistryService *ImageRegistryService)GetImageRegistryInfoList(ctx co
     }
 
 	err = db.Find(&imageRegistrys).Error
+	for i := range imageRegistrys {
+		maskCredentials(&imageRegistrys[i])
+	}
 	return  imageRegistrys, total, err
 }
 func (imageRegistryService *ImageRegistryService)GetImageRegistryPublic(ctx context.Context) {
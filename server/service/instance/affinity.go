@@ -0,0 +1,93 @@
+package instance
+
+import (
+	"fmt"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+)
+
+// 节点内置标签，未在 Labels 中显式设置时由节点字段生成
+const (
+	labelRegion = "region"
+	labelGpu    = "gpu"
+)
+
+// nodeLabels 节点的有效标签：显式标签优先，其次为区域与显卡型号
+func nodeLabels(node *computenode.ComputeNode) map[string]string {
+	labels := make(map[string]string, len(node.Labels)+2)
+	if r := safeString(node.Region); r != "" {
+		labels[labelRegion] = r
+	}
+	if g := safeString(node.GpuName); g != "" {
+		labels[labelGpu] = g
+	}
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// selectorMismatch 检查标签是否满足选择器，满足时返回空字符串
+func selectorMismatch(labels map[string]string, selector map[string]string) string {
+	for k, v := range selector {
+		if actual, ok := labels[k]; !ok || actual != v {
+			return fmt.Sprintf("节点标签不满足 %s=%s", k, v)
+		}
+	}
+	return ""
+}
+
+// affinityMismatch 检查节点是否满足规格与镜像的调度约束，满足时返回空字符串
+// 节点选择器要求标签全部匹配；ignoreTaints 用于实例已在该节点上的场景（如原节点变更规格），此时也不受节点维护状态影响。
+func affinityMismatch(node *computenode.ComputeNode, spec *product.ProductSpec, image *imageregistry.ImageRegistry, ignoreTaints bool) string {
+	labels := nodeLabels(node)
+	var tolerations []computenode.Toleration
+	selectors := make([]map[string]string, 0, 2)
+	if spec != nil {
+		selectors = append(selectors, spec.NodeSelector)
+		tolerations = append(tolerations, spec.Tolerations...)
+	}
+	if image != nil {
+		selectors = append(selectors, image.NodeSelector)
+		tolerations = append(tolerations, image.Tolerations...)
+	}
+	for _, selector := range selectors {
+		if reason := selectorMismatch(labels, selector); reason != "" {
+			return reason
+		}
+	}
+	if ignoreTaints {
+		return ""
+	}
+	if node.MaintenanceState != "" {
+		return fmt.Sprintf("节点处于维护状态(%s)", node.MaintenanceState)
+	}
+	for _, taint := range node.Taints {
+		tolerated := false
+		for _, t := range tolerations {
+			if t.Tolerates(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return fmt.Sprintf("节点存在未容忍的污点 %s=%s:%s", taint.Key, taint.Value, taint.EffectOrDefault())
+		}
+	}
+	return ""
+}
+
+// loadAffinityImage 读取调度约束所需的镜像，镜像不存在时按无约束处理
+func loadAffinityImage(imageID uint) *imageregistry.ImageRegistry {
+	if imageID == 0 {
+		return nil
+	}
+	var image imageregistry.ImageRegistry
+	if err := global.GVA_DB.Unscoped().Select("id", "node_selector", "tolerations").Where("id = ?", imageID).First(&image).Error; err != nil {
+		return nil
+	}
+	return &image
+}
@@ -0,0 +1,105 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+)
+
+func TestAffinityMismatch(t *testing.T) {
+	region := "bj"
+	gpu := "A100"
+	drained := computenode.NodeTaint{Key: "drain", Value: "true"}
+
+	tests := []struct {
+		name         string
+		node         computenode.ComputeNode
+		spec         product.ProductSpec
+		image        *imageregistry.ImageRegistry
+		ignoreTaints bool
+		wantOk       bool
+	}{
+		{
+			name:   "无约束",
+			node:   computenode.ComputeNode{Region: &region},
+			wantOk: true,
+		},
+		{
+			name:   "规格要求NVLink",
+			node:   computenode.ComputeNode{Labels: map[string]string{"nvlink": "true"}},
+			spec:   product.ProductSpec{NodeSelector: map[string]string{"nvlink": "true"}},
+			wantOk: true,
+		},
+		{
+			name:   "节点缺少标签",
+			node:   computenode.ComputeNode{Labels: map[string]string{"team": "audio"}},
+			image:  &imageregistry.ImageRegistry{NodeSelector: map[string]string{"team": "vision"}},
+			wantOk: false,
+		},
+		{
+			name:   "内置区域与显卡标签",
+			node:   computenode.ComputeNode{Region: &region, GpuName: &gpu},
+			spec:   product.ProductSpec{NodeSelector: map[string]string{"region": "bj", "gpu": "A100"}},
+			wantOk: true,
+		},
+		{
+			name:   "排空节点排除新实例",
+			node:   computenode.ComputeNode{Taints: []computenode.NodeTaint{drained}},
+			wantOk: false,
+		},
+		{
+			name:   "镜像容忍污点",
+			node:   computenode.ComputeNode{Taints: []computenode.NodeTaint{drained}},
+			image:  &imageregistry.ImageRegistry{Tolerations: []computenode.Toleration{{Key: "drain", Operator: computenode.TolerationExists}}},
+			wantOk: true,
+		},
+		{
+			name:   "容忍值不匹配",
+			node:   computenode.ComputeNode{Taints: []computenode.NodeTaint{drained}},
+			spec:   product.ProductSpec{Tolerations: []computenode.Toleration{{Key: "drain", Value: "false"}}},
+			wantOk: false,
+		},
+		{
+			name:   "隔离节点排除新实例",
+			node:   computenode.ComputeNode{MaintenanceState: computenode.MaintenanceCordoned},
+			wantOk: false,
+		},
+		{
+			name:         "原节点变更规格忽略污点",
+			node:         computenode.ComputeNode{Taints: []computenode.NodeTaint{drained}},
+			ignoreTaints: true,
+			wantOk:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := affinityMismatch(&tt.node, &tt.spec, tt.image, tt.ignoreTaints)
+			if (reason == "") != tt.wantOk {
+				t.Fatalf("affinityMismatch = %q, wantOk %v", reason, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSelectorMismatch(t *testing.T) {
+	labels := map[string]string{"region": "bj", "gpu": "A100", "nvlink": "true"}
+	tests := []struct {
+		name     string
+		selector map[string]string
+		wantOk   bool
+	}{
+		{"无要求", nil, true},
+		{"全部匹配", map[string]string{"region": "bj", "nvlink": "true"}, true},
+		{"值不同", map[string]string{"gpu": "H100"}, false},
+		{"缺少标签", map[string]string{"ib": "true"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectorMismatch(labels, tt.selector); (got == "") != tt.wantOk {
+				t.Fatalf("selectorMismatch() = %q, wantOk %v", got, tt.wantOk)
+			}
+		})
+	}
+}
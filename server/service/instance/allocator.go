@@ -0,0 +1,717 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nodeUsage 节点已被实例占用的资源
+type nodeUsage struct {
+	NodeId             int64   `json:"nodeId"`
+	GpuUsed            int64   `json:"gpuUsed"`
+	CpuUsed            int64   `json:"cpuUsed"`
+	MemUsed            int64   `json:"memUsed"`
+	MemoryCapacityUsed int64   `json:"memoryCapacityUsed"` // 已使用的显存容量（累加值）
+	CardMemoryUsage    []int64 `json:"cardMemoryUsage"`    // 每张卡已使用的显存容量
+	SystemDiskUsed     int64   `json:"systemDiskUsed"`
+	DataDiskUsed       int64   `json:"dataDiskUsed"`
+}
+
+// resourceAllocator 按节点核算实例占用的资源，并判断规格能否放到节点上
+// GetAvailableNodes 列出候选节点与 CreateInstance 落库前的复核共用同一套核算。
+type resourceAllocator struct {
+	nodeInfoMap   map[int64]*computenode.ComputeNode
+	usedResources map[int64]nodeUsage
+}
+
+// newResourceAllocator 统计 nodes 上未删除且未创建失败的实例所占用的资源
+// excludeInstanceID 对应的实例视为已释放（变更规格时排除实例自身占用），为0时不排除。
+func newResourceAllocator(db *gorm.DB, nodes []computenode.ComputeNode, excludeInstanceID uint) (*resourceAllocator, error) {
+	a := &resourceAllocator{
+		nodeInfoMap:   make(map[int64]*computenode.ComputeNode),
+		usedResources: make(map[int64]nodeUsage),
+	}
+	if len(nodes) == 0 {
+		return a, nil
+	}
+	nodeIds := make([]int64, 0, len(nodes))
+	for i := range nodes {
+		a.nodeInfoMap[int64(nodes[i].ID)] = &nodes[i]
+		nodeIds = append(nodeIds, int64(nodes[i].ID))
+	}
+	nodeInfoMap := a.nodeInfoMap
+	usedResources := a.usedResources
+
+	// 查询节点上已创建的实例（含迁移到这些节点的实例），创建失败的实例已释放资源
+	var instances []instanceModel.Instance
+	if err := db.Where("node_id IN ? OR migrate_target_node_id IN ?", nodeIds, nodeIds).
+		Where("provision_state IS NULL OR provision_state <> ?", provisionFailed).
+		Find(&instances).Error; err != nil {
+		return nil, err
+	}
+
+	// 已持久化的GPU卡分配记录，优先按实际设备索引统计
+	gpuAllocations, allocErr := loadGpuAllocationsByInstance(db, nodeIds)
+	if allocErr != nil {
+		global.GVA_LOG.Warn("读取GPU分配记录失败，按模拟分配统计", zap.Error(allocErr))
+	}
+
+	// 实例引用的规格与镜像一次性查出，避免逐个实例查询
+	specIds := make([]int64, 0, len(instances))
+	imageIds := make([]int64, 0, len(instances))
+	for _, inst := range instances {
+		if inst.SpecId != nil {
+			specIds = append(specIds, *inst.SpecId)
+		}
+		if inst.ImageId != nil {
+			imageIds = append(imageIds, *inst.ImageId)
+		}
+	}
+	specMap := make(map[int64]*product.ProductSpec)
+	if len(specIds) > 0 {
+		var specs []product.ProductSpec
+		if err := db.Where("id IN ?", specIds).Find(&specs).Error; err != nil {
+			return nil, err
+		}
+		for i := range specs {
+			specMap[int64(specs[i].ID)] = &specs[i]
+		}
+	}
+	splitImages := make(map[int64]bool)
+	if len(imageIds) > 0 {
+		var images []imageregistry.ImageRegistry
+		if err := db.Select("id", "support_memory_split").Where("id IN ?", imageIds).Find(&images).Error; err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			splitImages[int64(image.ID)] = image.SupportMemorySplit != nil && *image.SupportMemorySplit
+		}
+	}
+
+	for _, inst := range instances {
+		if inst.SpecId == nil || inst.ID == excludeInstanceID {
+			continue
+		}
+		// 该实例使用的规格
+		instSpec, ok := specMap[*inst.SpecId]
+		if !ok {
+			continue
+		}
+		// 该实例使用的镜像是否支持显存切分
+		supportMemorySplit := inst.ImageId != nil && splitImages[*inst.ImageId]
+		// 迁移中的实例同时占用源节点与目标节点
+		for _, ref := range []*int64{inst.NodeId, inst.MigrateTargetNodeId} {
+			if ref == nil {
+				continue
+			}
+			nodeId := *ref
+			if _, ok := nodeInfoMap[nodeId]; !ok {
+				continue
+			}
+			used := usedResources[nodeId]
+			used.NodeId = nodeId
+
+			// 初始化卡显存使用数组
+			if used.CardMemoryUsage == nil {
+				used.CardMemoryUsage = make([]int64, 0)
+			}
+
+			// 获取节点信息
+			nodeInfo, hasNodeInfo := nodeInfoMap[nodeId]
+
+			if instSpec.GpuCount != nil {
+				used.GpuUsed += *instSpec.GpuCount
+			}
+			if instSpec.CpuCores != nil {
+				used.CpuUsed += *instSpec.CpuCores
+			}
+			if instSpec.MemoryGb != nil {
+				used.MemUsed += *instSpec.MemoryGb
+			}
+
+			// 显存容量计算：有分配记录时按实际设备索引累计，否则按卡模拟分配
+			if allocs := allocsOnNode(gpuAllocations[inst.ID], nodeId); len(allocs) > 0 && hasNodeInfo && nodeInfo.GpuCount != nil {
+				totalCards := int(*nodeInfo.GpuCount)
+				for len(used.CardMemoryUsage) < totalCards {
+					used.CardMemoryUsage = append(used.CardMemoryUsage, 0)
+				}
+				for _, a := range allocs {
+					if a.DeviceIndex >= 0 && a.DeviceIndex < totalCards {
+						used.CardMemoryUsage[a.DeviceIndex] += a.MemoryGb
+					}
+				}
+				totalCardUsage := int64(0)
+				for _, usage := range used.CardMemoryUsage {
+					totalCardUsage += usage
+				}
+				used.MemoryCapacityUsed = totalCardUsage
+			} else if instSpec.MemoryCapacity != nil && instSpec.GpuCount != nil && *instSpec.GpuCount > 0 {
+				memoryNeeded := *instSpec.MemoryCapacity
+				gpuCount := *instSpec.GpuCount
+
+				// 计算每张卡需要的显存
+				memoryPerCard := memoryNeeded / gpuCount
+
+				// 获取节点每张卡的显存容量（MemoryCapacity 存储的是单卡容量）
+				perCardCapacity := int64(0)
+				if hasNodeInfo && nodeInfo.MemoryCapacity != nil {
+					perCardCapacity = *nodeInfo.MemoryCapacity
+				}
+
+				// 确保卡显存使用数组有足够的长度
+				if hasNodeInfo && nodeInfo.GpuCount != nil {
+					totalCards := int(*nodeInfo.GpuCount)
+					for len(used.CardMemoryUsage) < totalCards {
+						used.CardMemoryUsage = append(used.CardMemoryUsage, 0)
+					}
+				}
+
+				// 分配显存到卡上
+				if supportMemorySplit && perCardCapacity > 0 {
+					// 支持显存切分：可以分配到任意卡上
+					// 优先分配到已有使用但未满的卡上，如果都满了，再分配到新卡上
+					for i := 0; i < int(gpuCount); i++ {
+						// 找到可以分配的卡（有剩余空间的卡）
+						found := false
+						// 优先查找已有使用但未满的卡
+						for j := range used.CardMemoryUsage {
+							remaining := perCardCapacity - used.CardMemoryUsage[j]
+							if remaining >= memoryPerCard {
+								used.CardMemoryUsage[j] += memoryPerCard
+								used.MemoryCapacityUsed += memoryPerCard
+								found = true
+								break
+							}
+						}
+						// 如果没找到可用卡，分配到新卡上（完全未使用的卡）
+						if !found && len(used.CardMemoryUsage) < int(*nodeInfo.GpuCount) {
+							used.CardMemoryUsage = append(used.CardMemoryUsage, memoryPerCard)
+							used.MemoryCapacityUsed += memoryPerCard
+						} else if !found {
+							// 没有可用卡，累加到总使用量（这种情况不应该发生，但为了安全）
+							used.MemoryCapacityUsed += memoryPerCard
+							global.GVA_LOG.Warn("无法分配到卡，累加到总使用量",
+								zap.Int64("显存", memoryPerCard))
+						}
+					}
+
+					// 重新计算总使用显存，确保与卡使用情况一致
+					totalCardUsage := int64(0)
+					for _, usage := range used.CardMemoryUsage {
+						totalCardUsage += usage
+					}
+					used.MemoryCapacityUsed = totalCardUsage
+				} else {
+					// 不支持显存切分：每张卡必须完全分配给一个实例，不能部分使用
+					// 如果每张卡需要的显存等于每张卡的容量，可以分配
+					if perCardCapacity > 0 && memoryPerCard == perCardCapacity {
+						for i := 0; i < int(gpuCount); i++ {
+							// 找到完全未使用的卡（使用量为0）
+							found := false
+							for j := range used.CardMemoryUsage {
+								if used.CardMemoryUsage[j] == 0 {
+									used.CardMemoryUsage[j] = perCardCapacity
+									used.MemoryCapacityUsed += perCardCapacity
+									found = true
+									break
+								}
+							}
+							if !found && len(used.CardMemoryUsage) < int(*nodeInfo.GpuCount) {
+								used.CardMemoryUsage = append(used.CardMemoryUsage, perCardCapacity)
+								used.MemoryCapacityUsed += perCardCapacity
+							}
+						}
+					} else if perCardCapacity > 0 && memoryPerCard < perCardCapacity {
+						// 如果每张卡需要的显存小于每张卡的容量，不支持显存切分时无法分配
+						// 但为了统计，累加到总使用量（实际无法分配）
+						used.MemoryCapacityUsed += memoryNeeded
+						global.GVA_LOG.Warn("不支持切分且显存不匹配，无法分配",
+							zap.Int64("每张卡需要", memoryPerCard),
+							zap.Int64("每张卡容量", perCardCapacity))
+					} else {
+						// 如果每张卡需要的显存大于每张卡的容量，无法分配，但累加到总使用量
+						used.MemoryCapacityUsed += memoryNeeded
+						global.GVA_LOG.Warn("显存需求超过卡容量，无法分配",
+							zap.Int64("每张卡需要", memoryPerCard),
+							zap.Int64("每张卡容量", perCardCapacity))
+					}
+
+					// 重新计算总使用显存，确保与卡使用情况一致
+					totalCardUsage := int64(0)
+					for _, usage := range used.CardMemoryUsage {
+						totalCardUsage += usage
+					}
+					used.MemoryCapacityUsed = totalCardUsage
+				}
+			} else if instSpec.MemoryCapacity != nil {
+				// 如果没有GPU数量，直接累加
+				used.MemoryCapacityUsed += *instSpec.MemoryCapacity
+			}
+
+			if instSpec.SystemDiskGb != nil {
+				used.SystemDiskUsed += *instSpec.SystemDiskGb
+			}
+			if instSpec.DataDiskGb != nil {
+				used.DataDiskUsed += *instSpec.DataDiskGb
+			}
+			usedResources[nodeId] = used
+		}
+	}
+	return a, nil
+}
+
+// allocsOnNode 实例在指定节点上的GPU分配（迁移期间实例在源、目标节点上各有一组）
+func allocsOnNode(allocs []instanceModel.GpuAllocation, nodeId int64) []instanceModel.GpuAllocation {
+	res := make([]instanceModel.GpuAllocation, 0, len(allocs))
+	for _, a := range allocs {
+		if a.NodeId == nodeId {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// fit 判断节点能否容纳规格，能容纳时返回节点的可用资源
+func (a *resourceAllocator) fit(node *computenode.ComputeNode, spec *product.ProductSpec) (AvailableNode, bool) {
+	// 判断是否需要GPU
+	requiredGpu := int64(0)
+	needGpu := false
+	if spec.GpuCount != nil && *spec.GpuCount > 0 {
+		requiredGpu = *spec.GpuCount
+		needGpu = true
+	}
+
+	// 如果需要GPU，检查显卡型号是否匹配
+	if needGpu {
+		if spec.GpuModel != nil && node.GpuName != nil {
+			if *spec.GpuModel != *node.GpuName {
+				return AvailableNode{}, false
+			}
+		}
+	}
+
+	// 计算可用资源
+	nodeId := int64(node.ID)
+	used := a.usedResources[nodeId]
+
+	// 节点总GPU数量（仅在需要GPU时计算）
+	totalGpu := int64(0)
+	availableGpu := int64(0)
+	if needGpu {
+		if node.GpuCount != nil {
+			totalGpu = *node.GpuCount
+		}
+		availableGpu = totalGpu - used.GpuUsed
+	}
+
+	// 获取节点CPU
+	totalCpu := int64(0)
+	if node.Cpu != nil {
+		totalCpu = *node.Cpu
+	}
+	availableCpu := totalCpu - used.CpuUsed
+
+	// 获取节点内存
+	totalMem := int64(0)
+	if node.Memory != nil {
+		totalMem = *node.Memory
+	}
+	availableMem := totalMem - used.MemUsed
+
+	// 获取节点系统盘
+	totalSystemDisk := int64(0)
+	if node.SystemDisk != nil {
+		totalSystemDisk = *node.SystemDisk
+	}
+	availableSystemDisk := totalSystemDisk - used.SystemDiskUsed
+
+	// 获取节点数据盘
+	totalDataDisk := int64(0)
+	if node.DataDisk != nil {
+		totalDataDisk = *node.DataDisk
+	}
+	availableDataDisk := totalDataDisk - used.DataDiskUsed
+
+	// 检查是否满足规格要求
+	requiredCpu := int64(0)
+	if spec.CpuCores != nil {
+		requiredCpu = *spec.CpuCores
+	}
+	requiredMem := int64(0)
+	if spec.MemoryGb != nil {
+		requiredMem = *spec.MemoryGb
+	}
+	requiredSystemDisk := int64(0)
+	if spec.SystemDiskGb != nil {
+		requiredSystemDisk = *spec.SystemDiskGb
+	}
+	requiredDataDisk := int64(0)
+	if spec.DataDiskGb != nil {
+		requiredDataDisk = *spec.DataDiskGb
+	}
+	requiredMemoryCapacity := int64(0)
+	if spec.MemoryCapacity != nil {
+		requiredMemoryCapacity = *spec.MemoryCapacity
+	}
+
+	// 获取节点显存容量（MemoryCapacity 存储的是单卡容量）
+	perCardCapacity := int64(0)
+	if node.MemoryCapacity != nil {
+		perCardCapacity = *node.MemoryCapacity
+	}
+
+	// 计算总显存容量
+	totalMemoryCapacity := int64(0)
+	if node.GpuCount != nil && *node.GpuCount > 0 && perCardCapacity > 0 {
+		totalMemoryCapacity = perCardCapacity * *node.GpuCount
+	}
+
+	// 检查显存容量是否满足要求（按卡分配方式）
+	if requiredMemoryCapacity > 0 && needGpu && requiredGpu > 0 && perCardCapacity > 0 {
+		// 计算每张卡需要的显存
+		requiredMemoryPerCard := requiredMemoryCapacity / requiredGpu
+
+		// 初始化卡显存使用数组（如果还没有）
+		if used.CardMemoryUsage == nil {
+			used.CardMemoryUsage = make([]int64, 0)
+		}
+
+		// 确保数组长度足够
+		totalCards := int64(0)
+		if node.GpuCount != nil {
+			totalCards = *node.GpuCount
+		}
+		for len(used.CardMemoryUsage) < int(totalCards) {
+			used.CardMemoryUsage = append(used.CardMemoryUsage, 0)
+		}
+
+		// 获取产品规格是否支持显存分割
+		specSupportMemorySplit := false
+		if spec.SupportMemorySplit != nil {
+			specSupportMemorySplit = *spec.SupportMemorySplit
+		}
+
+		global.GVA_LOG.Debug("显存匹配检查",
+			zap.Int64("节点ID", int64(node.ID)),
+			zap.Int64("总显存容量", totalMemoryCapacity),
+			zap.Int64("总卡数", totalCards),
+			zap.Int64("每张卡容量", perCardCapacity),
+			zap.Int64("需要显存", requiredMemoryCapacity),
+			zap.Int64("需要GPU数", requiredGpu),
+			zap.Int64("每张卡需要显存", requiredMemoryPerCard),
+			zap.Bool("规格支持显存分割", specSupportMemorySplit),
+			zap.Any("卡使用情况", used.CardMemoryUsage))
+
+		// 创建一个临时数组来模拟分配，检查是否可以满足需求
+		tempCardUsage := make([]int64, len(used.CardMemoryUsage))
+		copy(tempCardUsage, used.CardMemoryUsage)
+
+		canAllocate := true
+		if specSupportMemorySplit {
+			// 支持显存分割：根据每个卡的剩余可用显存容量进行判断
+			for i := int64(0); i < requiredGpu; i++ {
+				found := false
+				// 查找可以分配的卡（有足够剩余空间的卡）
+				for j := range tempCardUsage {
+					remaining := perCardCapacity - tempCardUsage[j]
+					if remaining >= requiredMemoryPerCard {
+						tempCardUsage[j] += requiredMemoryPerCard
+						global.GVA_LOG.Debug("找到可用卡（支持显存分割）",
+							zap.Int64("节点ID", int64(node.ID)),
+							zap.Int("卡索引", j),
+							zap.Int64("卡剩余显存", remaining),
+							zap.Int64("需要显存", requiredMemoryPerCard))
+						found = true
+						break
+					}
+				}
+				// 如果没找到可用卡，检查是否有新卡可用
+				if !found {
+					if len(tempCardUsage) < int(totalCards) {
+						// 有新卡可用
+						tempCardUsage = append(tempCardUsage, requiredMemoryPerCard)
+						global.GVA_LOG.Debug("使用新卡（支持显存分割）",
+							zap.Int64("节点ID", int64(node.ID)),
+							zap.Int("新卡索引", len(tempCardUsage)-1),
+							zap.Int64("分配显存", requiredMemoryPerCard))
+						found = true
+					}
+				}
+				if !found {
+					global.GVA_LOG.Debug("无法找到可用卡（支持显存分割）",
+						zap.Int64("节点ID", int64(node.ID)),
+						zap.Int64("需要GPU索引", i),
+						zap.Int64("需要显存", requiredMemoryPerCard),
+						zap.Any("当前卡使用情况", tempCardUsage))
+					canAllocate = false
+					break
+				}
+			}
+		} else {
+			// 不支持显存分割：需要整个卡的计算（每张卡必须完全未使用）
+			// 需要 requiredGpu 张完全未使用的卡
+			availableUnusedCards := int64(0)
+			for _, cardUsage := range tempCardUsage {
+				if cardUsage == 0 {
+					availableUnusedCards++
+				}
+			}
+			// 如果还有未使用的卡槽，也加上
+			if len(tempCardUsage) < int(totalCards) {
+				availableUnusedCards += totalCards - int64(len(tempCardUsage))
+			}
+
+			global.GVA_LOG.Debug("检查未使用卡（不支持显存分割）",
+				zap.Int64("节点ID", int64(node.ID)),
+				zap.Int64("可用未使用卡数", availableUnusedCards),
+				zap.Int64("需要GPU数", requiredGpu))
+
+			if availableUnusedCards < requiredGpu {
+				global.GVA_LOG.Debug("未使用卡数不足（不支持显存分割）",
+					zap.Int64("节点ID", int64(node.ID)),
+					zap.Int64("可用未使用卡数", availableUnusedCards),
+					zap.Int64("需要GPU数", requiredGpu))
+				canAllocate = false
+			} else {
+				// 模拟分配：标记 requiredGpu 张卡为已使用
+				allocated := int64(0)
+				for j := range tempCardUsage {
+					if tempCardUsage[j] == 0 && allocated < requiredGpu {
+						tempCardUsage[j] = perCardCapacity // 标记为完全使用
+						allocated++
+					}
+				}
+				// 如果还有未使用的卡槽，也标记
+				for allocated < requiredGpu && len(tempCardUsage) < int(totalCards) {
+					tempCardUsage = append(tempCardUsage, perCardCapacity)
+					allocated++
+				}
+			}
+		}
+
+		// 如果无法分配，跳过该节点
+		if !canAllocate {
+			return AvailableNode{}, false
+		}
+
+		// 显存检查通过（按卡分配），根据卡的使用情况计算实际可用GPU数量
+		if needGpu {
+			if specSupportMemorySplit {
+				// 支持显存分割：计算有多少张卡有足够的剩余显存
+				availableCards := int64(0)
+				for _, cardUsage := range used.CardMemoryUsage {
+					remaining := perCardCapacity - cardUsage
+					if remaining >= requiredMemoryPerCard {
+						availableCards++
+					}
+				}
+				// 如果还有未使用的卡槽，也加上
+				if len(used.CardMemoryUsage) < int(totalCards) {
+					availableCards += totalCards - int64(len(used.CardMemoryUsage))
+				}
+				availableGpu = availableCards
+				global.GVA_LOG.Debug("根据卡使用情况计算可用GPU（支持显存分割）",
+					zap.Int64("节点ID", int64(node.ID)),
+					zap.Int64("可用卡数", availableCards),
+					zap.Int64("可用GPU", availableGpu))
+			} else {
+				// 不支持显存分割：计算有多少张完全未使用的卡
+				availableCards := int64(0)
+				for _, cardUsage := range used.CardMemoryUsage {
+					if cardUsage == 0 {
+						availableCards++
+					}
+				}
+				// 如果还有未使用的卡槽，也加上
+				if len(used.CardMemoryUsage) < int(totalCards) {
+					availableCards += totalCards - int64(len(used.CardMemoryUsage))
+				}
+				availableGpu = availableCards
+				global.GVA_LOG.Debug("根据卡使用情况计算可用GPU（不支持显存分割）",
+					zap.Int64("节点ID", int64(node.ID)),
+					zap.Int64("可用未使用卡数", availableCards),
+					zap.Int64("可用GPU", availableGpu))
+			}
+		}
+	} else if requiredMemoryCapacity > 0 {
+		// 如果没有GPU需求，使用简单累加方式
+		availableMemoryCapacity := totalMemoryCapacity - used.MemoryCapacityUsed
+		if availableMemoryCapacity < requiredMemoryCapacity {
+			return AvailableNode{}, false
+		}
+	}
+
+	// 资源不足则跳过（如果需要GPU才检查GPU资源）
+	if needGpu && availableGpu < requiredGpu {
+		return AvailableNode{}, false
+	}
+	if availableCpu < requiredCpu {
+		return AvailableNode{}, false
+	}
+	if availableMem < requiredMem {
+		return AvailableNode{}, false
+	}
+	if availableSystemDisk < requiredSystemDisk {
+		return AvailableNode{}, false
+	}
+	if availableDataDisk < requiredDataDisk {
+		return AvailableNode{}, false
+	}
+
+	// 计算单卡的可用显存容量（找到所有卡中剩余显存最大的那张卡的剩余显存）
+	availableMemoryCapacityPerCard := int64(0)
+	if node.MemoryCapacity != nil {
+		perCardCapacity := *node.MemoryCapacity
+
+		if node.GpuCount != nil && *node.GpuCount > 0 {
+			// 如果显存是按卡分配的，找到单卡的最大可用显存
+			if len(used.CardMemoryUsage) > 0 && perCardCapacity > 0 {
+				// 计算每张卡的剩余显存，找到最大的
+				maxAvailablePerCard := int64(0)
+				for _, cardUsage := range used.CardMemoryUsage {
+					if cardUsage < perCardCapacity {
+						remaining := perCardCapacity - cardUsage
+						if remaining > maxAvailablePerCard {
+							maxAvailablePerCard = remaining
+						}
+					}
+				}
+				availableMemoryCapacityPerCard = maxAvailablePerCard
+				// 如果还有未使用的卡槽，未使用的卡有完整的单卡容量
+				totalCards := int64(*node.GpuCount)
+				if len(used.CardMemoryUsage) < int(totalCards) {
+					// 未使用的卡有完整的单卡容量，这肯定比已使用卡的剩余显存大
+					availableMemoryCapacityPerCard = perCardCapacity
+				}
+			} else {
+				// 如果没有按卡分配，单卡可用显存就是单卡总容量
+				availableMemoryCapacityPerCard = perCardCapacity
+			}
+		} else {
+			// 如果没有GPU，单卡可用显存就是单卡总容量
+			availableMemoryCapacityPerCard = perCardCapacity
+		}
+	}
+
+	// 构建可用节点信息
+	availableNode := AvailableNode{
+		ID:                  node.ID,
+		AvailableGpu:        availableGpu,
+		AvailableCpu:        availableCpu,
+		AvailableMemory:     availableMem,
+		AvailableSystemDisk: availableSystemDisk,
+		AvailableDataDisk:   availableDataDisk,
+	}
+	if node.Name != nil {
+		availableNode.Name = *node.Name
+	}
+	if node.Region != nil {
+		availableNode.Region = *node.Region
+	}
+	if node.GpuName != nil {
+		availableNode.GpuName = *node.GpuName
+	}
+	if node.GpuCount != nil {
+		availableNode.GpuCount = *node.GpuCount
+	}
+	if node.Cpu != nil {
+		availableNode.Cpu = strconv.FormatInt(*node.Cpu, 10)
+	}
+	if node.Memory != nil {
+		availableNode.Memory = strconv.FormatInt(*node.Memory, 10)
+	}
+	// 返回单卡的可用显存容量
+	availableNode.MemoryCapacity = availableMemoryCapacityPerCard
+	if node.SystemDisk != nil {
+		availableNode.SystemDisk = strconv.FormatInt(*node.SystemDisk, 10)
+	}
+	if node.DataDisk != nil {
+		availableNode.DataDisk = strconv.FormatInt(*node.DataDisk, 10)
+	}
+	if node.PublicIp != nil {
+		availableNode.PublicIp = *node.PublicIp
+	}
+	if spec.PricePerHour != nil {
+		availableNode.PricePerHour = *spec.PricePerHour
+	}
+	// 卡级占用供碎片感知调度使用
+	if node.MemoryCapacity != nil {
+		availableNode.PerCardCapacity = *node.MemoryCapacity
+	}
+	if node.GpuCount != nil && *node.GpuCount > 0 {
+		availableNode.CardMemoryUsage = make([]int64, *node.GpuCount)
+		copy(availableNode.CardMemoryUsage, used.CardMemoryUsage)
+	}
+	return availableNode, true
+}
+
+// place 在内存中记录一次放置（调度模拟用，不写库），按 pickGpuDevices 的规则占用卡，返回占用的设备索引
+func (a *resourceAllocator) place(node *computenode.ComputeNode, spec *product.ProductSpec) []int {
+	nodeId := int64(node.ID)
+	used := a.usedResources[nodeId]
+	used.NodeId = nodeId
+	req := newSchedulingRequest(spec)
+	used.GpuUsed += req.GpuCount
+	used.CpuUsed += specInt(spec.CpuCores)
+	used.MemUsed += specInt(spec.MemoryGb)
+	used.SystemDiskUsed += specInt(spec.SystemDiskGb)
+	used.DataDiskUsed += specInt(spec.DataDiskGb)
+
+	var devices []int
+	if req.GpuCount > 0 && node.GpuCount != nil && *node.GpuCount > 0 {
+		totalCards := int(*node.GpuCount)
+		cardMemory := make([]int64, totalCards)
+		copy(cardMemory, used.CardMemoryUsage)
+		cards := make([]cardUsage, totalCards)
+		for i, m := range cardMemory {
+			if m > 0 {
+				cards[i] = cardUsage{MemoryGb: m, Holders: 1}
+			}
+		}
+		perCardCapacity := specInt(node.MemoryCapacity)
+		memoryPerCard := perCardCapacity
+		if req.Split && req.MemoryPerCard > 0 {
+			memoryPerCard = req.MemoryPerCard
+		}
+		devices, _ = pickGpuDevices(cards, req.GpuCount, perCardCapacity, memoryPerCard, req.Split)
+		for _, idx := range devices {
+			cardMemory[idx] += memoryPerCard
+			used.MemoryCapacityUsed += memoryPerCard
+		}
+		used.CardMemoryUsage = cardMemory
+	}
+	a.usedResources[nodeId] = used
+	return devices
+}
+
+// errNodeUnavailable 节点已下架或剩余资源不足，自动调度时可回退到下一个候选节点
+var errNodeUnavailable = errors.New("所选节点不可用或剩余资源不足，请重新选择节点")
+
+// reserveNodeCapacity 在事务内锁定节点行并复核节点能否容纳规格
+// 锁持有到事务结束，调用方需在同一事务内写入实例记录与GPU分配，之后的核算即包含本次占用。
+func reserveNodeCapacity(tx *gorm.DB, node *computenode.ComputeNode, spec *product.ProductSpec, excludeInstanceID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", node.ID).First(node).Error; err != nil {
+		return fmt.Errorf("锁定节点失败: %v", err)
+	}
+	if node.IsOnShelf != nil && !*node.IsOnShelf {
+		return errNodeUnavailable
+	}
+	allocator, err := newResourceAllocator(tx, []computenode.ComputeNode{*node}, excludeInstanceID)
+	if err != nil {
+		return fmt.Errorf("统计节点资源失败: %v", err)
+	}
+	if _, ok := allocator.fit(node, spec); !ok {
+		return errNodeUnavailable
+	}
+	return nil
+}
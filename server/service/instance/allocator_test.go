@@ -0,0 +1,64 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+)
+
+func TestResourceAllocatorFit(t *testing.T) {
+	global.GVA_LOG = zap.NewNop()
+	i64 := func(v int64) *int64 { return &v }
+	node := computenode.ComputeNode{GpuCount: i64(4), MemoryCapacity: i64(80), Cpu: i64(64), Memory: i64(256), SystemDisk: i64(1000), DataDisk: i64(2000)}
+	node.ID = 1
+	wholeCard := product.ProductSpec{GpuCount: i64(2), MemoryCapacity: i64(160), CpuCores: i64(16), MemoryGb: i64(64), SystemDiskGb: i64(100), DataDiskGb: i64(200)}
+
+	tests := []struct {
+		name    string
+		used    nodeUsage
+		wantOk  bool
+		wantGpu int64
+	}{
+		{
+			name:    "空闲节点可容纳",
+			used:    nodeUsage{},
+			wantOk:  true,
+			wantGpu: 4,
+		},
+		{
+			name:    "剩余整卡足够",
+			used:    nodeUsage{GpuUsed: 2, CpuUsed: 16, MemUsed: 64, CardMemoryUsage: []int64{80, 80, 0, 0}},
+			wantOk:  true,
+			wantGpu: 2,
+		},
+		{
+			name:   "整卡已被占满",
+			used:   nodeUsage{GpuUsed: 3, CardMemoryUsage: []int64{80, 80, 80, 0}},
+			wantOk: false,
+		},
+		{
+			name:   "CPU不足",
+			used:   nodeUsage{CpuUsed: 50},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := node
+			a := &resourceAllocator{
+				nodeInfoMap:   map[int64]*computenode.ComputeNode{1: &n},
+				usedResources: map[int64]nodeUsage{1: tt.used},
+			}
+			got, ok := a.fit(&n, &wholeCard)
+			if ok != tt.wantOk {
+				t.Fatalf("fit() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.AvailableGpu != tt.wantGpu {
+				t.Errorf("fit() availableGpu = %v, want %v", got.AvailableGpu, tt.wantGpu)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/wallet"
+	"gorm.io/gorm"
+)
+
+// checkBalance 校验用户余额是否足以支付规格运行 MinBalanceHours 小时
+func checkBalance(db *gorm.DB, userID *int64, spec *product.ProductSpec) error {
+	cfg := global.GVA_CONFIG.Billing
+	if !cfg.Enabled || spec.PricePerHour == nil || *spec.PricePerHour <= 0 {
+		return nil
+	}
+	if userID == nil {
+		return errors.New("实例未关联用户，无法校验余额")
+	}
+	hours := cfg.MinBalanceHours
+	if hours <= 0 {
+		hours = 1
+	}
+	required := *spec.PricePerHour * float64(hours)
+
+	var w wallet.Wallet
+	err := db.Where("user_id = ?", *userID).First(&w).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("读取余额失败: %v", err)
+	}
+	if w.Balance < required {
+		return fmt.Errorf("余额不足: 当前余额%.2f，至少需要%.2f（可运行%d小时）", w.Balance, required, hours)
+	}
+	return nil
+}
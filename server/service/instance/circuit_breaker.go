@@ -0,0 +1,326 @@
+package instance
+
+import (
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"go.uber.org/zap"
+)
+
+// 熔断状态
+const (
+	breakerClosed   = "closed"    // 正常放量，按滑动窗口统计失败率与延迟
+	breakerOpen     = "open"      // 回切稳定策略，冷却结束后进入半开
+	breakerHalfOpen = "half_open" // 小比例流量探测灰度策略，连续成功后关闭
+)
+
+// ruleHalfOpenProbe 半开状态下放行的探测请求，在命中规则后追加该后缀
+const ruleHalfOpenProbe = "_half_open_probe"
+
+// breakerBucket 滑动窗口中的一个时间桶
+type breakerBucket struct {
+	start     int64 // 桶起始时间(unix秒)，按桶宽对齐
+	requests  int
+	failures  int
+	latencyMs int64
+}
+
+// slidingWindow 按时间分桶的滑动窗口，过期的桶在写入时复用、在汇总时跳过
+type slidingWindow struct {
+	bucketSec int64
+	buckets   []breakerBucket
+}
+
+func newSlidingWindow(windowSec int, bucketCount int) *slidingWindow {
+	bucketSec := int64(windowSec / bucketCount)
+	if bucketSec <= 0 {
+		bucketSec = 1
+	}
+	return &slidingWindow{bucketSec: bucketSec, buckets: make([]breakerBucket, bucketCount)}
+}
+
+func (w *slidingWindow) add(now time.Time, failed bool, latencyMs int64) {
+	start := now.Unix() / w.bucketSec * w.bucketSec
+	b := &w.buckets[(start/w.bucketSec)%int64(len(w.buckets))]
+	if b.start != start {
+		*b = breakerBucket{start: start}
+	}
+	b.requests++
+	b.latencyMs += latencyMs
+	if failed {
+		b.failures++
+	}
+}
+
+// sum 汇总窗口内仍有效的桶
+func (w *slidingWindow) sum(now time.Time) (requests int, failures int, latencyMs int64) {
+	oldest := now.Unix()/w.bucketSec*w.bucketSec - w.bucketSec*int64(len(w.buckets)-1)
+	for _, b := range w.buckets {
+		if b.requests == 0 || b.start < oldest {
+			continue
+		}
+		requests += b.requests
+		failures += b.failures
+		latencyMs += b.latencyMs
+	}
+	return
+}
+
+func (w *slidingWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = breakerBucket{}
+	}
+}
+
+// breakerSettings 填充默认值后的熔断配置
+type breakerSettings struct {
+	failureRate       float64
+	latencyMs         float64
+	minSamples        int
+	windowSec         int
+	bucketCount       int
+	openDuration      time.Duration
+	halfOpenPercent   int
+	halfOpenSuccesses int
+}
+
+func loadBreakerSettings() breakerSettings {
+	cfg := global.GVA_CONFIG.PCDN.CircuitBreaker
+	s := breakerSettings{
+		failureRate:       cfg.FailureRateThreshold,
+		latencyMs:         float64(cfg.LatencyThresholdMs),
+		minSamples:        cfg.MinSamples,
+		windowSec:         cfg.WindowSeconds,
+		bucketCount:       cfg.BucketCount,
+		openDuration:      time.Duration(cfg.OpenSeconds) * time.Second,
+		halfOpenPercent:   cfg.HalfOpenPercentage,
+		halfOpenSuccesses: cfg.HalfOpenSuccesses,
+	}
+	if s.failureRate <= 0 {
+		s.failureRate = 0.3
+	}
+	if s.latencyMs <= 0 {
+		s.latencyMs = 800
+	}
+	if s.minSamples <= 0 {
+		s.minSamples = 10
+	}
+	if s.windowSec <= 0 {
+		s.windowSec = 60
+	}
+	if s.bucketCount <= 0 {
+		s.bucketCount = 12
+	}
+	if s.openDuration <= 0 {
+		s.openDuration = 30 * time.Second
+	}
+	if s.halfOpenPercent <= 0 {
+		s.halfOpenPercent = 10
+	}
+	if s.halfOpenPercent > 100 {
+		s.halfOpenPercent = 100
+	}
+	if s.halfOpenSuccesses <= 0 {
+		s.halfOpenSuccesses = 5
+	}
+	return s
+}
+
+// CircuitBreakerStatus 调度熔断状态与窗口统计
+type CircuitBreakerStatus struct {
+	State                string    `json:"state"`
+	StateSince           time.Time `json:"stateSince"`
+	WindowSeconds        int       `json:"windowSeconds"`
+	WindowRequests       int       `json:"windowRequests"`
+	WindowFailures       int       `json:"windowFailures"`
+	FailureRate          float64   `json:"failureRate"`
+	AvgLatencyMs         float64   `json:"avgLatencyMs"`
+	FailureRateThreshold float64   `json:"failureRateThreshold"`
+	LatencyThresholdMs   float64   `json:"latencyThresholdMs"`
+	MinSamples           int       `json:"minSamples"`
+	HalfOpenPercentage   int       `json:"halfOpenPercentage"`
+	ProbeSuccesses       int       `json:"probeSuccesses"` // 本轮半开已成功的探测数
+	ProbeRequired        int       `json:"probeRequired"`  // 关闭熔断所需的连续成功探测数
+	ReopenAt             time.Time `json:"reopenAt"`       // 打开状态下进入半开的时间
+	TripCount            int       `json:"tripCount"`      // 进程启动以来的熔断次数
+	LastTripReason       string    `json:"lastTripReason"`
+	LastStableVersion    string    `json:"lastStableVersion"`
+}
+
+// ensureWindow 配置热更新改变窗口大小时重建窗口
+func (r *schedulerRuntime) ensureWindow(s breakerSettings) {
+	if r.window == nil || len(r.window.buckets) != s.bucketCount || r.window.bucketSec != newSlidingWindow(s.windowSec, s.bucketCount).bucketSec {
+		r.window = newSlidingWindow(s.windowSec, s.bucketCount)
+	}
+	if r.state == "" {
+		r.state = breakerClosed
+	}
+}
+
+// advance 打开状态冷却结束后转入半开
+func (r *schedulerRuntime) advance(now time.Time, s breakerSettings) {
+	r.ensureWindow(s)
+	if r.state == breakerOpen && now.Sub(r.stateSince) >= s.openDuration {
+		r.state = breakerHalfOpen
+		r.stateSince = now
+		r.probeSeq = 0
+		r.probeSuccesses = 0
+		global.GVA_LOG.Info("PCDN调度熔断进入半开，开始小流量探测灰度策略", zap.Int("halfOpenPercentage", s.halfOpenPercent))
+	}
+}
+
+func (r *schedulerRuntime) trip(now time.Time, reason string) {
+	r.state = breakerOpen
+	r.stateSince = now
+	r.tripCount++
+	r.lastTripReason = reason
+	r.probeSuccesses = 0
+	global.GVA_LOG.Warn("PCDN调度熔断触发，自动回切稳定策略", zap.String("reason", reason))
+}
+
+func (r *schedulerRuntime) closeBreaker(now time.Time) {
+	r.state = breakerClosed
+	r.stateSince = now
+	r.probeSuccesses = 0
+	r.window.reset()
+	global.GVA_LOG.Info("PCDN调度熔断恢复，重新开放灰度")
+}
+
+func (r *schedulerRuntime) isCircuitOpened() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance(time.Now(), loadBreakerSettings())
+	return r.state != breakerClosed
+}
+
+// peekCircuitOpened 只读地判断熔断是否未关闭，不推进状态机
+func (r *schedulerRuntime) peekCircuitOpened() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state != "" && r.state != breakerClosed
+}
+
+// admit 决定本次请求是否回切稳定策略；半开状态下按比例放行探测请求
+func (r *schedulerRuntime) admit(now time.Time) (fallback bool, probe bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := loadBreakerSettings()
+	r.advance(now, s)
+	switch r.state {
+	case breakerOpen:
+		return true, false
+	case breakerHalfOpen:
+		r.probeSeq++
+		if r.probeSeq%100 < s.halfOpenPercent {
+			return false, true
+		}
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// releaseProbe 归还未用于探测灰度策略的半开名额
+func (r *schedulerRuntime) releaseProbe() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == breakerHalfOpen && r.probeSeq > 0 {
+		r.probeSeq--
+	}
+}
+
+func (r *schedulerRuntime) recordAndEvaluate(version string, state string, rule string, nodes []AvailableNode, err error, latency time.Duration) {
+	r.record(time.Now(), version, state, rule, nodes, err, latency)
+}
+
+func (r *schedulerRuntime) record(now time.Time, version string, state string, rule string, nodes []AvailableNode, err error, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed := err != nil || len(nodes) == 0
+	// 刷新稳定策略快照
+	if state == releaseStable && !failed {
+		r.lastStableNodes = append([]AvailableNode(nil), nodes...)
+		r.lastStableVersion = version
+	}
+
+	s := loadBreakerSettings()
+	r.advance(now, s)
+	switch r.state {
+	case breakerOpen:
+		return
+	case breakerHalfOpen:
+		// 只有探测请求参与半开判定，其余请求走的是稳定策略
+		if !strings.HasSuffix(rule, ruleHalfOpenProbe) {
+			return
+		}
+		if failed || float64(latency.Milliseconds()) >= s.latencyMs {
+			r.trip(now, "半开探测失败")
+			return
+		}
+		r.probeSuccesses++
+		if r.probeSuccesses >= s.halfOpenSuccesses {
+			r.closeBreaker(now)
+		}
+		return
+	}
+
+	r.window.add(now, failed, latency.Milliseconds())
+	requests, failures, latencyMs := r.window.sum(now)
+	if requests < s.minSamples {
+		return
+	}
+	failureRate := float64(failures) / float64(requests)
+	avgLatency := float64(latencyMs) / float64(requests)
+	if failureRate >= s.failureRate {
+		r.trip(now, "失败率超过阈值")
+	} else if avgLatency >= s.latencyMs {
+		r.trip(now, "平均延迟超过阈值")
+	}
+	if r.state == breakerOpen {
+		global.GVA_LOG.Warn("PCDN调度熔断窗口统计",
+			zap.Int("requests", requests),
+			zap.Float64("failureRate", failureRate),
+			zap.Float64("avgLatencyMs", avgLatency),
+			zap.Float64("failureRateThreshold", s.failureRate),
+			zap.Float64("latencyThresholdMs", s.latencyMs),
+		)
+	}
+}
+
+func (r *schedulerRuntime) status(now time.Time) CircuitBreakerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := loadBreakerSettings()
+	r.advance(now, s)
+	requests, failures, latencyMs := r.window.sum(now)
+	st := CircuitBreakerStatus{
+		State:                r.state,
+		StateSince:           r.stateSince,
+		WindowSeconds:        s.windowSec,
+		WindowRequests:       requests,
+		WindowFailures:       failures,
+		FailureRateThreshold: s.failureRate,
+		LatencyThresholdMs:   s.latencyMs,
+		MinSamples:           s.minSamples,
+		HalfOpenPercentage:   s.halfOpenPercent,
+		ProbeSuccesses:       r.probeSuccesses,
+		ProbeRequired:        s.halfOpenSuccesses,
+		TripCount:            r.tripCount,
+		LastTripReason:       r.lastTripReason,
+		LastStableVersion:    r.lastStableVersion,
+	}
+	if requests > 0 {
+		st.FailureRate = roundToTwoDecimals(float64(failures) / float64(requests))
+		st.AvgLatencyMs = roundToTwoDecimals(float64(latencyMs) / float64(requests))
+	}
+	if r.state == breakerOpen {
+		st.ReopenAt = r.stateSince.Add(s.openDuration)
+	}
+	return st
+}
+
+// GetCircuitBreakerStatus 查询调度熔断状态
+func (instanceService *InstanceService) GetCircuitBreakerStatus() CircuitBreakerStatus {
+	return pcdnSchedulerRuntime.status(time.Now())
+}
@@ -0,0 +1,143 @@
+package instance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"go.uber.org/zap"
+)
+
+func TestSlidingWindowExpiresOldBuckets(t *testing.T) {
+	w := newSlidingWindow(60, 12)
+	base := time.Unix(1000, 0)
+	w.add(base, true, 100)
+	w.add(base.Add(20*time.Second), false, 100)
+
+	tests := []struct {
+		name         string
+		at           time.Duration
+		wantRequests int
+		wantFailures int
+	}{
+		{name: "窗口内全部计入", at: 30 * time.Second, wantRequests: 2, wantFailures: 1},
+		{name: "最早的桶滑出窗口", at: 61 * time.Second, wantRequests: 1, wantFailures: 0},
+		{name: "全部过期", at: 90 * time.Second, wantRequests: 0, wantFailures: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, failures, _ := w.sum(base.Add(tt.at))
+			if requests != tt.wantRequests || failures != tt.wantFailures {
+				t.Fatalf("sum = (%d, %d), want (%d, %d)", requests, failures, tt.wantRequests, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerStateMachine(t *testing.T) {
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.PCDN.CircuitBreaker = config.CircuitBreak{
+		FailureRateThreshold: 0.5,
+		LatencyThresholdMs:   800,
+		MinSamples:           4,
+		WindowSeconds:        60,
+		BucketCount:          6,
+		OpenSeconds:          30,
+		HalfOpenPercentage:   100,
+		HalfOpenSuccesses:    2,
+	}
+	defer func() { global.GVA_CONFIG.PCDN.CircuitBreaker = config.CircuitBreak{} }()
+
+	ok := []AvailableNode{{}}
+	failErr := errors.New("no node")
+	r := &schedulerRuntime{}
+	now := time.Unix(10000, 0)
+
+	// 早期失败滑出窗口后不再影响判定
+	for i := 0; i < 3; i++ {
+		r.record(now, "v2", releaseCanary, "canary_hit", nil, failErr, time.Millisecond)
+	}
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		r.record(now, "v2", releaseCanary, "canary_hit", ok, nil, time.Millisecond)
+	}
+	if r.status(now).State != breakerClosed {
+		t.Fatalf("过期失败不应触发熔断")
+	}
+
+	for i := 0; i < 4; i++ {
+		r.record(now, "v2", releaseCanary, "canary_hit", nil, failErr, time.Millisecond)
+	}
+	if st := r.status(now); st.State != breakerOpen {
+		t.Fatalf("state = %s, want open", st.State)
+	}
+	if fallback, _ := r.admit(now.Add(10 * time.Second)); !fallback {
+		t.Fatalf("打开状态应回切稳定策略")
+	}
+
+	// 冷却结束进入半开，探测失败重新打开
+	now = now.Add(31 * time.Second)
+	if fallback, probe := r.admit(now); fallback || !probe {
+		t.Fatalf("半开状态应放行探测请求")
+	}
+	r.record(now, "v2", releaseCanary, "canary_hit"+ruleHalfOpenProbe, nil, failErr, time.Millisecond)
+	if st := r.status(now); st.State != breakerOpen || st.TripCount != 2 {
+		t.Fatalf("state = %s trips = %d, want open/2", st.State, st.TripCount)
+	}
+
+	// 再次半开，连续成功探测后关闭
+	now = now.Add(31 * time.Second)
+	r.admit(now)
+	r.record(now, "v1", releaseStable, "circuit_breaker_fallback", ok, nil, time.Millisecond)
+	for i := 0; i < 2; i++ {
+		r.record(now, "v2", releaseCanary, "canary_hit"+ruleHalfOpenProbe, ok, nil, time.Millisecond)
+	}
+	if st := r.status(now); st.State != breakerClosed || st.WindowRequests != 0 {
+		t.Fatalf("state = %s window = %d, want closed/0", st.State, st.WindowRequests)
+	}
+}
+
+func TestChooseStrategyHalfOpenProbeOnlyOnCanary(t *testing.T) {
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.PCDN = config.PCDN{
+		Enabled:       true,
+		Version:       "v2",
+		StableVersion: "v1",
+		ReleaseState:  releaseCanary,
+		Canary:        config.Canary{Regions: []string{"cn-east"}, UserHashPercentage: 100},
+		CircuitBreaker: config.CircuitBreak{
+			OpenSeconds:        30,
+			HalfOpenPercentage: 2, // 半开后的第1个请求占用探测名额，第2个回切稳定策略
+			HalfOpenSuccesses:  1,
+		},
+	}
+	defer func() { global.GVA_CONFIG.PCDN = config.PCDN{} }()
+
+	now := time.Unix(20000, 0)
+	r := &schedulerRuntime{}
+	r.ensureWindow(loadBreakerSettings())
+	r.trip(now, "test")
+	now = now.Add(31 * time.Second)
+
+	// 未命中灰度的请求走稳定版本，不占用探测名额
+	version, state, rule, _ := r.chooseStrategy(SchedulingRequestMeta{Region: "cn-west"}, now)
+	if version != "v1" || state != releaseStable || rule != "canary_miss" {
+		t.Fatalf("got (%s, %s, %s), want (v1, stable, canary_miss)", version, state, rule)
+	}
+	r.record(now, version, state, rule, []AvailableNode{{}}, nil, time.Millisecond)
+	if st := r.status(now); st.State != breakerHalfOpen || st.ProbeSuccesses != 0 {
+		t.Fatalf("state = %s probes = %d, want half_open/0", st.State, st.ProbeSuccesses)
+	}
+
+	// 名额归还后，命中灰度的请求成为探测，成功后关闭熔断
+	version, state, rule, _ = r.chooseStrategy(SchedulingRequestMeta{Region: "cn-east"}, now)
+	if state != releaseCanary || rule != "canary_hit"+ruleHalfOpenProbe {
+		t.Fatalf("got (%s, %s, %s), want canary probe", version, state, rule)
+	}
+	r.record(now, version, state, rule, []AvailableNode{{}}, nil, time.Millisecond)
+	if st := r.status(now); st.State != breakerClosed {
+		t.Fatalf("state = %s, want closed", st.State)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
@@ -15,9 +16,12 @@ import (
 
 // checkAllContainerStatusAndMetrics 检查所有容器的状态并刷新监控指标（并发版本）
 func CheckAllContainerStatusAndMetrics(ctx context.Context) {
-	// 获取所有有容器ID的实例（未删除的）
+	// 获取所有有容器ID的实例（未删除的），后台创建中的实例由创建worker维护状态
 	var instances []instanceModel.Instance
-	if err := global.GVA_DB.Where("deleted_at IS NULL AND container_id IS NOT NULL AND container_id != ''").Find(&instances).Error; err != nil {
+	if err := global.GVA_DB.Where("deleted_at IS NULL AND container_id IS NOT NULL AND container_id != ''").
+		Where("provision_state IS NULL OR provision_state = '' OR provision_state = ?", provisionRunning).
+		Where("container_status IS NULL OR container_status <> ?", containerStatusMissing). // 容器丢失的实例由节点对账处理
+		Find(&instances).Error; err != nil {
 		global.GVA_LOG.Error("查询实例列表失败", zap.Error(err))
 		return
 	}
@@ -39,6 +43,11 @@ func CheckAllContainerStatusAndMetrics(ctx context.Context) {
 	maxConcurrency := 30
 	semaphore := make(chan struct{}, maxConcurrency)
 
+	// 本轮采样统一使用同一时间点写入监控历史
+	sampledAt := time.Now()
+	var samplesMu sync.Mutex
+	var samples []instanceModel.MetricPoint
+
 	for _, inst := range instances {
 		if inst.ContainerId == nil || *inst.ContainerId == "" || inst.NodeId == nil {
 			continue
@@ -70,12 +79,18 @@ func CheckAllContainerStatusAndMetrics(ctx context.Context) {
 				return
 			}
 
-			stats, err := dockerService.GetContainerStats(ctx, &node, *instance.ContainerId)
+			// 节点显卡遥测有效时显存取自遥测，不再逐容器 exec nvidia-smi
+			gpuUsage, telemetryOK := freshInstanceGpuUsage(node.ID, instance.ID)
+			stats, err := dockerService.containerStats(ctx, &node, *instance.ContainerId, !telemetryOK)
 			if err != nil {
 				global.GVA_LOG.Warn("获取容器统计信息失败，跳过指标刷新",
 					zap.Uint("实例ID", instance.ID), zap.String("容器ID", *instance.ContainerId), zap.Error(err))
 				return
 			}
+			if telemetryOK {
+				stats.GPUMemorySizeGB = gpuUsage.allocatedGB
+				stats.GPUMemoryUsageRate = gpuUsage.rate
+			}
 
 			// 将 CPU 和内存使用率保留 2 位小数
 			cpu := roundToTwoDecimals(stats.CPUUsagePercent)
@@ -114,12 +129,18 @@ func CheckAllContainerStatusAndMetrics(ctx context.Context) {
 				global.GVA_LOG.Warn("写入实例指标失败",
 					zap.Uint("实例ID", instance.ID), zap.Error(err))
 			}
+
+			samplesMu.Lock()
+			samples = append(samples, newMetricPoint(instance.ID, uint(*instance.NodeId), sampledAt, cpu, mem, gpu))
+			samplesMu.Unlock()
 		}(inst)
 	}
 
 	// 等待所有 goroutine 完成
 	wg.Wait()
 
+	recordMetricSamples(samples, sampledAt)
+
 	// 已移除定时任务汇总日志，避免控制台噪声
 }
 
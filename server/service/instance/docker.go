@@ -6,7 +6,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -23,11 +25,16 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
 	"go.uber.org/zap"
 
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 )
 
@@ -36,16 +43,18 @@ type DockerService struct{}
 
 // ContainerConfig 容器配置
 type ContainerConfig struct {
-	Image              string // 镜像地址
-	Name               string // 容器名称
-	CPUCores           int64  // CPU核心数
-	MemoryGB           int64  // 内存大小(GB)
-	SystemDiskGB       int64  // 系统盘大小(GB)
-	DataDiskGB         int64  // 数据盘大小(GB)
-	GPUCount           int64  // GPU数量
-	SupportMemorySplit bool   // 是否支持显存分割
-	MemoryCapacity     int64  // 显存容量(GB)
-	PerCardCapacity    int64  // 节点单卡显存容量(GB)
+	Image              string   // 镜像地址
+	Name               string   // 容器名称
+	CPUCores           int64    // CPU核心数
+	MemoryGB           int64    // 内存大小(GB)
+	SystemDiskGB       int64    // 系统盘大小(GB)
+	DataDiskGB         int64    // 数据盘大小(GB)
+	GPUCount           int64    // GPU数量
+	GPUDeviceIDs       []string // 指定的GPU设备索引（为空时按数量由运行时挑选）
+	SupportMemorySplit bool     // 是否支持显存分割
+	MemoryCapacity     int64    // 显存容量(GB)
+	PerCardCapacity    int64    // 节点单卡显存容量(GB)
+	DataVolumeName     string   // 复用已有数据卷（重建容器时保留数据），为空时按容器名创建
 }
 
 // CreateDockerClient 创建Docker客户端
@@ -113,7 +122,7 @@ func (d *DockerService) createTLSConfig(caCert, clientCert, clientKey string) (*
 	}, nil
 }
 
-// CreateContainer 创建容器
+// CreateContainer 创建容器（仅创建，不启动）
 func (d *DockerService) CreateContainer(ctx context.Context, node *computenode.ComputeNode, config *ContainerConfig) (containerID string, err error) {
 	// 创建Docker客户端
 	cli, err := d.CreateDockerClient(node)
@@ -146,20 +155,23 @@ func (d *DockerService) CreateContainer(ctx context.Context, node *computenode.C
 		hostConfig.Memory = config.MemoryGB * 1024 * 1024 * 1024
 	}
 
-	// 数据盘配置: 创建命名卷并挂载到 /data
-	if config.DataDiskGB > 0 {
-		volumeName := fmt.Sprintf("%s-data", config.Name)
+	// 数据盘配置: 创建命名卷（或复用已有数据卷）并挂载到 /data
+	if config.DataDiskGB > 0 || config.DataVolumeName != "" {
+		volumeName := config.DataVolumeName
+		if volumeName == "" {
+			volumeName = fmt.Sprintf("%s-data", config.Name)
 
-		// 创建数据卷
-		_, err = cli.VolumeCreate(ctx, volume.CreateOptions{
-			Name: volumeName,
-			Labels: map[string]string{
-				"managed-by": "docker-gpu-manage",
-				"instance":   config.Name,
-			},
-		})
-		if err != nil {
-			global.GVA_LOG.Warn("创建数据卷失败，可能已存在", zap.Error(err))
+			// 创建数据卷
+			_, err = cli.VolumeCreate(ctx, volume.CreateOptions{
+				Name: volumeName,
+				Labels: map[string]string{
+					"managed-by": "docker-gpu-manage",
+					"instance":   config.Name,
+				},
+			})
+			if err != nil {
+				global.GVA_LOG.Warn("创建数据卷失败，可能已存在", zap.Error(err))
+			}
 		}
 
 		// 挂载数据卷到 /data
@@ -210,8 +222,18 @@ func (d *DockerService) CreateContainer(ctx context.Context, node *computenode.C
 			zap.Int64("SM限制", smLimit))
 	}
 
-	// GPU配置: --gpus N
-	if config.GPUCount > 0 {
+	// GPU配置: 已分配设备时 --gpus '"device=0,1"'，否则 --gpus N
+	if len(config.GPUDeviceIDs) > 0 {
+		hostConfig.DeviceRequests = []container.DeviceRequest{
+			{
+				Driver:    "nvidia",
+				DeviceIDs: config.GPUDeviceIDs,
+				Capabilities: [][]string{
+					{"gpu"},
+				},
+			},
+		}
+	} else if config.GPUCount > 0 {
 		hostConfig.DeviceRequests = []container.DeviceRequest{
 			{
 				Driver: "nvidia",
@@ -249,19 +271,402 @@ func (d *DockerService) CreateContainer(ctx context.Context, node *computenode.C
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("创建容器失败: %v", err)
+		return "", fmt.Errorf("创建容器失败: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// ImagePullProgress 镜像拉取进度
+type ImagePullProgress struct {
+	Percent      float64 `json:"percent"`      // 下载进度百分比(0-100)
+	LayersTotal  int     `json:"layersTotal"`  // 镜像层总数
+	LayersDone   int     `json:"layersDone"`   // 已完成的层数
+	CurrentBytes int64   `json:"currentBytes"` // 已下载字节
+	TotalBytes   int64   `json:"totalBytes"`   // 已知总字节
+	Status       string  `json:"status"`       // 最近一条状态
+}
+
+// ImagePull 确保节点上存在指定镜像，不存在时使用仓库凭据拉取，并通过 onProgress 回调拉取进度
+func (d *DockerService) ImagePull(ctx context.Context, node *computenode.ComputeNode, imageRef string, auth *registry.AuthConfig, onProgress func(ImagePullProgress)) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	// 镜像已存在则跳过拉取
+	if _, _, err = cli.ImageInspectWithRaw(ctx, imageRef); err == nil {
+		if onProgress != nil {
+			onProgress(ImagePullProgress{Percent: 100, Status: "镜像已存在"})
+		}
+		return nil
+	}
+
+	options := image.PullOptions{}
+	if auth != nil {
+		if options.RegistryAuth, err = registry.EncodeAuthConfig(*auth); err != nil {
+			return fmt.Errorf("编码仓库凭据失败: %w", err)
+		}
+	}
+	reader, err := cli.ImagePull(ctx, imageRef, options)
+	if err != nil {
+		return fmt.Errorf("拉取镜像失败: %w", err)
+	}
+	defer reader.Close()
+
+	// 拉取结果以JSON消息流返回，需读完整个流并检查其中的错误
+	tracker := newPullProgressTracker()
+	dec := json.NewDecoder(reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err = dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("读取镜像拉取进度失败: %w", err)
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("拉取镜像失败: %s", msg.Error.Message)
+		}
+		tracker.update(msg)
+		if onProgress != nil {
+			onProgress(tracker.snapshot())
+		}
 	}
+	if onProgress != nil {
+		final := tracker.snapshot()
+		final.Percent = 100
+		onProgress(final)
+	}
+	return nil
+}
+
+// RegistryAuthFor 根据镜像地址和凭据构建仓库认证信息；未配置凭据时返回nil
+// 仅配置令牌时将其作为密码使用（Harbor机器人账号、个人访问令牌均采用该方式）
+func RegistryAuthFor(imageRef, username, password, token string) *registry.AuthConfig {
+	if username == "" && password == "" && token == "" {
+		return nil
+	}
+	if password == "" {
+		password = token
+	}
+	auth := &registry.AuthConfig{Username: username, Password: password}
+	if named, err := reference.ParseNormalizedNamed(imageRef); err == nil {
+		auth.ServerAddress = reference.Domain(named)
+	}
+	return auth
+}
 
-	// 启动容器
-	if err = cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		// 启动失败，删除已创建的容器
-		_ = cli.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
-		return "", fmt.Errorf("启动容器失败: %v", err)
+// CommitContainer 将容器当前文件系统提交为新镜像，返回镜像ID
+// 提交期间暂停容器以保证一致性；数据卷中的内容不会包含在镜像内
+func (d *DockerService) CommitContainer(ctx context.Context, node *computenode.ComputeNode, containerID string, imageRef string, comment string) (string, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return "", fmt.Errorf("创建Docker客户端失败: %v", err)
 	}
+	defer cli.Close()
 
+	resp, err := cli.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Reference: imageRef,
+		Comment:   comment,
+		Pause:     true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("提交容器镜像失败: %w", err)
+	}
 	return resp.ID, nil
 }
 
+// ImagePush 将节点上的镜像推送到仓库
+func (d *DockerService) ImagePush(ctx context.Context, node *computenode.ComputeNode, imageRef string, auth *registry.AuthConfig) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	opts := image.PushOptions{}
+	if auth != nil {
+		encoded, encErr := registry.EncodeAuthConfig(*auth)
+		if encErr != nil {
+			return fmt.Errorf("编码仓库认证信息失败: %v", encErr)
+		}
+		opts.RegistryAuth = encoded
+	} else {
+		// 推送接口要求携带认证头，匿名推送时传空认证
+		opts.RegistryAuth = "e30="
+	}
+	reader, err := cli.ImagePush(ctx, imageRef, opts)
+	if err != nil {
+		return fmt.Errorf("推送镜像失败: %w", err)
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err = decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("读取推送进度失败: %v", err)
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("推送镜像失败: %s", msg.Error.Message)
+		}
+	}
+}
+
+// ImageExists 判断节点上是否已存在镜像
+func (d *DockerService) ImageExists(ctx context.Context, node *computenode.ComputeNode, imageRef string) (bool, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return false, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	if _, _, err = cli.ImageInspectWithRaw(ctx, imageRef); err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListImages 列出节点上的镜像
+func (d *DockerService) ListImages(ctx context.Context, node *computenode.ComputeNode) ([]image.Summary, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	return cli.ImageList(ctx, image.ListOptions{})
+}
+
+// NormalizeImageRef 将镜像地址规范化为完整形式（补全仓库域名与latest标签），用于与节点镜像清单比对
+// 无法解析的地址原样返回
+func NormalizeImageRef(imageRef string) string {
+	named, err := reference.ParseNormalizedNamed(strings.TrimSpace(imageRef))
+	if err != nil {
+		return strings.TrimSpace(imageRef)
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+type layerPull struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// pullProgressTracker 汇总各镜像层的拉取进度
+type pullProgressTracker struct {
+	layers map[string]*layerPull
+	status string
+}
+
+func newPullProgressTracker() *pullProgressTracker {
+	return &pullProgressTracker{layers: make(map[string]*layerPull)}
+}
+
+func (t *pullProgressTracker) update(msg jsonmessage.JSONMessage) {
+	if msg.Status != "" {
+		t.status = strings.TrimSpace(msg.Status + " " + msg.ID)
+	}
+	// 无ID的消息为整体状态（Pulling from / Digest / Status），不对应具体层
+	if msg.ID == "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		return
+	}
+	layer, ok := t.layers[msg.ID]
+	if !ok {
+		layer = &layerPull{}
+		t.layers[msg.ID] = layer
+	}
+	switch msg.Status {
+	case "Downloading":
+		if msg.Progress != nil {
+			layer.current = msg.Progress.Current
+			if msg.Progress.Total > 0 {
+				layer.total = msg.Progress.Total
+			}
+		}
+	case "Download complete", "Verifying Checksum":
+		layer.current = layer.total
+	case "Pull complete", "Already exists":
+		layer.current = layer.total
+		layer.done = true
+	}
+}
+
+func (t *pullProgressTracker) snapshot() ImagePullProgress {
+	p := ImagePullProgress{LayersTotal: len(t.layers), Status: t.status}
+	for _, l := range t.layers {
+		p.CurrentBytes += l.current
+		p.TotalBytes += l.total
+		if l.done {
+			p.LayersDone++
+		}
+	}
+	if p.TotalBytes > 0 {
+		p.Percent = float64(p.CurrentBytes) / float64(p.TotalBytes) * 100
+	} else if p.LayersTotal > 0 {
+		p.Percent = float64(p.LayersDone) / float64(p.LayersTotal) * 100
+	}
+	p.Percent = roundToTwoDecimals(p.Percent)
+	return p
+}
+
+// UpdateContainerResources 在线调整容器的CPU与内存限制
+func (d *DockerService) UpdateContainerResources(ctx context.Context, node *computenode.ComputeNode, containerID string, cpuCores int64, memoryGB int64) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	resources := container.Resources{}
+	if cpuCores > 0 {
+		resources.NanoCPUs = cpuCores * 1e9
+	}
+	if memoryGB > 0 {
+		resources.Memory = memoryGB * 1024 * 1024 * 1024
+		// 与创建时的默认行为一致：swap上限为内存的2倍，否则调大内存会超过原swap上限而失败
+		resources.MemorySwap = resources.Memory * 2
+	}
+	if _, err = cli.ContainerUpdate(ctx, containerID, container.UpdateConfig{Resources: resources}); err != nil {
+		return fmt.Errorf("更新容器资源失败: %w", err)
+	}
+	return nil
+}
+
+// RenameContainer 重命名容器
+func (d *DockerService) RenameContainer(ctx context.Context, node *computenode.ComputeNode, containerID string, newName string) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	return cli.ContainerRename(ctx, containerID, newName)
+}
+
+// GetDataVolumeName 获取容器挂载到 /data 的命名卷，未挂载时返回空
+func (d *DockerService) GetDataVolumeName(ctx context.Context, node *computenode.ComputeNode, containerID string) (string, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return "", fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range inspect.Mounts {
+		if m.Type == mount.TypeVolume && m.Destination == "/data" {
+			return m.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// CopyVolume 将源节点上的命名卷内容以tar流复制到目标节点的同名卷
+// 两端各创建一个挂载该卷的辅助容器（不启动），通过 CopyFromContainer/CopyToContainer 传输，结束后删除辅助容器。
+// helperImage 需已存在于两个节点上。
+func (d *DockerService) CopyVolume(ctx context.Context, src, dst *computenode.ComputeNode, volumeName string, helperImage string, labels map[string]string) error {
+	srcCli, err := d.CreateDockerClient(src)
+	if err != nil {
+		return fmt.Errorf("创建源节点Docker客户端失败: %v", err)
+	}
+	defer srcCli.Close()
+	dstCli, err := d.CreateDockerClient(dst)
+	if err != nil {
+		return fmt.Errorf("创建目标节点Docker客户端失败: %v", err)
+	}
+	defer dstCli.Close()
+
+	if _, err = dstCli.VolumeCreate(ctx, volume.CreateOptions{Name: volumeName, Labels: labels}); err != nil {
+		return fmt.Errorf("创建目标数据卷失败: %v", err)
+	}
+
+	createHelper := func(cli *client.Client, readOnly bool) (string, error) {
+		resp, createErr := cli.ContainerCreate(ctx, &container.Config{
+			Image:      helperImage,
+			Entrypoint: []string{"true"},
+			Labels:     map[string]string{"managed-by": "docker-gpu-manage"},
+		}, &container.HostConfig{
+			Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: "/data", ReadOnly: readOnly}},
+		}, nil, nil, "")
+		if createErr != nil {
+			return "", createErr
+		}
+		return resp.ID, nil
+	}
+	srcHelper, err := createHelper(srcCli, true)
+	if err != nil {
+		return fmt.Errorf("创建源节点辅助容器失败: %v", err)
+	}
+	defer srcCli.ContainerRemove(context.Background(), srcHelper, container.RemoveOptions{Force: true})
+	dstHelper, err := createHelper(dstCli, false)
+	if err != nil {
+		return fmt.Errorf("创建目标节点辅助容器失败: %v", err)
+	}
+	defer dstCli.ContainerRemove(context.Background(), dstHelper, container.RemoveOptions{Force: true})
+
+	// 导出的tar以 data/ 为根目录，解压到目标容器的 / 即落入 /data
+	reader, _, err := srcCli.CopyFromContainer(ctx, srcHelper, "/data")
+	if err != nil {
+		return fmt.Errorf("导出数据卷失败: %v", err)
+	}
+	defer reader.Close()
+	if err = dstCli.CopyToContainer(ctx, dstHelper, "/", reader, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("导入数据卷失败: %v", err)
+	}
+	return nil
+}
+
+// VolumeExists 判断节点上是否已存在命名卷
+func (d *DockerService) VolumeExists(ctx context.Context, node *computenode.ComputeNode, volumeName string) (bool, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return false, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.VolumeInspect(ctx, volumeName); err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveVolume 强制删除命名卷
+func (d *DockerService) RemoveVolume(ctx context.Context, node *computenode.ComputeNode, volumeName string) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	return cli.VolumeRemove(ctx, volumeName, true)
+}
+
+// RemoveContainer 强制删除容器（不处理数据卷）
+func (d *DockerService) RemoveContainer(ctx context.Context, node *computenode.ComputeNode, containerID string) error {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	return cli.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
+}
+
 // DeleteContainer 删除容器及其数据卷
 func (d *DockerService) DeleteContainer(ctx context.Context, node *computenode.ComputeNode, containerID string, containerName string) error {
 	cli, err := d.CreateDockerClient(node)
@@ -464,7 +869,15 @@ func (d *DockerService) SyncContainerStatus(ctx context.Context, instanceID uint
 		status = "unknown"
 	}
 
-	return global.GVA_DB.Model(&inst).Update("container_status", status).Error
+	fromStatus := safeString(inst.ContainerStatus)
+	if err = global.GVA_DB.Model(&inst).Update("container_status", status).Error; err != nil {
+		return err
+	}
+	// 节点暂时不可达（unknown）不视为状态变化，避免网络抖动中断计费
+	if status != fromStatus && status != "unknown" {
+		recordUsageEvent(global.GVA_DB, &inst, usageEventStatusChange, fromStatus, status)
+	}
+	return nil
 }
 
 // GetContainerLogs 获取容器日志
@@ -1044,11 +1457,16 @@ func parseBytes(s string) int64 {
 
 // GetContainerStats 获取容器统计信息
 func (d *DockerService) GetContainerStats(ctx context.Context, node *computenode.ComputeNode, containerID string) (*ContainerStats, error) {
+	return d.containerStats(ctx, node, containerID, true)
+}
+
+// containerStats 获取容器统计信息，withGPU 为 false 时不在容器内 exec nvidia-smi（显存由节点遥测提供）
+func (d *DockerService) containerStats(ctx context.Context, node *computenode.ComputeNode, containerID string, withGPU bool) (*ContainerStats, error) {
 
 	// 优先走 docker stats --no-stream（与Docker CLI一致）
 	if stats, err := d.getContainerStatsViaCLI(ctx, node, containerID); err == nil && stats != nil {
 		// 追加GPU信息（通过SDK exec nvidia-smi）
-		if node != nil {
+		if node != nil && withGPU {
 			if cliTmp, err := d.CreateDockerClient(node); err == nil {
 				defer cliTmp.Close()
 				gm, gr := d.getGPUMemoryInfo(ctx, cliTmp, containerID)
@@ -1144,7 +1562,10 @@ func (d *DockerService) GetContainerStats(ctx context.Context, node *computenode
 	// 不再采集网络与块设备I/O
 	pids := curr.PidsStats.Current
 
-	gm, gr := d.getGPUMemoryInfo(ctx, cli, containerID)
+	var gm, gr float64
+	if withGPU {
+		gm, gr = d.getGPUMemoryInfo(ctx, cli, containerID)
+	}
 
 	res := &ContainerStats{
 		CPUUsagePercent:    normCPUPercent,
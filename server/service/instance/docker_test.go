@@ -0,0 +1,83 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+func TestPullProgressTracker(t *testing.T) {
+	tracker := newPullProgressTracker()
+	msgs := []jsonmessage.JSONMessage{
+		{Status: "Pulling from library/ubuntu", ID: "22.04"},
+		{Status: "Already exists", ID: "a"},
+		{Status: "Pulling fs layer", ID: "b"},
+		{Status: "Downloading", ID: "b", Progress: &jsonmessage.JSONProgress{Current: 25, Total: 100}},
+	}
+	for _, m := range msgs {
+		tracker.update(m)
+	}
+	got := tracker.snapshot()
+	if got.LayersTotal != 2 || got.LayersDone != 1 {
+		t.Errorf("snapshot() layers = %d/%d, want 1/2", got.LayersDone, got.LayersTotal)
+	}
+	if got.Percent != 25 {
+		t.Errorf("snapshot() percent = %v, want 25", got.Percent)
+	}
+
+	tracker.update(jsonmessage.JSONMessage{Status: "Pull complete", ID: "b"})
+	got = tracker.snapshot()
+	if got.Percent != 100 || got.LayersDone != 2 {
+		t.Errorf("snapshot() after complete = %+v", got)
+	}
+}
+
+func TestRegistryAuthFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		imageRef   string
+		username   string
+		password   string
+		token      string
+		wantNil    bool
+		wantServer string
+		wantPass   string
+	}{
+		{name: "未配置凭据", imageRef: "nginx:latest", wantNil: true},
+		{name: "私有仓库账号密码", imageRef: "harbor.example.com/ai/pytorch:2.1", username: "u", password: "p", wantServer: "harbor.example.com", wantPass: "p"},
+		{name: "仅令牌时作为密码", imageRef: "registry.example.com:5000/app", username: "robot", token: "t", wantServer: "registry.example.com:5000", wantPass: "t"},
+		{name: "Docker Hub镜像", imageRef: "ubuntu", username: "u", password: "p", wantServer: "docker.io", wantPass: "p"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RegistryAuthFor(tt.imageRef, tt.username, tt.password, tt.token)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("RegistryAuthFor() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.ServerAddress != tt.wantServer || got.Password != tt.wantPass {
+				t.Errorf("RegistryAuthFor() = %+v, want server %s password %s", got, tt.wantServer, tt.wantPass)
+			}
+		})
+	}
+}
+
+func TestNormalizeImageRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "nginx", want: "docker.io/library/nginx:latest"},
+		{ref: "docker.io/library/nginx:latest", want: "docker.io/library/nginx:latest"},
+		{ref: "harbor.example.com/ai/pytorch:2.1", want: "harbor.example.com/ai/pytorch:2.1"},
+		{ref: " harbor.example.com/ai/pytorch ", want: "harbor.example.com/ai/pytorch:latest"},
+		{ref: "<none>:<none>", want: "<none>:<none>"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeImageRef(tt.ref); got != tt.want {
+			t.Errorf("NormalizeImageRef(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
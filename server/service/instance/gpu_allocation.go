@@ -0,0 +1,185 @@
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// cardUsage 单张卡的占用情况
+type cardUsage struct {
+	MemoryGb int64 // 已分配显存(GB)
+	Holders  int   // 占用该卡的实例数
+}
+
+// loadNodeCardUsage 读取节点上每张卡的分配情况
+func loadNodeCardUsage(db *gorm.DB, nodeId int64, totalCards int) ([]cardUsage, error) {
+	var allocs []instanceModel.GpuAllocation
+	if err := db.Where("node_id = ?", nodeId).Find(&allocs).Error; err != nil {
+		return nil, err
+	}
+	cards := make([]cardUsage, totalCards)
+	for _, a := range allocs {
+		if a.DeviceIndex < 0 || a.DeviceIndex >= totalCards {
+			global.GVA_LOG.Warn("GPU分配记录的设备索引超出节点卡数",
+				zap.Int64("节点ID", nodeId),
+				zap.Uint("实例ID", a.InstanceId),
+				zap.Int("设备索引", a.DeviceIndex))
+			continue
+		}
+		cards[a.DeviceIndex].MemoryGb += a.MemoryGb
+		cards[a.DeviceIndex].Holders++
+	}
+	return cards, nil
+}
+
+// pickGpuDevices 在节点卡上选出 count 张互不相同的卡
+// 支持显存切分时优先选择已部分使用且剩余显存足够的卡，尽量保留整卡；否则只选完全空闲的卡。
+func pickGpuDevices(cards []cardUsage, count int64, perCardCapacity int64, memoryPerCard int64, split bool) ([]int, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	candidates := make([]int, 0, len(cards))
+	for i, c := range cards {
+		if split && perCardCapacity > 0 && memoryPerCard > 0 {
+			if perCardCapacity-c.MemoryGb >= memoryPerCard {
+				candidates = append(candidates, i)
+			}
+			continue
+		}
+		if c.Holders == 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	if int64(len(candidates)) < count {
+		return nil, fmt.Errorf("节点可用GPU不足: 需要%d张, 可用%d张", count, len(candidates))
+	}
+	if split {
+		// 已部分使用的卡排在前面，其次按索引
+		sort.SliceStable(candidates, func(i, j int) bool {
+			ui, uj := cards[candidates[i]].Holders > 0, cards[candidates[j]].Holders > 0
+			if ui != uj {
+				return ui
+			}
+			return candidates[i] < candidates[j]
+		})
+	}
+	picked := append([]int(nil), candidates[:count]...)
+	sort.Ints(picked)
+	return picked, nil
+}
+
+// allocateGpuDevices 为实例分配物理GPU卡并写入分配表，返回设备索引
+func allocateGpuDevices(tx *gorm.DB, instanceID uint, node *computenode.ComputeNode, spec *product.ProductSpec) ([]int, error) {
+	if spec.GpuCount == nil || *spec.GpuCount <= 0 {
+		return nil, nil
+	}
+	if node.GpuCount == nil || *node.GpuCount <= 0 {
+		return nil, fmt.Errorf("%w: 节点未配置GPU数量", errNodeUnavailable)
+	}
+	gpuCount := *spec.GpuCount
+	perCardCapacity := int64(0)
+	if node.MemoryCapacity != nil {
+		perCardCapacity = *node.MemoryCapacity
+	}
+	split := spec.SupportMemorySplit != nil && *spec.SupportMemorySplit
+	memoryPerCard := perCardCapacity
+	if split && spec.MemoryCapacity != nil && *spec.MemoryCapacity > 0 {
+		memoryPerCard = *spec.MemoryCapacity / gpuCount
+	}
+
+	nodeId := int64(node.ID)
+	cards, err := loadNodeCardUsage(tx, nodeId, int(*node.GpuCount))
+	if err != nil {
+		return nil, fmt.Errorf("读取GPU分配记录失败: %v", err)
+	}
+	devices, err := pickGpuDevices(cards, gpuCount, perCardCapacity, memoryPerCard, split)
+	if err != nil {
+		// 卡碎片化时总量核算通过但选不出足够的卡，属于容量不足，自动调度可换下一个节点
+		return nil, fmt.Errorf("%w: %v", errNodeUnavailable, err)
+	}
+
+	allocs := make([]instanceModel.GpuAllocation, 0, len(devices))
+	for _, idx := range devices {
+		allocs = append(allocs, instanceModel.GpuAllocation{
+			NodeId:      nodeId,
+			InstanceId:  instanceID,
+			DeviceIndex: idx,
+			MemoryGb:    memoryPerCard,
+		})
+	}
+	if err = tx.Create(&allocs).Error; err != nil {
+		return nil, fmt.Errorf("写入GPU分配记录失败: %v", err)
+	}
+	return devices, nil
+}
+
+// releaseGpuDevices 释放实例占用的GPU卡
+func releaseGpuDevices(db *gorm.DB, instanceID uint) error {
+	return db.Where("instance_id = ?", instanceID).Delete(&instanceModel.GpuAllocation{}).Error
+}
+
+// reallocateGpuDevices 释放实例原有GPU卡并在指定节点上按规格重新分配，返回原分配记录用于回滚
+func reallocateGpuDevices(tx *gorm.DB, instanceID uint, node *computenode.ComputeNode, spec *product.ProductSpec) (old []instanceModel.GpuAllocation, devices []int, err error) {
+	if err = tx.Where("instance_id = ?", instanceID).Find(&old).Error; err != nil {
+		return nil, nil, fmt.Errorf("读取GPU分配记录失败: %v", err)
+	}
+	if err = releaseGpuDevices(tx, instanceID); err != nil {
+		return nil, nil, fmt.Errorf("释放GPU失败: %v", err)
+	}
+	if devices, err = allocateGpuDevices(tx, instanceID, node, spec); err != nil {
+		return nil, nil, fmt.Errorf("分配GPU失败: %w", err)
+	}
+	return old, devices, nil
+}
+
+// restoreGpuAllocations 将实例的GPU分配恢复为 old
+func restoreGpuAllocations(db *gorm.DB, instanceID uint, old []instanceModel.GpuAllocation) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := releaseGpuDevices(tx, instanceID); err != nil {
+			return err
+		}
+		if len(old) == 0 {
+			return nil
+		}
+		restored := make([]instanceModel.GpuAllocation, 0, len(old))
+		for _, a := range old {
+			restored = append(restored, instanceModel.GpuAllocation{
+				NodeId:      a.NodeId,
+				InstanceId:  a.InstanceId,
+				DeviceIndex: a.DeviceIndex,
+				MemoryGb:    a.MemoryGb,
+			})
+		}
+		return tx.Create(&restored).Error
+	})
+}
+
+// loadGpuAllocationsByInstance 读取指定节点上的分配记录并按实例分组
+func loadGpuAllocationsByInstance(db *gorm.DB, nodeIds []int64) (map[uint][]instanceModel.GpuAllocation, error) {
+	var allocs []instanceModel.GpuAllocation
+	if err := db.Where("node_id IN ?", nodeIds).Find(&allocs).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[uint][]instanceModel.GpuAllocation)
+	for _, a := range allocs {
+		res[a.InstanceId] = append(res[a.InstanceId], a)
+	}
+	return res, nil
+}
+
+// deviceIDStrings 将设备索引转为 Docker DeviceIDs 参数
+func deviceIDStrings(devices []int) []string {
+	ids := make([]string, 0, len(devices))
+	for _, d := range devices {
+		ids = append(ids, strconv.Itoa(d))
+	}
+	return ids
+}
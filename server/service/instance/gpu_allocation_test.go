@@ -0,0 +1,86 @@
+package instance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPickGpuDevices(t *testing.T) {
+	type args struct {
+		cards           []cardUsage
+		count           int64
+		perCardCapacity int64
+		memoryPerCard   int64
+		split           bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "整卡分配跳过已占用的卡",
+			args: args{
+				cards:           []cardUsage{{MemoryGb: 80, Holders: 1}, {}, {}, {}},
+				count:           2,
+				perCardCapacity: 80,
+				memoryPerCard:   80,
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "显存切分优先填充已部分使用的卡",
+			args: args{
+				cards:           []cardUsage{{}, {MemoryGb: 20, Holders: 1}, {}},
+				count:           1,
+				perCardCapacity: 80,
+				memoryPerCard:   40,
+				split:           true,
+			},
+			want: []int{1},
+		},
+		{
+			name: "显存切分多卡必须落在不同的卡上",
+			args: args{
+				cards:           []cardUsage{{MemoryGb: 20, Holders: 1}, {}},
+				count:           2,
+				perCardCapacity: 80,
+				memoryPerCard:   20,
+				split:           true,
+			},
+			want: []int{0, 1},
+		},
+		{
+			name: "显存切分剩余不足的卡不可用",
+			args: args{
+				cards:           []cardUsage{{MemoryGb: 60, Holders: 2}, {MemoryGb: 70, Holders: 1}},
+				count:           1,
+				perCardCapacity: 80,
+				memoryPerCard:   40,
+				split:           true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "单卡容量未知时按占用实例数判断",
+			args: args{
+				cards: []cardUsage{{Holders: 1}, {}},
+				count: 1,
+			},
+			want: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickGpuDevices(tt.args.cards, tt.args.count, tt.args.perCardCapacity, tt.args.memoryPerCard, tt.args.split)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("pickGpuDevices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pickGpuDevices() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// gpuTelemetryScript 辅助容器内执行：先输出每卡指标，分隔行之后输出计算进程及其所属容器ID
+// 进程行格式 gpu_uuid,pid,used_memory,container_id,process_name（进程名可能含逗号，放在最后）。
+const gpuTelemetryScript = `nvidia-smi --query-gpu=index,uuid,name,utilization.gpu,utilization.memory,memory.used,memory.total,temperature.gpu,power.draw,power.limit,ecc.errors.corrected.volatile.total,ecc.errors.uncorrected.volatile.total --format=csv,noheader,nounits || exit 1
+echo "` + gpuProcessSeparator + `"
+nvidia-smi --query-compute-apps=gpu_uuid,pid,used_memory,process_name --format=csv,noheader,nounits | while IFS=, read -r uuid pid mem name; do
+  pid=$(echo $pid)
+  cid=$(grep -o -E '[0-9a-f]{64}' /proc/$pid/cgroup 2>/dev/null | head -n 1)
+  echo "$uuid,$pid,$mem,$cid,$name"
+done`
+
+const gpuProcessSeparator = "### processes"
+
+const (
+	// gpuTelemetryFresh 遥测快照在该时长内视为有效，实例显存使用率优先取自快照
+	gpuTelemetryFresh = 90 * time.Second
+	// gpuSampleInterval 历史采样的最小间隔
+	gpuSampleInterval = time.Minute
+	// gpuTelemetryTimeout 单个节点一轮采集的超时，不含探测镜像拉取
+	gpuTelemetryTimeout = 20 * time.Second
+	// gpuProbePullTimeout 后台拉取探测镜像的超时
+	gpuProbePullTimeout = 10 * time.Minute
+	// gpuTelemetryMaxBackoff 连续采集失败后的最长重试间隔
+	gpuTelemetryMaxBackoff = 30 * time.Minute
+)
+
+// gpuTelemetrySnapshot 节点最近一次遥测
+type gpuTelemetrySnapshot struct {
+	at    time.Time
+	cards []computenode.NodeGpuTelemetry
+	// instanceUsage 实例显存使用率(%)与分配的显存(GB)
+	instanceUsage map[uint]instanceGpuUsage
+}
+
+type instanceGpuUsage struct {
+	rate        float64
+	allocatedGB float64
+	// smUtil 实例所用各卡SM利用率的平均值，共享卡按整卡计
+	smUtil    float64
+	hasSmUtil bool
+}
+
+var (
+	gpuTelemetryCache sync.Map // nodeID -> gpuTelemetrySnapshot
+	gpuSampleAt       sync.Map // nodeID -> 最近一次写入历史的时间
+	gpuTelemetryRetry sync.Map // nodeID -> gpuTelemetryFailure，连续失败后的退避状态
+	gpuProbePulling   sync.Map // nodeID -> struct{}，正在后台拉取探测镜像的节点
+)
+
+// gpuTelemetryFailure 节点连续采集失败次数与下次允许采集的时间
+type gpuTelemetryFailure struct {
+	failures int
+	retryAt  time.Time
+}
+
+// gpuTelemetryRetryDelay 连续失败 failures 次后的重试间隔：从一分钟开始翻倍，最长 gpuTelemetryMaxBackoff
+func gpuTelemetryRetryDelay(failures int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < failures && delay < gpuTelemetryMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, gpuTelemetryMaxBackoff)
+}
+
+// CollectGpuTelemetry 采集节点显卡遥测（每卡利用率、显存、温度、功耗、ECC与计算进程）
+func (d *DockerService) CollectGpuTelemetry(ctx context.Context, node *computenode.ComputeNode, probeImage string) ([]computenode.NodeGpuTelemetry, error) {
+	// 镜像拉取可能远超单轮采集的超时，由调用方在后台拉取
+	out, err := d.runHelper(ctx, node, probeImage, []string{"sh", "-c", gpuTelemetryScript}, helperOptions{gpus: true, hostPid: true, noPull: true})
+	if err != nil {
+		return nil, err
+	}
+	return parseGpuTelemetry(out)
+}
+
+// parseTelemetryFloat 解析 nvidia-smi 数值，[N/A]、[Not Supported] 等返回 nil
+func parseTelemetryFloat(s string) *float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func parseTelemetryInt(s string) *int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// parseGpuTelemetry 解析 gpuTelemetryScript 的输出，进程按 GPU UUID 归入对应的卡
+func parseGpuTelemetry(out string) ([]computenode.NodeGpuTelemetry, error) {
+	var cards []computenode.NodeGpuTelemetry
+	byUUID := make(map[string]int)
+	inProcesses := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == gpuProcessSeparator {
+			inProcesses = true
+			continue
+		}
+		if inProcesses {
+			fields := strings.SplitN(line, ",", 5)
+			if len(fields) < 5 {
+				continue
+			}
+			idx, ok := byUUID[strings.TrimSpace(fields[0])]
+			if !ok {
+				continue
+			}
+			pid, _ := strconv.Atoi(strings.TrimSpace(fields[1]))
+			mem := parseTelemetryInt(fields[2])
+			p := computenode.GpuProcessInfo{Pid: pid, ContainerId: strings.TrimSpace(fields[3]), Name: strings.TrimSpace(fields[4])}
+			if mem != nil {
+				p.UsedMemoryMb = *mem
+			}
+			cards[idx].Processes = append(cards[idx].Processes, p)
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 12 {
+			return nil, fmt.Errorf("无法解析 nvidia-smi 输出: %s", line)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("无法解析显卡序号: %s", line)
+		}
+		card := computenode.NodeGpuTelemetry{
+			GpuIndex: index,
+			UUID:     strings.TrimSpace(fields[1]),
+			Name:     strings.TrimSpace(fields[2]),
+			GpuTelemetryMetrics: computenode.GpuTelemetryMetrics{
+				SmUtil:         parseTelemetryFloat(fields[3]),
+				MemUtil:        parseTelemetryFloat(fields[4]),
+				MemoryUsedMb:   parseTelemetryInt(fields[5]),
+				MemoryTotalMb:  parseTelemetryInt(fields[6]),
+				TemperatureC:   parseTelemetryFloat(fields[7]),
+				PowerDrawW:     parseTelemetryFloat(fields[8]),
+				PowerLimitW:    parseTelemetryFloat(fields[9]),
+				EccCorrected:   parseTelemetryInt(fields[10]),
+				EccUncorrected: parseTelemetryInt(fields[11]),
+			},
+		}
+		byUUID[card.UUID] = len(cards)
+		cards = append(cards, card)
+	}
+	if len(cards) == 0 {
+		return nil, errors.New("nvidia-smi 未返回显卡信息")
+	}
+	return cards, nil
+}
+
+// attributeGpuUsage 把进程按容器ID映射到实例，并合并GPU分配记录得到每张卡上的实例
+// 返回各实例的显存使用率：独占整卡时取卡的已用显存，多个实例共享一张卡（显存切分）时按实例进程占用的显存计算。
+func attributeGpuUsage(cards []computenode.NodeGpuTelemetry, containerInstances map[string]uint, allocs []instanceModel.GpuAllocation) map[uint]instanceGpuUsage {
+	cardAllocs := make(map[int][]instanceModel.GpuAllocation)
+	for _, a := range allocs {
+		cardAllocs[a.DeviceIndex] = append(cardAllocs[a.DeviceIndex], a)
+	}
+
+	usedMb := make(map[uint]float64)
+	allocatedMb := make(map[uint]float64)
+	smSum := make(map[uint]float64)
+	smCards := make(map[uint]int)
+	for i := range cards {
+		card := &cards[i]
+		owners := make(map[uint]bool)
+		for _, a := range cardAllocs[card.GpuIndex] {
+			owners[a.InstanceId] = true
+		}
+		procMb := make(map[uint]float64)
+		for j := range card.Processes {
+			p := &card.Processes[j]
+			if p.ContainerId == "" {
+				continue
+			}
+			if id, ok := containerInstances[p.ContainerId]; ok {
+				p.InstanceId = id
+				owners[id] = true
+				procMb[id] += float64(p.UsedMemoryMb)
+			}
+		}
+		card.InstanceIds = card.InstanceIds[:0]
+		for id := range owners {
+			card.InstanceIds = append(card.InstanceIds, id)
+		}
+		sort.Slice(card.InstanceIds, func(a, b int) bool { return card.InstanceIds[a] < card.InstanceIds[b] })
+
+		totalMb := float64(safeInt64(card.MemoryTotalMb))
+		exclusive := len(cardAllocs[card.GpuIndex]) == 1
+		for _, a := range cardAllocs[card.GpuIndex] {
+			alloc := float64(a.MemoryGb) * 1024
+			if alloc <= 0 || alloc > totalMb && totalMb > 0 {
+				alloc = totalMb
+			}
+			allocatedMb[a.InstanceId] += alloc
+			if card.SmUtil != nil {
+				smSum[a.InstanceId] += *card.SmUtil
+				smCards[a.InstanceId]++
+			}
+			if exclusive && card.MemoryUsedMb != nil {
+				usedMb[a.InstanceId] += float64(*card.MemoryUsedMb)
+			} else {
+				usedMb[a.InstanceId] += procMb[a.InstanceId]
+			}
+		}
+	}
+
+	res := make(map[uint]instanceGpuUsage, len(allocatedMb))
+	for id, alloc := range allocatedMb {
+		u := instanceGpuUsage{allocatedGB: alloc / 1024}
+		if alloc > 0 {
+			u.rate = roundToTwoDecimals(min(usedMb[id]/alloc*100, 100))
+		}
+		if n := smCards[id]; n > 0 {
+			u.smUtil = roundToTwoDecimals(smSum[id] / float64(n))
+			u.hasSmUtil = true
+		}
+		res[id] = u
+	}
+	return res
+}
+
+// CollectAllNodeGpuTelemetry 节点级显卡遥测：每个有GPU且连接正常的节点每轮运行一次辅助容器，由定时任务调用
+// 结果写入最新遥测表与历史采样表，并缓存供本轮容器指标刷新使用，避免逐个容器 exec nvidia-smi。
+// 节点上尚无探测镜像时本轮跳过并在后台拉取；连续失败的节点按 gpuTelemetryRetryDelay 退避。
+func CollectAllNodeGpuTelemetry(ctx context.Context) {
+	cfg := global.GVA_CONFIG.Discovery
+	if !cfg.GpuTelemetry || cfg.GpuProbeImage == "" {
+		return
+	}
+	var nodes []computenode.ComputeNode
+	if err := global.GVA_DB.Where("docker_status = ? AND gpu_count > 0", "connected").Find(&nodes).Error; err != nil {
+		global.GVA_LOG.Error("查询算力节点失败", zap.Error(err))
+		return
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+	for i := range nodes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(node *computenode.ComputeNode) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if v, ok := gpuTelemetryRetry.Load(node.ID); ok && time.Now().Before(v.(gpuTelemetryFailure).retryAt) {
+				return
+			}
+			collectCtx, cancel := context.WithTimeout(ctx, gpuTelemetryTimeout)
+			defer cancel()
+			err := refreshNodeGpuTelemetry(collectCtx, node, cfg.GpuProbeImage)
+			switch {
+			case err == nil:
+				gpuTelemetryRetry.Delete(node.ID)
+			case errors.Is(err, errProbeImageNotReady):
+				pullGpuProbeImage(node, cfg.GpuProbeImage)
+			default:
+				var failure gpuTelemetryFailure
+				if v, ok := gpuTelemetryRetry.Load(node.ID); ok {
+					failure = v.(gpuTelemetryFailure)
+				}
+				failure.failures++
+				failure.retryAt = time.Now().Add(gpuTelemetryRetryDelay(failure.failures))
+				gpuTelemetryRetry.Store(node.ID, failure)
+				global.GVA_LOG.Warn("采集节点显卡遥测失败", zap.Uint("nodeId", node.ID), zap.Int("failures", failure.failures),
+					zap.Time("retryAt", failure.retryAt), zap.Error(err))
+			}
+		}(&nodes[i])
+	}
+	wg.Wait()
+}
+
+// pullGpuProbeImage 在后台拉取节点的探测镜像，同一节点同时只拉取一次
+func pullGpuProbeImage(node *computenode.ComputeNode, probeImage string) {
+	if _, loaded := gpuProbePulling.LoadOrStore(node.ID, struct{}{}); loaded {
+		return
+	}
+	n := *node
+	go func() {
+		defer gpuProbePulling.Delete(n.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), gpuProbePullTimeout)
+		defer cancel()
+		global.GVA_LOG.Info("开始拉取探测镜像", zap.Uint("nodeId", n.ID), zap.String("image", probeImage))
+		if err := dockerService.ImagePull(ctx, &n, probeImage, nil, nil); err != nil {
+			global.GVA_LOG.Warn("拉取探测镜像失败", zap.Uint("nodeId", n.ID), zap.String("image", probeImage), zap.Error(err))
+		}
+	}()
+}
+
+func refreshNodeGpuTelemetry(ctx context.Context, node *computenode.ComputeNode, probeImage string) error {
+	cards, err := dockerService.CollectGpuTelemetry(ctx, node, probeImage)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var instances []instanceModel.Instance
+	if err = global.GVA_DB.Select("id", "container_id").
+		Where("node_id = ? AND container_id IS NOT NULL AND container_id <> ''", node.ID).
+		Find(&instances).Error; err != nil {
+		return fmt.Errorf("查询节点实例失败: %v", err)
+	}
+	containerInstances := make(map[string]uint, len(instances))
+	for _, inst := range instances {
+		containerInstances[*inst.ContainerId] = inst.ID
+	}
+	var allocs []instanceModel.GpuAllocation
+	if err = global.GVA_DB.Where("node_id = ?", node.ID).Find(&allocs).Error; err != nil {
+		return fmt.Errorf("查询GPU分配记录失败: %v", err)
+	}
+	usage := attributeGpuUsage(cards, containerInstances, allocs)
+	gpuTelemetryCache.Store(node.ID, gpuTelemetrySnapshot{at: now, cards: cards, instanceUsage: usage})
+
+	indexes := make([]int, 0, len(cards))
+	for i := range cards {
+		cards[i].NodeId = node.ID
+		cards[i].CollectedAt = now
+		indexes = append(indexes, cards[i].GpuIndex)
+	}
+	if err = global.GVA_DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "node_id"}, {Name: "gpu_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "uuid", "name", "sm_util", "mem_util", "memory_used_mb", "memory_total_mb",
+			"temperature_c", "power_draw_w", "power_limit_w", "ecc_corrected", "ecc_uncorrected", "processes", "instance_ids", "collected_at"}),
+	}).Create(&cards).Error; err != nil {
+		return fmt.Errorf("保存显卡遥测失败: %v", err)
+	}
+	// 已拔除或不可见的卡不再保留最新遥测
+	global.GVA_DB.Unscoped().Where("node_id = ? AND gpu_index NOT IN ?", node.ID, indexes).Delete(&computenode.NodeGpuTelemetry{})
+
+	if last, ok := gpuSampleAt.Load(node.ID); ok && now.Sub(last.(time.Time)) < gpuSampleInterval {
+		return nil
+	}
+	samples := make([]computenode.NodeGpuSample, len(cards))
+	for i, c := range cards {
+		samples[i] = computenode.NodeGpuSample{NodeId: node.ID, GpuIndex: c.GpuIndex, GpuTelemetryMetrics: c.GpuTelemetryMetrics, CollectedAt: now}
+	}
+	if err = global.GVA_DB.Create(&samples).Error; err != nil {
+		return fmt.Errorf("保存显卡遥测历史失败: %v", err)
+	}
+	gpuSampleAt.Store(node.ID, now)
+	return nil
+}
+
+// freshInstanceGpuUsage 读取节点遥测快照中实例的显存使用率，快照过期或实例没有GPU分配时返回 false
+func freshInstanceGpuUsage(nodeID, instanceID uint) (instanceGpuUsage, bool) {
+	v, ok := gpuTelemetryCache.Load(nodeID)
+	if !ok {
+		return instanceGpuUsage{}, false
+	}
+	snap := v.(gpuTelemetrySnapshot)
+	if time.Since(snap.at) > gpuTelemetryFresh {
+		return instanceGpuUsage{}, false
+	}
+	u, ok := snap.instanceUsage[instanceID]
+	return u, ok
+}
+
+// GetNodeGpuTelemetry 查询节点各卡最新遥测
+func (instanceService *InstanceService) GetNodeGpuTelemetry(ctx context.Context, nodeID string) (cards []computenode.NodeGpuTelemetry, err error) {
+	err = global.GVA_DB.Where("node_id = ?", nodeID).Order("gpu_index").Find(&cards).Error
+	return cards, err
+}
+
+// GetNodeGpuHistory 查询节点显卡遥测历史，默认最近24小时
+func (instanceService *InstanceService) GetNodeGpuHistory(ctx context.Context, req instanceReq.GpuHistoryReq) (samples []computenode.NodeGpuSample, err error) {
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+	if len(req.TimeRange) == 2 {
+		start, end = req.TimeRange[0], req.TimeRange[1]
+	}
+	db := global.GVA_DB.Where("node_id = ? AND collected_at >= ? AND collected_at < ?", req.ID, start, end)
+	if req.GpuIndex != nil {
+		db = db.Where("gpu_index = ?", *req.GpuIndex)
+	}
+	err = db.Order("collected_at, gpu_index").Find(&samples).Error
+	return samples, err
+}
+
+// GetInstanceGpuTelemetry 查询实例所用显卡的最新遥测，只保留该实例的进程
+func (instanceService *InstanceService) GetInstanceGpuTelemetry(ctx context.Context, ID string, userID uint, isAdmin bool) ([]computenode.NodeGpuTelemetry, error) {
+	var inst instanceModel.Instance
+	if err := global.GVA_DB.Where("id = ?", ID).First(&inst).Error; err != nil {
+		return nil, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return nil, errors.New("无权查看此实例")
+	}
+	if inst.NodeId == nil {
+		return nil, nil
+	}
+	var cards []computenode.NodeGpuTelemetry
+	if err := global.GVA_DB.Where("node_id = ?", *inst.NodeId).Order("gpu_index").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	res := make([]computenode.NodeGpuTelemetry, 0, len(cards))
+	for _, c := range cards {
+		mine := false
+		for _, id := range c.InstanceIds {
+			mine = mine || id == inst.ID
+		}
+		if !mine {
+			continue
+		}
+		procs := make([]computenode.GpuProcessInfo, 0, len(c.Processes))
+		for _, p := range c.Processes {
+			if p.InstanceId == inst.ID {
+				procs = append(procs, p)
+			}
+		}
+		c.Processes = procs
+		c.InstanceIds = []uint{inst.ID}
+		res = append(res, c)
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+const testTelemetryOut = `0, GPU-aaa, NVIDIA A100-SXM4-80GB, 87, 40, 40960, 81920, 61, 312.45, 400.00, 0, 0
+1, GPU-bbb, NVIDIA A100-SXM4-80GB, 0, 0, 2048, 81920, 35, 60.10, 400.00, [N/A], [N/A]
+### processes
+GPU-aaa, 1201, 40000, 1111111111111111111111111111111111111111111111111111111111111111, python train.py --a, --b
+GPU-bbb, 1302, 1024, 2222222222222222222222222222222222222222222222222222222222222222, python
+GPU-bbb, 1303, 1024, 3333333333333333333333333333333333333333333333333333333333333333, python
+GPU-bbb, 99, 10, , Xorg
+`
+
+func TestParseGpuTelemetry(t *testing.T) {
+	cards, err := parseGpuTelemetry(testTelemetryOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("cards = %d, want 2", len(cards))
+	}
+	if cards[0].SmUtil == nil || *cards[0].SmUtil != 87 || cards[0].PowerDrawW == nil || *cards[0].PowerDrawW != 312.45 {
+		t.Fatalf("card0 metrics = %+v", cards[0].GpuTelemetryMetrics)
+	}
+	if cards[1].EccCorrected != nil || cards[1].EccUncorrected != nil {
+		t.Fatalf("[N/A] 应解析为 nil")
+	}
+	if len(cards[0].Processes) != 1 || cards[0].Processes[0].Name != "python train.py --a, --b" {
+		t.Fatalf("card0 processes = %+v", cards[0].Processes)
+	}
+	if len(cards[1].Processes) != 3 || cards[1].Processes[2].ContainerId != "" {
+		t.Fatalf("card1 processes = %+v", cards[1].Processes)
+	}
+
+	if _, err = parseGpuTelemetry("### processes\n"); err == nil {
+		t.Fatal("无显卡时应返回错误")
+	}
+	if _, err = parseGpuTelemetry("0, GPU-aaa, A100"); err == nil {
+		t.Fatal("字段不足时应返回错误")
+	}
+}
+
+func TestAttributeGpuUsage(t *testing.T) {
+	cards, err := parseGpuTelemetry(testTelemetryOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers := map[string]uint{
+		"1111111111111111111111111111111111111111111111111111111111111111": 1,
+		"2222222222222222222222222222222222222222222222222222222222222222": 2,
+		"3333333333333333333333333333333333333333333333333333333333333333": 3,
+	}
+	// 实例1独占0号卡，实例2、3各切分1号卡20GB
+	allocs := []instanceModel.GpuAllocation{
+		{InstanceId: 1, DeviceIndex: 0},
+		{InstanceId: 2, DeviceIndex: 1, MemoryGb: 20},
+		{InstanceId: 3, DeviceIndex: 1, MemoryGb: 20},
+	}
+	usage := attributeGpuUsage(cards, containers, allocs)
+
+	tests := []struct {
+		id          uint
+		rate        float64
+		allocatedGB float64
+	}{
+		{1, 50, 80},
+		{2, 5, 20},
+		{3, 5, 20},
+	}
+	for _, tt := range tests {
+		u, ok := usage[tt.id]
+		if !ok {
+			t.Fatalf("实例 %d 无显存使用率", tt.id)
+		}
+		if u.rate != tt.rate || u.allocatedGB != tt.allocatedGB {
+			t.Fatalf("实例 %d usage = %+v, want rate %v allocated %v", tt.id, u, tt.rate, tt.allocatedGB)
+		}
+	}
+	if got := cards[1].InstanceIds; len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("card1 instanceIds = %v", got)
+	}
+	if cards[1].Processes[0].InstanceId != 2 || cards[1].Processes[2].InstanceId != 0 {
+		t.Fatalf("进程映射错误: %+v", cards[1].Processes)
+	}
+}
+
+func TestGpuTelemetryRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{6, gpuTelemetryMaxBackoff},
+		{100, gpuTelemetryMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := gpuTelemetryRetryDelay(tt.failures); got != tt.want {
+			t.Fatalf("failures=%d got %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+)
+
+// nvidiaSmiGpuQuery 辅助容器执行的显卡查询，输出每卡一行：index, name, memory.total(MiB), uuid
+var nvidiaSmiGpuQuery = []string{"nvidia-smi", "--query-gpu=index,name,memory.total,uuid", "--format=csv,noheader,nounits"}
+
+// HardwareInfo 节点实际硬件信息
+type HardwareInfo struct {
+	CpuCores      int64
+	MemoryBytes   int64
+	DockerVersion string
+	OS            string
+	KernelVersion string
+	GpuCards      []computenode.GpuCardInfo
+	GpuError      error // 显卡采集失败不影响 CPU/内存结果
+}
+
+// DiscoverHardware 采集节点硬件：Docker Info 提供 CPU 与内存；
+// 再通过辅助容器执行 nvidia-smi，获取显卡型号、数量与每卡显存。
+func (d *DockerService) DiscoverHardware(ctx context.Context, node *computenode.ComputeNode, probeImage string) (*HardwareInfo, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取Docker信息失败: %v", err)
+	}
+	hw := &HardwareInfo{
+		CpuCores:      int64(info.NCPU),
+		MemoryBytes:   info.MemTotal,
+		DockerVersion: info.ServerVersion,
+		OS:            info.OperatingSystem,
+		KernelVersion: info.KernelVersion,
+	}
+	out, err := d.runHelper(ctx, node, probeImage, nvidiaSmiGpuQuery, helperOptions{gpus: true})
+	if err != nil {
+		hw.GpuError = err
+		return hw, nil
+	}
+	hw.GpuCards, hw.GpuError = parseNvidiaSmiGpuQuery(out)
+	return hw, nil
+}
+
+// dockerRootMount 辅助容器内 Docker 数据目录的只读挂载点
+const dockerRootMount = "/docker-root"
+
+// DiskUsage 磁盘容量与使用率
+type DiskUsage struct {
+	TotalGb     float64
+	UsedPercent float64
+}
+
+// CollectDockerDiskUsage 采集节点 Docker 数据目录所在磁盘的使用率：辅助容器只读挂载该目录后执行 df
+func (d *DockerService) CollectDockerDiskUsage(ctx context.Context, node *computenode.ComputeNode, probeImage string) (*DiskUsage, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	info, err := cli.Info(ctx)
+	cli.Close()
+	if err != nil {
+		return nil, fmt.Errorf("获取Docker信息失败: %v", err)
+	}
+	root := info.DockerRootDir
+	if root == "" {
+		root = "/var/lib/docker"
+	}
+	out, err := d.runHelper(ctx, node, probeImage, []string{"df", "-Pk", dockerRootMount},
+		helperOptions{binds: []string{root + ":" + dockerRootMount + ":ro"}})
+	if err != nil {
+		return nil, err
+	}
+	return parseDfOutput(out)
+}
+
+// parseDfOutput 解析 df -Pk 输出（表头之后一行），使用率与 df 一致按 已用/(已用+可用) 计算
+func parseDfOutput(out string) (*DiskUsage, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("无法解析 df 输出: %s", out)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("无法解析 df 输出: %s", lines[len(lines)-1])
+	}
+	total, err1 := strconv.ParseFloat(fields[1], 64)
+	used, err2 := strconv.ParseFloat(fields[2], 64)
+	avail, err3 := strconv.ParseFloat(fields[3], 64)
+	if err1 != nil || err2 != nil || err3 != nil || used+avail <= 0 {
+		return nil, fmt.Errorf("无法解析 df 输出: %s", lines[len(lines)-1])
+	}
+	return &DiskUsage{
+		TotalGb:     roundToTwoDecimals(total / (1 << 20)),
+		UsedPercent: roundToTwoDecimals(used / (used + avail) * 100),
+	}, nil
+}
+
+// helperOptions 辅助容器运行选项
+type helperOptions struct {
+	gpus    bool     // 挂载全部GPU（--gpus all）
+	hostPid bool     // 使用宿主机 PID 命名空间
+	binds   []string // 宿主机目录挂载
+	noPull  bool     // 镜像不存在时不拉取，直接返回 errProbeImageNotReady
+}
+
+// errProbeImageNotReady 节点上尚无探测镜像且调用方不允许在本次调用中拉取
+var errProbeImageNotReady = errors.New("节点上尚无探测镜像")
+
+// runHelper 运行一次性辅助容器执行 cmd，返回标准输出；probeImage 不存在时先拉取
+// hostPid 为 true 时共享宿主机 PID 命名空间，用于读取其他容器进程的 cgroup。
+func (d *DockerService) runHelper(ctx context.Context, node *computenode.ComputeNode, probeImage string, cmd []string, opts helperOptions) (string, error) {
+	if probeImage == "" {
+		return "", errors.New("未配置探测镜像")
+	}
+	exists, err := d.ImageExists(ctx, node, probeImage)
+	if err == nil && !exists {
+		if opts.noPull {
+			return "", errProbeImageNotReady
+		}
+		err = d.ImagePull(ctx, node, probeImage, nil, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("准备探测镜像失败: %v", err)
+	}
+
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return "", fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	hostConfig := &container.HostConfig{Binds: opts.binds}
+	if opts.gpus {
+		hostConfig.DeviceRequests = []container.DeviceRequest{{Driver: "nvidia", Count: -1, Capabilities: [][]string{{"gpu"}}}}
+	}
+	if opts.hostPid {
+		hostConfig.PidMode = "host"
+	}
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Image:      probeImage,
+		Entrypoint: cmd[:1],
+		Cmd:        cmd[1:],
+		Labels:     map[string]string{managedByLabel: managedByValue},
+	}, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("创建探测容器失败: %v", err)
+	}
+	defer cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	if err = cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("启动探测容器失败: %v", err)
+	}
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	var exitCode int64
+	select {
+	case err = <-errCh:
+		return "", fmt.Errorf("等待探测容器失败: %v", err)
+	case st := <-statusCh:
+		exitCode = st.StatusCode
+	}
+
+	logs, err := cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", fmt.Errorf("读取探测输出失败: %v", err)
+	}
+	defer logs.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err = stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return "", fmt.Errorf("读取探测输出失败: %v", err)
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("探测命令退出码 %d: %s", exitCode, strings.TrimSpace(stderr.String()+stdout.String()))
+	}
+	return stdout.String(), nil
+}
+
+// parseNvidiaSmiGpuQuery 解析 nvidiaSmiGpuQuery 的 CSV 输出
+func parseNvidiaSmiGpuQuery(out string) ([]computenode.GpuCardInfo, error) {
+	var cards []computenode.GpuCardInfo
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("无法解析 nvidia-smi 输出: %s", line)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("无法解析显卡序号: %s", line)
+		}
+		memMb, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析显存容量: %s", line)
+		}
+		card := computenode.GpuCardInfo{Index: index, Name: fields[1], MemoryMb: memMb}
+		if len(fields) > 3 {
+			card.UUID = fields[3]
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
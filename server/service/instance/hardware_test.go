@@ -0,0 +1,77 @@
+package instance
+
+import "testing"
+
+func TestParseNvidiaSmiGpuQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		out       string
+		wantCards int
+		wantMb    int64
+		wantErr   bool
+	}{
+		{name: "无显卡", out: "\n", wantCards: 0},
+		{
+			name:      "两张A100",
+			out:       "0, NVIDIA A100-SXM4-80GB, 81920, GPU-aaa\n1, NVIDIA A100-SXM4-80GB, 81920, GPU-bbb\n",
+			wantCards: 2,
+			wantMb:    81920,
+		},
+		{name: "字段不足", out: "0, NVIDIA A100", wantErr: true},
+		{name: "显存非数字", out: "0, NVIDIA A100, [N/A], GPU-aaa", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, err := parseNvidiaSmiGpuQuery(tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(cards) != tt.wantCards {
+				t.Fatalf("cards = %d, want %d", len(cards), tt.wantCards)
+			}
+			if tt.wantCards > 0 && cards[0].MemoryMb != tt.wantMb {
+				t.Fatalf("memoryMb = %d, want %d", cards[0].MemoryMb, tt.wantMb)
+			}
+		})
+	}
+}
+
+func TestParseDfOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		out         string
+		wantTotal   float64
+		wantPercent float64
+		wantErr     bool
+	}{
+		{
+			name:        "正常输出",
+			out:         "Filesystem 1024-blocks Used Available Capacity Mounted on\n/dev/sda1 104857600 78643200 26214400 75% /docker-root\n",
+			wantTotal:   100,
+			wantPercent: 75,
+		},
+		{
+			// 保留块不计入可用空间，使用率按 used/(used+avail) 计算
+			name:        "含保留块",
+			out:         "Filesystem 1024-blocks Used Available Capacity Mounted on\n/dev/sda1 1048576 450000 450000 50% /docker-root",
+			wantTotal:   1,
+			wantPercent: 50,
+		},
+		{name: "只有表头", out: "Filesystem 1024-blocks Used Available Capacity Mounted on", wantErr: true},
+		{name: "非数字", out: "Filesystem\n/dev/sda1 - - - - /docker-root", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDfOutput(tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.TotalGb != tt.wantTotal || got.UsedPercent != tt.wantPercent {
+				t.Fatalf("got %+v, want total %v percent %v", *got, tt.wantTotal, tt.wantPercent)
+			}
+		})
+	}
+}
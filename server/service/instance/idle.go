@@ -0,0 +1,283 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// idleStopRetryInterval 空闲停止失败后的最短重试间隔，策略宽限期更长时按宽限期重试
+const idleStopRetryInterval = 10 * time.Minute
+
+// idleStopFailedAt 实例ID -> 最近一次空闲停止失败的时间，避免每分钟重复停止并写入审计
+var idleStopFailedAt sync.Map
+
+// idleStopBackoff 停止失败后是否仍在退避期内
+func idleStopBackoff(p *idlepolicy.IdlePolicy, failedAt time.Time, now time.Time) bool {
+	wait := max(time.Duration(p.GraceMinutes)*time.Minute, idleStopRetryInterval)
+	return now.Sub(failedAt) < wait
+}
+
+// idleState 实例空闲计时
+type idleState struct {
+	since    *time.Time
+	warnedAt *time.Time
+}
+
+func (s idleState) equal(o idleState) bool {
+	eq := func(a, b *time.Time) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a.Equal(*b)
+	}
+	return eq(s.since, o.since) && eq(s.warnedAt, o.warnedAt)
+}
+
+// matchIdlePolicy 选择实例适用的空闲停止策略，只考虑已启用的策略，规格策略优先于角色策略
+func matchIdlePolicy(policies []idlepolicy.IdlePolicy, specID int64, authorityID uint) *idlepolicy.IdlePolicy {
+	var byAuthority *idlepolicy.IdlePolicy
+	for i := range policies {
+		p := &policies[i]
+		if !p.Enabled {
+			continue
+		}
+		switch {
+		case p.SubjectType == idlepolicy.SubjectSpec && int64(p.SubjectId) == specID:
+			return p
+		case p.SubjectType == idlepolicy.SubjectAuthority && p.SubjectId == authorityID && byAuthority == nil:
+			byAuthority = p
+		}
+	}
+	return byAuthority
+}
+
+// evaluateIdle 按本轮指标推进空闲计时，返回新的计时与需要执行的动作（空、warn 或 stop）
+// 没有指标时计时保持不变；指标回到阈值以上时清零。提醒后仍空闲 GraceMinutes 分钟才停止，GraceMinutes 为0时到期直接停止。
+func evaluateIdle(p *idlepolicy.IdlePolicy, value float64, ok bool, st idleState, now time.Time) (idleState, string) {
+	if !ok {
+		return st, ""
+	}
+	if value >= p.Threshold {
+		return idleState{}, ""
+	}
+	if st.since == nil {
+		st.since = &now
+	}
+	if st.warnedAt == nil {
+		if now.Sub(*st.since) < time.Duration(p.IdleMinutes)*time.Minute {
+			return st, ""
+		}
+		if p.GraceMinutes <= 0 {
+			return st, idlepolicy.ActionStop
+		}
+		st.warnedAt = &now
+		return st, idlepolicy.ActionWarn
+	}
+	if now.Sub(*st.warnedAt) >= time.Duration(p.GraceMinutes)*time.Minute {
+		return st, idlepolicy.ActionStop
+	}
+	return st, ""
+}
+
+// idleMetricValue 读取实例当前的空闲判定指标
+func idleMetricValue(metric string, inst *instanceModel.Instance) (float64, bool) {
+	switch metric {
+	case idlepolicy.MetricGpuUtil:
+		u, ok := freshInstanceGpuUsage(uint(*inst.NodeId), inst.ID)
+		return u.smUtil, ok && u.hasSmUtil
+	case idlepolicy.MetricGpuMemory:
+		if inst.GpuMemoryUsageRate == nil {
+			return 0, false
+		}
+		return *inst.GpuMemoryUsageRate, true
+	}
+	return 0, false
+}
+
+// EnforceIdlePolicies 按空闲停止策略提醒并停止长时间空闲的GPU实例，由定时任务调用
+func EnforceIdlePolicies(ctx context.Context) {
+	// 未运行的实例不再计时
+	if err := global.GVA_DB.Model(&instanceModel.Instance{}).
+		Where("idle_since IS NOT NULL AND (container_status IS NULL OR container_status <> ?)", "running").
+		Updates(map[string]any{"idle_since": nil, "idle_warned_at": nil}).Error; err != nil {
+		global.GVA_LOG.Warn("清理空闲计时失败", zap.Error(err))
+	}
+
+	var policies []idlepolicy.IdlePolicy
+	if err := global.GVA_DB.Where("enabled = ?", true).Order("id").Find(&policies).Error; err != nil {
+		global.GVA_LOG.Error("查询空闲停止策略失败", zap.Error(err))
+		return
+	}
+	var instances []instanceModel.Instance
+	if err := global.GVA_DB.Where("container_status = ? AND node_id IS NOT NULL AND spec_id IS NOT NULL AND user_id IS NOT NULL", "running").
+		Find(&instances).Error; err != nil {
+		global.GVA_LOG.Error("查询运行中实例失败", zap.Error(err))
+		return
+	}
+	if len(instances) == 0 {
+		return
+	}
+
+	var specIDs, userIDs []int64
+	for _, inst := range instances {
+		specIDs = append(specIDs, *inst.SpecId)
+		userIDs = append(userIDs, *inst.UserId)
+	}
+	gpuSpecs := make(map[int64]bool)
+	var specs []product.ProductSpec
+	global.GVA_DB.Unscoped().Select("id", "gpu_count").Where("id IN ?", dedupeInt64(specIDs)).Find(&specs)
+	for _, s := range specs {
+		gpuSpecs[int64(s.ID)] = s.GpuCount != nil && *s.GpuCount > 0
+	}
+	authorities := make(map[int64]uint)
+	var users []system.SysUser
+	global.GVA_DB.Select("id", "authority_id").Where("id IN ?", dedupeInt64(userIDs)).Find(&users)
+	for _, u := range users {
+		authorities[int64(u.ID)] = u.AuthorityId
+	}
+
+	now := time.Now()
+	running := make(map[uint]bool, len(instances))
+	for i := range instances {
+		inst := &instances[i]
+		running[inst.ID] = true
+		st := idleState{since: inst.IdleSince, warnedAt: inst.IdleWarnedAt}
+		next, action := idleState{}, ""
+		// 只对GPU实例生效；豁免实例仅在策略允许豁免时跳过
+		p := matchIdlePolicy(policies, *inst.SpecId, authorities[*inst.UserId])
+		exempt := p != nil && p.AllowOptOut && inst.IdleStopExempt != nil && *inst.IdleStopExempt
+		if p != nil && gpuSpecs[*inst.SpecId] && !exempt {
+			value, ok := idleMetricValue(p.Metric, inst)
+			next, action = evaluateIdle(p, value, ok, st, now)
+			if action == idlepolicy.ActionStop {
+				if failedAt, ok := idleStopFailedAt.Load(inst.ID); ok && idleStopBackoff(p, failedAt.(time.Time), now) {
+					action = ""
+				}
+			}
+			if action != "" {
+				applyIdleAction(ctx, inst, p, value, next, action)
+				continue
+			}
+		}
+		if next.since == nil {
+			idleStopFailedAt.Delete(inst.ID)
+		}
+		if !next.equal(st) {
+			saveIdleState(inst.ID, next)
+		}
+	}
+	// 已不在运行的实例不再需要退避记录
+	idleStopFailedAt.Range(func(k, _ any) bool {
+		if !running[k.(uint)] {
+			idleStopFailedAt.Delete(k)
+		}
+		return true
+	})
+}
+
+// saveIdleState 保存空闲计时，只更新仍在运行的实例
+func saveIdleState(instanceID uint, st idleState) {
+	if err := global.GVA_DB.Model(&instanceModel.Instance{}).
+		Where("id = ? AND container_status = ?", instanceID, "running").
+		Updates(map[string]any{"idle_since": st.since, "idle_warned_at": st.warnedAt}).Error; err != nil {
+		global.GVA_LOG.Warn("保存空闲计时失败", zap.Uint("实例ID", instanceID), zap.Error(err))
+	}
+}
+
+func applyIdleAction(ctx context.Context, inst *instanceModel.Instance, p *idlepolicy.IdlePolicy, value float64, st idleState, action string) {
+	name := safeString(inst.Name)
+	idleFor := time.Since(*st.since).Round(time.Minute)
+	metricName := "GPU利用率"
+	if p.Metric == idlepolicy.MetricGpuMemory {
+		metricName = "GPU显存使用率"
+	}
+	rec := idlepolicy.IdleStopRecord{
+		InstanceId: inst.ID,
+		UserId:     *inst.UserId,
+		PolicyId:   p.ID,
+		Action:     action,
+		Metric:     p.Metric,
+		Value:      value,
+		Threshold:  p.Threshold,
+		IdleSince:  st.since,
+		OccurredAt: time.Now(),
+	}
+
+	switch action {
+	case idlepolicy.ActionWarn:
+		saveIdleState(inst.ID, st)
+		rec.Detail = fmt.Sprintf("%s已连续 %s 低于 %.1f%%", metricName, idleFor, p.Threshold)
+		NotifyUser(uint(*inst.UserId), "实例空闲提醒",
+			fmt.Sprintf("您的实例 %s 的%s已连续 %s 低于 %.1f%%（当前 %.1f%%）。如在 %d 分钟内仍无负载，实例将被自动停止（数据不会删除）。",
+				name, metricName, idleFor, p.Threshold, value, p.GraceMinutes))
+	case idlepolicy.ActionStop:
+		err := (&InstanceService{}).StopContainer(ctx, strconv.FormatUint(uint64(inst.ID), 10))
+		if err != nil {
+			idleStopFailedAt.Store(inst.ID, time.Now())
+			rec.Action = idlepolicy.ActionStopFailed
+			rec.Detail = fmt.Sprintf("停止失败，%s后重试: %v", max(time.Duration(p.GraceMinutes)*time.Minute, idleStopRetryInterval), err)
+			global.GVA_LOG.Error("空闲停止实例失败", zap.Uint("实例ID", inst.ID), zap.Error(err))
+			break
+		}
+		idleStopFailedAt.Delete(inst.ID)
+		saveIdleState(inst.ID, idleState{})
+		rec.Detail = fmt.Sprintf("%s已连续 %s 低于 %.1f%%，已自动停止", metricName, idleFor, p.Threshold)
+		global.GVA_LOG.Info("实例空闲已自动停止", zap.Uint("实例ID", inst.ID), zap.Uint("策略ID", p.ID))
+		NotifyUser(uint(*inst.UserId), "实例已因空闲停止",
+			fmt.Sprintf("您的实例 %s 的%s已连续 %s 低于 %.1f%%，已被自动停止以释放GPU资源，数据不会删除，可随时重新启动。",
+				name, metricName, idleFor, p.Threshold))
+	}
+	if err := global.GVA_DB.Create(&rec).Error; err != nil {
+		global.GVA_LOG.Error("写入空闲停止审计失败", zap.Uint("实例ID", inst.ID), zap.Error(err))
+	}
+}
+
+// SetIdleStopExempt 设置实例是否豁免空闲自动停止，普通用户只能设置自己的实例
+// 策略未允许豁免时该标记不生效。
+func (instanceService *InstanceService) SetIdleStopExempt(ctx context.Context, req instanceReq.SetIdleStopExemptReq, userID uint, isAdmin bool) error {
+	var inst instanceModel.Instance
+	if err := global.GVA_DB.Where("id = ?", req.ID).First(&inst).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("实例不存在")
+		}
+		return fmt.Errorf("获取实例信息失败: %v", err)
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return errors.New("无权操作此实例")
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&inst).Updates(map[string]any{
+			"idle_stop_exempt": req.Exempt,
+			"idle_since":       nil,
+			"idle_warned_at":   nil,
+		}).Error; err != nil {
+			return err
+		}
+		rec := idlepolicy.IdleStopRecord{
+			InstanceId: inst.ID,
+			UserId:     safeInt64(inst.UserId),
+			Action:     idlepolicy.ActionOptIn,
+			OperatorId: userID,
+			Detail:     "取消空闲自动停止豁免",
+			OccurredAt: time.Now(),
+		}
+		if req.Exempt {
+			rec.Action = idlepolicy.ActionOptOut
+			rec.Detail = "设为空闲自动停止豁免"
+		}
+		return tx.Create(&rec).Error
+	})
+}
@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/idlepolicy"
+)
+
+func TestMatchIdlePolicy(t *testing.T) {
+	policies := []idlepolicy.IdlePolicy{
+		{SubjectType: idlepolicy.SubjectAuthority, SubjectId: 100, Enabled: true, Name: "role"},
+		{SubjectType: idlepolicy.SubjectSpec, SubjectId: 7, Enabled: true, Name: "spec"},
+		{SubjectType: idlepolicy.SubjectSpec, SubjectId: 8, Enabled: false, Name: "disabled"},
+	}
+	tests := []struct {
+		name      string
+		specID    int64
+		authority uint
+		want      string
+	}{
+		{"规格策略优先", 7, 100, "spec"},
+		{"退回角色策略", 9, 100, "role"},
+		{"停用的规格策略不生效", 8, 100, "role"},
+		{"无匹配", 9, 200, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if p := matchIdlePolicy(policies, tt.specID, tt.authority); p != nil {
+				got = p.Name
+			}
+			if got != tt.want {
+				t.Fatalf("policy = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateIdle(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	ago := func(m int) *time.Time {
+		v := now.Add(-time.Duration(m) * time.Minute)
+		return &v
+	}
+	p := &idlepolicy.IdlePolicy{Threshold: 5, IdleMinutes: 120, GraceMinutes: 30}
+	tests := []struct {
+		name       string
+		policy     *idlepolicy.IdlePolicy
+		value      float64
+		ok         bool
+		st         idleState
+		wantAction string
+		wantSince  bool
+		wantWarned bool
+	}{
+		{"开始计时", p, 1, true, idleState{}, "", true, false},
+		{"未满空闲时长", p, 1, true, idleState{since: ago(60)}, "", true, false},
+		{"满空闲时长提醒", p, 1, true, idleState{since: ago(120)}, idlepolicy.ActionWarn, true, true},
+		{"宽限期内", p, 1, true, idleState{since: ago(130), warnedAt: ago(10)}, "", true, true},
+		{"宽限期满停止", p, 1, true, idleState{since: ago(150), warnedAt: ago(30)}, idlepolicy.ActionStop, true, true},
+		{"恢复负载清零", p, 40, true, idleState{since: ago(150), warnedAt: ago(10)}, "", false, false},
+		{"无指标保持", p, 0, false, idleState{since: ago(150), warnedAt: ago(10)}, "", true, true},
+		{"无宽限期直接停止", &idlepolicy.IdlePolicy{Threshold: 5, IdleMinutes: 120}, 1, true, idleState{since: ago(120)}, idlepolicy.ActionStop, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, action := evaluateIdle(tt.policy, tt.value, tt.ok, tt.st, now)
+			if action != tt.wantAction {
+				t.Fatalf("action = %q, want %q", action, tt.wantAction)
+			}
+			if (st.since != nil) != tt.wantSince || (st.warnedAt != nil) != tt.wantWarned {
+				t.Fatalf("state = %+v, want since %v warned %v", st, tt.wantSince, tt.wantWarned)
+			}
+		})
+	}
+}
+
+func TestIdleStopBackoff(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		grace    int
+		failedAt time.Duration
+		want     bool
+	}{
+		{"无宽限期按最短间隔退避", 0, 5 * time.Minute, true},
+		{"无宽限期到达最短间隔重试", 0, 10 * time.Minute, false},
+		{"宽限期更长时按宽限期退避", 30, 20 * time.Minute, true},
+		{"宽限期满重试", 30, 30 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &idlepolicy.IdlePolicy{GraceMinutes: tt.grace}
+			if got := idleStopBackoff(p, now.Add(-tt.failedAt), now); got != tt.want {
+				t.Fatalf("backoff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
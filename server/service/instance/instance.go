@@ -27,7 +27,7 @@ type InstanceService struct{}
 
 var dockerService = &DockerService{}
 
-// CreateInstance 创建实例管理记录并创建Docker容器
+// CreateInstance 创建实例管理记录并提交后台创建Docker容器
 func (instanceService *InstanceService) CreateInstance(ctx context.Context, inst *instanceModel.Instance) (err error) {
 	// 1. 获取镜像信息
 	var image imageregistry.ImageRegistry
@@ -37,6 +37,9 @@ func (instanceService *InstanceService) CreateInstance(ctx context.Context, inst
 	if err = global.GVA_DB.Where("id = ?", *inst.ImageId).First(&image).Error; err != nil {
 		return fmt.Errorf("获取镜像信息失败: %v", err)
 	}
+	if err = checkImageAccess(&image, inst.UserId); err != nil {
+		return err
+	}
 
 	// 2. 获取产品规格信息
 	var spec product.ProductSpec
@@ -46,58 +49,96 @@ func (instanceService *InstanceService) CreateInstance(ctx context.Context, inst
 	if err = global.GVA_DB.Where("id = ?", *inst.SpecId).First(&spec).Error; err != nil {
 		return fmt.Errorf("获取产品规格信息失败: %v", err)
 	}
-
-	// 3. 获取算力节点信息
-	var node computenode.ComputeNode
-	if inst.NodeId == nil {
-		return fmt.Errorf("算力节点ID不能为空")
-	}
-	if err = global.GVA_DB.Where("id = ?", *inst.NodeId).First(&node).Error; err != nil {
-		return fmt.Errorf("获取算力节点信息失败: %v", err)
+	if err = checkBalance(global.GVA_DB, inst.UserId, &spec); err != nil {
+		return err
 	}
 
-	// 4. 先创建数据库记录获取ID
-	initialStatus := "creating"
-	inst.ContainerStatus = &initialStatus
-	if err = global.GVA_DB.Create(inst).Error; err != nil {
-		return fmt.Errorf("创建实例记录失败: %v", err)
+	// 3. 确定算力节点：未指定时按调度打分自动选择，候选节点提交时容量不足则依次回退
+	autoPlace := inst.NodeId == nil
+	var candidates []uint
+	var traceID string
+	if autoPlace {
+		if err = computenode.ValidateLabels(inst.Labels); err != nil {
+			return err
+		}
+		if candidates, traceID, err = instanceService.pickPlacementCandidates(ctx, inst, &image); err != nil {
+			return err
+		}
+	} else {
+		candidates = []uint{uint(*inst.NodeId)}
 	}
 
-	// 5. 生成容器名称
-	instanceName := inst.Name
-	if instanceName == nil || *instanceName == "" {
-		name := fmt.Sprintf("instance-%d", inst.ID)
-		instanceName = &name
+	var attempts []placementAttempt
+	for i, nodeID := range candidates {
+		var remaining []uint
+		if autoPlace {
+			remaining = candidates[i+1:]
+		}
+		err = createInstanceOnNode(inst, &image, &spec, nodeID, remaining)
+		if err == nil {
+			break
+		}
+		if !autoPlace || !errors.Is(err, errNodeUnavailable) {
+			return err
+		}
+		attempts = append(attempts, placementAttempt{NodeId: nodeID, Stage: "reserve", Error: err.Error()})
+		inst.ID = 0
+		inst.NodeId = nil
+	}
+	if autoPlace {
+		if err != nil {
+			writePlacementTraceLog(traceID, 0, spec.ID, attempts, 0, err)
+			return err
+		}
+		writePlacementTraceLog(traceID, inst.ID, spec.ID, attempts, uint(*inst.NodeId), nil)
 	}
-	containerName := dockerService.GenerateInstanceName(*instanceName, inst.ID)
+	recordProvisionEvent(inst.ID, provisionPending, eventSucceeded, 1, "已提交创建任务", 0)
 
-	// 6. 构建容器配置
-	containerConfig := dockerService.BuildContainerConfig(&image, &spec, &node, containerName)
+	// 4. 拉取镜像、创建并启动容器交由后台worker完成，通过 GetProvisionProgress 轮询进度
+	instanceProvisioner.enqueue(inst.ID)
+	return nil
+}
 
-	// 7. 创建Docker容器
-	containerID, err := dockerService.CreateContainer(ctx, &node, containerConfig)
-	if err != nil {
-		// 创建容器失败，更新状态
-		failedStatus := "failed"
-		global.GVA_DB.Model(inst).Updates(map[string]interface{}{
-			"container_status": failedStatus,
-		})
-		global.GVA_LOG.Error("创建Docker容器失败", zap.Error(err))
-		return fmt.Errorf("创建Docker容器失败: %v", err)
+// createInstanceOnNode 锁定节点复核容量后创建数据库记录（pending），并在同一事务内分配物理GPU卡
+// 客户端传入的节点来自之前的可用列表，提交时可能已被其他请求占满；remaining 为自动调度的备选节点。
+func createInstanceOnNode(inst *instanceModel.Instance, image *imageregistry.ImageRegistry, spec *product.ProductSpec, nodeID uint, remaining []uint) error {
+	var node computenode.ComputeNode
+	if err := global.GVA_DB.Where("id = ?", nodeID).First(&node).Error; err != nil {
+		return fmt.Errorf("获取算力节点信息失败: %v", err)
 	}
-
-	// 8. 更新实例记录
-	runningStatus := "running"
-	err = global.GVA_DB.Model(inst).Updates(map[string]interface{}{
-		"container_id":     containerID,
-		"container_name":   containerName,
-		"container_status": runningStatus,
-	}).Error
-	if err != nil {
-		global.GVA_LOG.Error("更新实例记录失败", zap.Error(err))
+	if err := checkSnapshotNode(image, node.ID); err != nil {
+		return err
+	}
+	if reason := affinityMismatch(&node, spec, image, false); reason != "" {
+		return fmt.Errorf("%w: %s", errNodeUnavailable, reason)
 	}
 
-	return nil
+	nodeId := int64(node.ID)
+	initialStatus := "creating"
+	pendingState := provisionPending
+	inst.NodeId = &nodeId
+	inst.ContainerStatus = &initialStatus
+	inst.ProvisionState = &pendingState
+	inst.PlacementCandidates = nil
+	if len(remaining) > 0 {
+		encoded := encodeCandidates(remaining)
+		inst.PlacementCandidates = &encoded
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := checkQuota(tx, inst.UserId, spec, 0); txErr != nil {
+			return txErr
+		}
+		if txErr := reserveNodeCapacity(tx, &node, spec, 0); txErr != nil {
+			return txErr
+		}
+		if txErr := tx.Create(inst).Error; txErr != nil {
+			return fmt.Errorf("创建实例记录失败: %v", txErr)
+		}
+		if _, txErr := allocateGpuDevices(tx, inst.ID, &node, spec); txErr != nil {
+			return fmt.Errorf("分配GPU失败: %w", txErr)
+		}
+		return nil
+	})
 }
 
 // DeleteInstance 删除实例管理记录并删除Docker容器
@@ -131,9 +172,14 @@ func (instanceService *InstanceService) DeleteInstance(ctx context.Context, ID s
 		}
 	}
 
-	// 3. 删除数据库记录
-	err = global.GVA_DB.Delete(&instanceModel.Instance{}, "id = ?", ID).Error
-	return err
+	// 3. 删除数据库记录并释放GPU卡，同时结束计费
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := releaseGpuDevices(tx, inst.ID); txErr != nil {
+			return fmt.Errorf("释放GPU分配失败: %v", txErr)
+		}
+		recordUsageEvent(tx, &inst, usageEventDelete, safeString(inst.ContainerStatus), "deleted")
+		return tx.Delete(&instanceModel.Instance{}, "id = ?", ID).Error
+	})
 }
 
 // DeleteInstanceByIds 批量删除实例管理记录并删除Docker容器
@@ -152,9 +198,13 @@ func (instanceService *InstanceService) DeleteInstanceByIds(ctx context.Context,
 }
 
 // UpdateInstance 更新实例管理记录
+// 节点、规格、容器、创建进度等由创建状态机、调度与变更规格维护，不接受客户端修改
 // Author [yourname](https://github.com/yourname)
 func (instanceService *InstanceService) UpdateInstance(ctx context.Context, inst instanceModel.Instance) (err error) {
-	err = global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(&inst).Error
+	err = global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).
+		Omit("idle_stop_exempt", "idle_since", "idle_warned_at",
+			"node_id", "spec_id", "container_id", "container_name", "container_status", "provision_state", "provision_error",
+			"placement_candidates", "pull_progress", "pull_detail", "migrate_target_node_id").Updates(&inst).Error
 	return err
 }
 
@@ -263,12 +313,13 @@ func (instanceService *InstanceService) GetInstanceInfoList(ctx context.Context,
 	err = db.Find(&instances).Error
 	return instances, total, err
 }
-func (instanceService *InstanceService) GetInstanceDataSource(ctx context.Context) (res map[string][]map[string]any, err error) {
+func (instanceService *InstanceService) GetInstanceDataSource(ctx context.Context, userID uint) (res map[string][]map[string]any, err error) {
 	res = make(map[string][]map[string]any)
 
-	// 只返回已上架的镜像
+	// 只返回已上架的镜像，私有镜像仅对所属用户可见
 	imageId := make([]map[string]any, 0)
 	global.GVA_DB.Table("image_registry").Where("deleted_at IS NULL AND is_on_shelf = ?", true).
+		Where("(is_private IS NULL OR is_private = ? OR owner_id = ?)", false, userID).
 		Select("name as label, id as value, support_memory_split as supportMemorySplit").Scan(&imageId)
 	res["imageId"] = imageId
 
@@ -415,6 +466,9 @@ type AvailableNode struct {
 	AvailableDataDisk   int64   `json:"availableDataDisk"` // 可用数据盘(GB)
 	PublicIp            string  `json:"publicIp"`
 	PricePerHour        float64 `json:"pricePerHour"`
+	ImageCached         bool    `json:"imageCached"`     // 节点上已缓存所需镜像
+	PerCardCapacity     int64   `json:"perCardCapacity"` // 单卡显存容量(GB)
+	CardMemoryUsage     []int64 `json:"cardMemoryUsage"` // 每张卡已分配的显存(GB)
 }
 
 // GetAvailableNodes 根据产品规格获取可用的算力节点
@@ -438,558 +492,32 @@ func (instanceService *InstanceService) GetAvailableNodes(ctx context.Context, s
 		return nil, err
 	}
 
-	// 3. 统计每个节点已被实例占用的资源
-	type UsedResource struct {
-		NodeId             int64   `json:"nodeId"`
-		GpuUsed            int64   `json:"gpuUsed"`
-		CpuUsed            int64   `json:"cpuUsed"`
-		MemUsed            int64   `json:"memUsed"`
-		MemoryCapacityUsed int64   `json:"memoryCapacityUsed"` // 已使用的显存容量（累加值）
-		CardMemoryUsage    []int64 `json:"cardMemoryUsage"`    // 每张卡已使用的显存容量
-		SystemDiskUsed     int64   `json:"systemDiskUsed"`
-		DataDiskUsed       int64   `json:"dataDiskUsed"`
-	}
-	usedResources := make(map[int64]UsedResource)
-
-	// 查询每个节点已创建的实例所占用的资源
-	var instances []instanceModel.Instance
-	global.GVA_DB.Where("deleted_at IS NULL").Find(&instances)
-
-	// 先获取所有节点信息，用于计算每张卡的显存容量
-	nodeInfoMap := make(map[int64]*computenode.ComputeNode)
-	for i := range allNodes {
-		nodeInfoMap[int64(allNodes[i].ID)] = &allNodes[i]
+	// 3. 按节点核算已被实例占用的资源
+	allocator, err := newResourceAllocator(global.GVA_DB, allNodes, meta.ExcludeInstanceId)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, inst := range instances {
-		if inst.NodeId == nil || inst.SpecId == nil {
-			continue
-		}
-		// 获取该实例使用的规格
-		var instSpec product.ProductSpec
-		if err := global.GVA_DB.Where("id = ?", *inst.SpecId).First(&instSpec).Error; err != nil {
-			continue
-		}
-		// 获取该实例使用的镜像，检查是否支持显存切分
-		var image imageregistry.ImageRegistry
-		supportMemorySplit := false
-		if inst.ImageId != nil {
-			if err := global.GVA_DB.Where("id = ?", *inst.ImageId).First(&image).Error; err == nil {
-				if image.SupportMemorySplit != nil {
-					supportMemorySplit = *image.SupportMemorySplit
-				}
-			}
-		}
-		nodeId := *inst.NodeId
-		used := usedResources[nodeId]
-		used.NodeId = nodeId
-
-		// 记录分配前的状态
-		beforeCardUsage := make([]int64, len(used.CardMemoryUsage))
-		copy(beforeCardUsage, used.CardMemoryUsage)
-
-		// 初始化卡显存使用数组
-		if used.CardMemoryUsage == nil {
-			used.CardMemoryUsage = make([]int64, 0)
-		}
-
-		// 获取节点信息
-		nodeInfo, hasNodeInfo := nodeInfoMap[nodeId]
-
-		if instSpec.GpuCount != nil {
-			used.GpuUsed += *instSpec.GpuCount
-		}
-		if instSpec.CpuCores != nil {
-			used.CpuUsed += *instSpec.CpuCores
-		}
-		if instSpec.MemoryGb != nil {
-			used.MemUsed += *instSpec.MemoryGb
-		}
-
-		// 显存容量计算：按卡分配
-		if instSpec.MemoryCapacity != nil && instSpec.GpuCount != nil && *instSpec.GpuCount > 0 {
-			memoryNeeded := *instSpec.MemoryCapacity
-			gpuCount := *instSpec.GpuCount
-
-			// 计算每张卡需要的显存
-			memoryPerCard := memoryNeeded / gpuCount
-
-			// 获取节点每张卡的显存容量（MemoryCapacity 存储的是单卡容量）
-			perCardCapacity := int64(0)
-			if hasNodeInfo && nodeInfo.MemoryCapacity != nil {
-				perCardCapacity = *nodeInfo.MemoryCapacity
-			}
+	// 已缓存所需镜像的节点，创建时可免去冷拉取
+	cachedNodes := loadCachedNodes(meta.ImageId)
+	image := loadAffinityImage(meta.ImageId)
 
-			// 确保卡显存使用数组有足够的长度
-			if hasNodeInfo && nodeInfo.GpuCount != nil {
-				totalCards := int(*nodeInfo.GpuCount)
-				for len(used.CardMemoryUsage) < totalCards {
-					used.CardMemoryUsage = append(used.CardMemoryUsage, 0)
-				}
-			}
-
-			// 分配显存到卡上
-			if supportMemorySplit && perCardCapacity > 0 {
-				// 支持显存切分：可以分配到任意卡上
-				// 优先分配到已有使用但未满的卡上，如果都满了，再分配到新卡上
-				for i := 0; i < int(gpuCount); i++ {
-					// 找到可以分配的卡（有剩余空间的卡）
-					found := false
-					// 优先查找已有使用但未满的卡
-					for j := range used.CardMemoryUsage {
-						remaining := perCardCapacity - used.CardMemoryUsage[j]
-						if remaining >= memoryPerCard {
-							used.CardMemoryUsage[j] += memoryPerCard
-							used.MemoryCapacityUsed += memoryPerCard
-							found = true
-							break
-						}
-					}
-					// 如果没找到可用卡，分配到新卡上（完全未使用的卡）
-					if !found && len(used.CardMemoryUsage) < int(*nodeInfo.GpuCount) {
-						used.CardMemoryUsage = append(used.CardMemoryUsage, memoryPerCard)
-						used.MemoryCapacityUsed += memoryPerCard
-					} else if !found {
-						// 没有可用卡，累加到总使用量（这种情况不应该发生，但为了安全）
-						used.MemoryCapacityUsed += memoryPerCard
-						global.GVA_LOG.Warn("无法分配到卡，累加到总使用量",
-							zap.Int64("显存", memoryPerCard))
-					}
-				}
-
-				// 重新计算总使用显存，确保与卡使用情况一致
-				totalCardUsage := int64(0)
-				for _, usage := range used.CardMemoryUsage {
-					totalCardUsage += usage
-				}
-				used.MemoryCapacityUsed = totalCardUsage
-			} else {
-				// 不支持显存切分：每张卡必须完全分配给一个实例，不能部分使用
-				// 如果每张卡需要的显存等于每张卡的容量，可以分配
-				if perCardCapacity > 0 && memoryPerCard == perCardCapacity {
-					for i := 0; i < int(gpuCount); i++ {
-						// 找到完全未使用的卡（使用量为0）
-						found := false
-						for j := range used.CardMemoryUsage {
-							if used.CardMemoryUsage[j] == 0 {
-								used.CardMemoryUsage[j] = perCardCapacity
-								used.MemoryCapacityUsed += perCardCapacity
-								found = true
-								break
-							}
-						}
-						if !found && len(used.CardMemoryUsage) < int(*nodeInfo.GpuCount) {
-							used.CardMemoryUsage = append(used.CardMemoryUsage, perCardCapacity)
-							used.MemoryCapacityUsed += perCardCapacity
-						}
-					}
-				} else if perCardCapacity > 0 && memoryPerCard < perCardCapacity {
-					// 如果每张卡需要的显存小于每张卡的容量，不支持显存切分时无法分配
-					// 但为了统计，累加到总使用量（实际无法分配）
-					used.MemoryCapacityUsed += memoryNeeded
-					global.GVA_LOG.Warn("不支持切分且显存不匹配，无法分配",
-						zap.Int64("每张卡需要", memoryPerCard),
-						zap.Int64("每张卡容量", perCardCapacity))
-				} else {
-					// 如果每张卡需要的显存大于每张卡的容量，无法分配，但累加到总使用量
-					used.MemoryCapacityUsed += memoryNeeded
-					global.GVA_LOG.Warn("显存需求超过卡容量，无法分配",
-						zap.Int64("每张卡需要", memoryPerCard),
-						zap.Int64("每张卡容量", perCardCapacity))
-				}
-
-				// 重新计算总使用显存，确保与卡使用情况一致
-				totalCardUsage := int64(0)
-				for _, usage := range used.CardMemoryUsage {
-					totalCardUsage += usage
-				}
-				used.MemoryCapacityUsed = totalCardUsage
-			}
-		} else if instSpec.MemoryCapacity != nil {
-			// 如果没有GPU数量，直接累加
-			used.MemoryCapacityUsed += *instSpec.MemoryCapacity
-		}
-
-		if instSpec.SystemDiskGb != nil {
-			used.SystemDiskUsed += *instSpec.SystemDiskGb
-		}
-		if instSpec.DataDiskGb != nil {
-			used.DataDiskUsed += *instSpec.DataDiskGb
-		}
-		usedResources[nodeId] = used
-	}
-
-	// 4. 筛选满足要求的节点
+	// 4. 筛选满足要求与调度约束的节点；变更规格时实例已在节点上，不受污点影响
 	nodes = make([]AvailableNode, 0)
-
-	// 判断是否需要GPU
-	requiredGpu := int64(0)
-	needGpu := false
-	if spec.GpuCount != nil && *spec.GpuCount > 0 {
-		requiredGpu = *spec.GpuCount
-		needGpu = true
-	}
-
-	for _, node := range allNodes {
-		// 如果需要GPU，检查显卡型号是否匹配
-		if needGpu {
-			if spec.GpuModel != nil && node.GpuName != nil {
-				if *spec.GpuModel != *node.GpuName {
-					continue
-				}
-			}
-		}
-
-		// 计算可用资源
-		nodeId := int64(node.ID)
-		used := usedResources[nodeId]
-
-		// 节点总GPU数量（仅在需要GPU时计算）
-		totalGpu := int64(0)
-		availableGpu := int64(0)
-		if needGpu {
-			if node.GpuCount != nil {
-				totalGpu = *node.GpuCount
-			}
-			availableGpu = totalGpu - used.GpuUsed
-		}
-
-		// 获取节点CPU
-		totalCpu := int64(0)
-		if node.Cpu != nil {
-			totalCpu = *node.Cpu
-		}
-		availableCpu := totalCpu - used.CpuUsed
-
-		// 获取节点内存
-		totalMem := int64(0)
-		if node.Memory != nil {
-			totalMem = *node.Memory
-		}
-		availableMem := totalMem - used.MemUsed
-
-		// 获取节点系统盘
-		totalSystemDisk := int64(0)
-		if node.SystemDisk != nil {
-			totalSystemDisk = *node.SystemDisk
-		}
-		availableSystemDisk := totalSystemDisk - used.SystemDiskUsed
-
-		// 获取节点数据盘
-		totalDataDisk := int64(0)
-		if node.DataDisk != nil {
-			totalDataDisk = *node.DataDisk
-		}
-		availableDataDisk := totalDataDisk - used.DataDiskUsed
-
-		// 检查是否满足规格要求
-		requiredCpu := int64(0)
-		if spec.CpuCores != nil {
-			requiredCpu = *spec.CpuCores
-		}
-		requiredMem := int64(0)
-		if spec.MemoryGb != nil {
-			requiredMem = *spec.MemoryGb
-		}
-		requiredSystemDisk := int64(0)
-		if spec.SystemDiskGb != nil {
-			requiredSystemDisk = *spec.SystemDiskGb
-		}
-		requiredDataDisk := int64(0)
-		if spec.DataDiskGb != nil {
-			requiredDataDisk = *spec.DataDiskGb
-		}
-		requiredMemoryCapacity := int64(0)
-		if spec.MemoryCapacity != nil {
-			requiredMemoryCapacity = *spec.MemoryCapacity
-		}
-
-		// 获取节点显存容量（MemoryCapacity 存储的是单卡容量）
-		perCardCapacity := int64(0)
-		if node.MemoryCapacity != nil {
-			perCardCapacity = *node.MemoryCapacity
-		}
-
-		// 计算总显存容量
-		totalMemoryCapacity := int64(0)
-		if node.GpuCount != nil && *node.GpuCount > 0 && perCardCapacity > 0 {
-			totalMemoryCapacity = perCardCapacity * *node.GpuCount
-		}
-
-		// 检查显存容量是否满足要求（按卡分配方式）
-		if requiredMemoryCapacity > 0 && needGpu && requiredGpu > 0 && perCardCapacity > 0 {
-			// 计算每张卡需要的显存
-			requiredMemoryPerCard := requiredMemoryCapacity / requiredGpu
-
-			// 初始化卡显存使用数组（如果还没有）
-			if used.CardMemoryUsage == nil {
-				used.CardMemoryUsage = make([]int64, 0)
-			}
-
-			// 确保数组长度足够
-			totalCards := int64(0)
-			if node.GpuCount != nil {
-				totalCards = *node.GpuCount
-			}
-			for len(used.CardMemoryUsage) < int(totalCards) {
-				used.CardMemoryUsage = append(used.CardMemoryUsage, 0)
-			}
-
-			// 获取产品规格是否支持显存分割
-			specSupportMemorySplit := false
-			if spec.SupportMemorySplit != nil {
-				specSupportMemorySplit = *spec.SupportMemorySplit
-			}
-
-			global.GVA_LOG.Info("显存匹配检查",
-				zap.Int64("节点ID", int64(node.ID)),
-				zap.Int64("总显存容量", totalMemoryCapacity),
-				zap.Int64("总卡数", totalCards),
-				zap.Int64("每张卡容量", perCardCapacity),
-				zap.Int64("需要显存", requiredMemoryCapacity),
-				zap.Int64("需要GPU数", requiredGpu),
-				zap.Int64("每张卡需要显存", requiredMemoryPerCard),
-				zap.Bool("规格支持显存分割", specSupportMemorySplit),
-				zap.Any("卡使用情况", used.CardMemoryUsage))
-
-			// 创建一个临时数组来模拟分配，检查是否可以满足需求
-			tempCardUsage := make([]int64, len(used.CardMemoryUsage))
-			copy(tempCardUsage, used.CardMemoryUsage)
-
-			canAllocate := true
-			if specSupportMemorySplit {
-				// 支持显存分割：根据每个卡的剩余可用显存容量进行判断
-				for i := int64(0); i < requiredGpu; i++ {
-					found := false
-					// 查找可以分配的卡（有足够剩余空间的卡）
-					for j := range tempCardUsage {
-						remaining := perCardCapacity - tempCardUsage[j]
-						if remaining >= requiredMemoryPerCard {
-							tempCardUsage[j] += requiredMemoryPerCard
-							global.GVA_LOG.Info("找到可用卡（支持显存分割）",
-								zap.Int64("节点ID", int64(node.ID)),
-								zap.Int("卡索引", j),
-								zap.Int64("卡剩余显存", remaining),
-								zap.Int64("需要显存", requiredMemoryPerCard))
-							found = true
-							break
-						}
-					}
-					// 如果没找到可用卡，检查是否有新卡可用
-					if !found {
-						if len(tempCardUsage) < int(totalCards) {
-							// 有新卡可用
-							tempCardUsage = append(tempCardUsage, requiredMemoryPerCard)
-							global.GVA_LOG.Info("使用新卡（支持显存分割）",
-								zap.Int64("节点ID", int64(node.ID)),
-								zap.Int("新卡索引", len(tempCardUsage)-1),
-								zap.Int64("分配显存", requiredMemoryPerCard))
-							found = true
-						}
-					}
-					if !found {
-						global.GVA_LOG.Warn("无法找到可用卡（支持显存分割）",
-							zap.Int64("节点ID", int64(node.ID)),
-							zap.Int64("需要GPU索引", i),
-							zap.Int64("需要显存", requiredMemoryPerCard),
-							zap.Any("当前卡使用情况", tempCardUsage))
-						canAllocate = false
-						break
-					}
-				}
-			} else {
-				// 不支持显存分割：需要整个卡的计算（每张卡必须完全未使用）
-				// 需要 requiredGpu 张完全未使用的卡
-				availableUnusedCards := int64(0)
-				for _, cardUsage := range tempCardUsage {
-					if cardUsage == 0 {
-						availableUnusedCards++
-					}
-				}
-				// 如果还有未使用的卡槽，也加上
-				if len(tempCardUsage) < int(totalCards) {
-					availableUnusedCards += totalCards - int64(len(tempCardUsage))
-				}
-
-				global.GVA_LOG.Info("检查未使用卡（不支持显存分割）",
-					zap.Int64("节点ID", int64(node.ID)),
-					zap.Int64("可用未使用卡数", availableUnusedCards),
-					zap.Int64("需要GPU数", requiredGpu))
-
-				if availableUnusedCards < requiredGpu {
-					global.GVA_LOG.Warn("未使用卡数不足（不支持显存分割）",
-						zap.Int64("节点ID", int64(node.ID)),
-						zap.Int64("可用未使用卡数", availableUnusedCards),
-						zap.Int64("需要GPU数", requiredGpu))
-					canAllocate = false
-				} else {
-					// 模拟分配：标记 requiredGpu 张卡为已使用
-					allocated := int64(0)
-					for j := range tempCardUsage {
-						if tempCardUsage[j] == 0 && allocated < requiredGpu {
-							tempCardUsage[j] = perCardCapacity // 标记为完全使用
-							allocated++
-						}
-					}
-					// 如果还有未使用的卡槽，也标记
-					for allocated < requiredGpu && len(tempCardUsage) < int(totalCards) {
-						tempCardUsage = append(tempCardUsage, perCardCapacity)
-						allocated++
-					}
-				}
-			}
-
-			// 如果无法分配，跳过该节点
-			if !canAllocate {
-				continue
-			}
-
-			// 显存检查通过（按卡分配），根据卡的使用情况计算实际可用GPU数量
-			if needGpu {
-				if specSupportMemorySplit {
-					// 支持显存分割：计算有多少张卡有足够的剩余显存
-					availableCards := int64(0)
-					for _, cardUsage := range used.CardMemoryUsage {
-						remaining := perCardCapacity - cardUsage
-						if remaining >= requiredMemoryPerCard {
-							availableCards++
-						}
-					}
-					// 如果还有未使用的卡槽，也加上
-					if len(used.CardMemoryUsage) < int(totalCards) {
-						availableCards += totalCards - int64(len(used.CardMemoryUsage))
-					}
-					availableGpu = availableCards
-					global.GVA_LOG.Info("根据卡使用情况计算可用GPU（支持显存分割）",
-						zap.Int64("节点ID", int64(node.ID)),
-						zap.Int64("可用卡数", availableCards),
-						zap.Int64("可用GPU", availableGpu))
-				} else {
-					// 不支持显存分割：计算有多少张完全未使用的卡
-					availableCards := int64(0)
-					for _, cardUsage := range used.CardMemoryUsage {
-						if cardUsage == 0 {
-							availableCards++
-						}
-					}
-					// 如果还有未使用的卡槽，也加上
-					if len(used.CardMemoryUsage) < int(totalCards) {
-						availableCards += totalCards - int64(len(used.CardMemoryUsage))
-					}
-					availableGpu = availableCards
-					global.GVA_LOG.Info("根据卡使用情况计算可用GPU（不支持显存分割）",
-						zap.Int64("节点ID", int64(node.ID)),
-						zap.Int64("可用未使用卡数", availableCards),
-						zap.Int64("可用GPU", availableGpu))
-				}
-			}
-		} else if requiredMemoryCapacity > 0 {
-			// 如果没有GPU需求，使用简单累加方式
-			availableMemoryCapacity := totalMemoryCapacity - used.MemoryCapacityUsed
-			if availableMemoryCapacity < requiredMemoryCapacity {
-				continue
-			}
-		}
-
-		// 资源不足则跳过（如果需要GPU才检查GPU资源）
-		if needGpu && availableGpu < requiredGpu {
-			continue
-		}
-		if availableCpu < requiredCpu {
-			continue
-		}
-		if availableMem < requiredMem {
-			continue
-		}
-		if availableSystemDisk < requiredSystemDisk {
+	for i := range allNodes {
+		if affinityMismatch(&allNodes[i], &spec, image, meta.ExcludeInstanceId != 0) != "" {
 			continue
 		}
-		if availableDataDisk < requiredDataDisk {
+		availableNode, ok := allocator.fit(&allNodes[i], &spec)
+		if !ok {
 			continue
 		}
-
-		// 计算单卡的可用显存容量（找到所有卡中剩余显存最大的那张卡的剩余显存）
-		availableMemoryCapacityPerCard := int64(0)
-		if node.MemoryCapacity != nil {
-			perCardCapacity := *node.MemoryCapacity
-
-			if node.GpuCount != nil && *node.GpuCount > 0 {
-				// 如果显存是按卡分配的，找到单卡的最大可用显存
-				if len(used.CardMemoryUsage) > 0 && perCardCapacity > 0 {
-					// 计算每张卡的剩余显存，找到最大的
-					maxAvailablePerCard := int64(0)
-					for _, cardUsage := range used.CardMemoryUsage {
-						if cardUsage < perCardCapacity {
-							remaining := perCardCapacity - cardUsage
-							if remaining > maxAvailablePerCard {
-								maxAvailablePerCard = remaining
-							}
-						}
-					}
-					availableMemoryCapacityPerCard = maxAvailablePerCard
-					// 如果还有未使用的卡槽，未使用的卡有完整的单卡容量
-					totalCards := int64(*node.GpuCount)
-					if len(used.CardMemoryUsage) < int(totalCards) {
-						// 未使用的卡有完整的单卡容量，这肯定比已使用卡的剩余显存大
-						availableMemoryCapacityPerCard = perCardCapacity
-					}
-				} else {
-					// 如果没有按卡分配，单卡可用显存就是单卡总容量
-					availableMemoryCapacityPerCard = perCardCapacity
-				}
-			} else {
-				// 如果没有GPU，单卡可用显存就是单卡总容量
-				availableMemoryCapacityPerCard = perCardCapacity
-			}
-		}
-
-		// 构建可用节点信息
-		availableNode := AvailableNode{
-			ID:                  node.ID,
-			AvailableGpu:        availableGpu,
-			AvailableCpu:        availableCpu,
-			AvailableMemory:     availableMem,
-			AvailableSystemDisk: availableSystemDisk,
-			AvailableDataDisk:   availableDataDisk,
-		}
-		if node.Name != nil {
-			availableNode.Name = *node.Name
-		}
-		if node.Region != nil {
-			availableNode.Region = *node.Region
-		}
-		if node.GpuName != nil {
-			availableNode.GpuName = *node.GpuName
-		}
-		if node.GpuCount != nil {
-			availableNode.GpuCount = *node.GpuCount
-		}
-		if node.Cpu != nil {
-			availableNode.Cpu = strconv.FormatInt(*node.Cpu, 10)
-		}
-		if node.Memory != nil {
-			availableNode.Memory = strconv.FormatInt(*node.Memory, 10)
-		}
-		// 返回单卡的可用显存容量
-		availableNode.MemoryCapacity = availableMemoryCapacityPerCard
-		if node.SystemDisk != nil {
-			availableNode.SystemDisk = strconv.FormatInt(*node.SystemDisk, 10)
-		}
-		if node.DataDisk != nil {
-			availableNode.DataDisk = strconv.FormatInt(*node.DataDisk, 10)
-		}
-		if node.PublicIp != nil {
-			availableNode.PublicIp = *node.PublicIp
-		}
-		if spec.PricePerHour != nil {
-			availableNode.PricePerHour = *spec.PricePerHour
-		}
-
+		availableNode.ImageCached = cachedNodes[allNodes[i].ID]
 		nodes = append(nodes, availableNode)
 	}
 
 	breakerOn := pcdnSchedulerRuntime.isCircuitOpened()
-	nodes, scoreDetails := scoreAndSortNodes(nodes, strategyVersion, strategyState, hitRule, breakerOn)
+	nodes, scoreDetails := scoreAndSortNodes(nodes, newSchedulingRequest(&spec), strategyVersion, strategyState, hitRule, breakerOn)
 	if forceStable && len(nodes) == 0 {
 		fallback := pcdnSchedulerRuntime.stableFallbackNodes()
 		if len(fallback) > 0 {
@@ -997,8 +525,8 @@ func (instanceService *InstanceService) GetAvailableNodes(ctx context.Context, s
 			hitRule += "_snapshot"
 		}
 	}
-	pcdnSchedulerRuntime.recordAndEvaluate(strategyVersion, strategyState, nodes, nil, time.Since(startAt))
-	writeSchedulingTraceLog(specIdStr, strategyVersion, strategyState, hitRule, meta, scoreDetails, len(nodes), nil)
+	pcdnSchedulerRuntime.recordAndEvaluate(strategyVersion, strategyState, hitRule, nodes, nil, time.Since(startAt))
+	writeSchedulingTraceLog(specIdStr, strategyVersion, strategyState, hitRule, meta, scoreDetails, nodes, breakerOn, time.Since(startAt), nil)
 	return nodes, nil
 }
 
@@ -1034,14 +562,34 @@ func (instanceService *InstanceService) StartContainer(ctx context.Context, ID s
 		return fmt.Errorf("容器ID为空")
 	}
 
+	if inst.SpecId != nil {
+		var spec product.ProductSpec
+		if err = global.GVA_DB.Where("id = ?", *inst.SpecId).First(&spec).Error; err == nil {
+			if err = checkBalance(global.GVA_DB, inst.UserId, &spec); err != nil {
+				return err
+			}
+			// 配额下调后，超出新配额的已停止实例不允许再启动
+			if err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+				return checkQuota(tx, inst.UserId, &spec, inst.ID)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
 	err = dockerService.StartContainer(ctx, node, *inst.ContainerId)
 	if err != nil {
 		return err
 	}
 
-	// 更新状态
+	// 更新状态并写入计量流水
+	fromStatus := safeString(inst.ContainerStatus)
 	status := "running"
-	return global.GVA_DB.Model(&inst).Update("container_status", status).Error
+	if err = global.GVA_DB.Model(&inst).Update("container_status", status).Error; err != nil {
+		return err
+	}
+	recordUsageEvent(global.GVA_DB, inst, usageEventStart, fromStatus, status)
+	return nil
 }
 
 // StopContainer 停止容器
@@ -1059,9 +607,14 @@ func (instanceService *InstanceService) StopContainer(ctx context.Context, ID st
 		return err
 	}
 
-	// 更新状态
+	// 更新状态并写入计量流水
+	fromStatus := safeString(inst.ContainerStatus)
 	status := "exited"
-	return global.GVA_DB.Model(&inst).Update("container_status", status).Error
+	if err = global.GVA_DB.Model(&inst).Update("container_status", status).Error; err != nil {
+		return err
+	}
+	recordUsageEvent(global.GVA_DB, inst, usageEventStop, fromStatus, status)
+	return nil
 }
 
 // RestartContainer 重启容器
@@ -1079,9 +632,14 @@ func (instanceService *InstanceService) RestartContainer(ctx context.Context, ID
 		return err
 	}
 
-	// 更新状态
+	// 更新状态并写入计量流水
+	fromStatus := safeString(inst.ContainerStatus)
 	status := "running"
-	return global.GVA_DB.Model(&inst).Update("container_status", status).Error
+	if err = global.GVA_DB.Model(&inst).Update("container_status", status).Error; err != nil {
+		return err
+	}
+	recordUsageEvent(global.GVA_DB, inst, usageEventRestart, fromStatus, status)
+	return nil
 }
 
 // GetContainerLogs 获取容器日志
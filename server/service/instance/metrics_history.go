@@ -0,0 +1,277 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 监控历史精度
+const (
+	MetricResolutionRaw = "raw"
+	MetricResolution5m  = "5m"
+	MetricResolution1h  = "1h"
+)
+
+// metricTier 一级监控历史存储，retention 需与 task.ClearTable 中的保留时长一致
+type metricTier struct {
+	resolution string
+	step       time.Duration
+	retention  time.Duration
+	table      string
+}
+
+var metricTiers = []metricTier{
+	{MetricResolutionRaw, 0, 24 * time.Hour, instanceModel.MetricSample{}.TableName()},
+	{MetricResolution5m, 5 * time.Minute, 30 * 24 * time.Hour, instanceModel.MetricRollup5m{}.TableName()},
+	{MetricResolution1h, time.Hour, 365 * 24 * time.Hour, instanceModel.MetricRollup1h{}.TableName()},
+}
+
+// metricRollupDelay 汇总时跳过最近的时间，等待本轮巡检的采样写完
+const metricRollupDelay = 2 * time.Minute
+
+// maxMetricPoints 单条曲线返回的最大点数，自动选择精度时用于避免返回过多原始采样
+const maxMetricPoints = 3000
+
+// MetricSeries 监控历史曲线
+type MetricSeries struct {
+	Resolution string                      `json:"resolution"`
+	Start      time.Time                   `json:"start"`
+	End        time.Time                   `json:"end"`
+	Points     []instanceModel.MetricPoint `json:"points"`
+}
+
+// newMetricPoint 单次采样点
+func newMetricPoint(instanceID, nodeID uint, at time.Time, cpu, mem, gpu float64) instanceModel.MetricPoint {
+	return instanceModel.MetricPoint{
+		InstanceId: instanceID,
+		NodeId:     nodeID,
+		BucketAt:   at,
+		CpuAvg:     cpu,
+		CpuMax:     cpu,
+		MemoryAvg:  mem,
+		MemoryMax:  mem,
+		GpuAvg:     gpu,
+		GpuMax:     gpu,
+		Samples:    1,
+	}
+}
+
+// nodeMetricPoints 按节点汇总同一轮采样，得到节点上各实例的平均值
+func nodeMetricPoints(points []instanceModel.MetricPoint, at time.Time) []instanceModel.MetricPoint {
+	merged := rollupMetricPoints(points, 0, true)
+	for i := range merged {
+		merged[i].BucketAt = at
+		merged[i].Samples = 1
+	}
+	return merged
+}
+
+// rollupMetricPoints 把采样点按 step 对齐的区间汇总，均值按 Samples 加权
+// byNode 为 true 时忽略实例维度，按节点汇总（此时 InstanceId 为 0）；step 为 0 时不按时间分桶。
+func rollupMetricPoints(points []instanceModel.MetricPoint, step time.Duration, byNode bool) []instanceModel.MetricPoint {
+	type key struct {
+		instanceID uint
+		nodeID     uint
+		bucket     int64
+	}
+	groups := make(map[key]*instanceModel.MetricPoint)
+	for _, p := range points {
+		k := key{instanceID: p.InstanceId, nodeID: p.NodeId}
+		if byNode {
+			k.instanceID = 0
+		}
+		if step > 0 {
+			k.bucket = p.BucketAt.Truncate(step).Unix()
+		}
+		w := p.Samples
+		if w <= 0 {
+			w = 1
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &instanceModel.MetricPoint{InstanceId: k.instanceID, NodeId: k.nodeID, BucketAt: time.Unix(k.bucket, 0)}
+			groups[k] = g
+		}
+		// 先累计加权和，最后统一除以样本数
+		g.CpuAvg += p.CpuAvg * float64(w)
+		g.MemoryAvg += p.MemoryAvg * float64(w)
+		g.GpuAvg += p.GpuAvg * float64(w)
+		g.CpuMax = max(g.CpuMax, p.CpuMax)
+		g.MemoryMax = max(g.MemoryMax, p.MemoryMax)
+		g.GpuMax = max(g.GpuMax, p.GpuMax)
+		g.Samples += w
+	}
+
+	res := make([]instanceModel.MetricPoint, 0, len(groups))
+	for _, g := range groups {
+		n := float64(g.Samples)
+		g.CpuAvg = roundToTwoDecimals(g.CpuAvg / n)
+		g.MemoryAvg = roundToTwoDecimals(g.MemoryAvg / n)
+		g.GpuAvg = roundToTwoDecimals(g.GpuAvg / n)
+		res = append(res, *g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].BucketAt.Equal(res[j].BucketAt) {
+			return res[i].BucketAt.Before(res[j].BucketAt)
+		}
+		if res[i].NodeId != res[j].NodeId {
+			return res[i].NodeId < res[j].NodeId
+		}
+		return res[i].InstanceId < res[j].InstanceId
+	})
+	return res
+}
+
+// recordMetricSamples 写入一轮采样的实例原始点与节点汇总点
+func recordMetricSamples(points []instanceModel.MetricPoint, at time.Time) {
+	if len(points) == 0 {
+		return
+	}
+	points = append(points, nodeMetricPoints(points, at)...)
+	rows := make([]instanceModel.MetricSample, len(points))
+	for i, p := range points {
+		rows[i] = instanceModel.MetricSample{MetricPoint: p}
+	}
+	if err := global.GVA_DB.CreateInBatches(&rows, 500).Error; err != nil {
+		global.GVA_LOG.Warn("写入监控历史失败", zap.Error(err))
+	}
+}
+
+// RollupMetrics 把已结束区间的原始采样汇总为5分钟点、5分钟点汇总为1小时点，由定时任务调用
+// 每级从目标表最后一个区间之后开始，只处理完整的区间，重复执行不会重复汇总。
+func RollupMetrics(ctx context.Context) {
+	now := time.Now().Add(-metricRollupDelay)
+	for i := 1; i < len(metricTiers); i++ {
+		src, dst := metricTiers[i-1], metricTiers[i]
+		if err := rollupMetricTier(src, dst, now); err != nil {
+			global.GVA_LOG.Error("汇总监控历史失败", zap.String("resolution", dst.resolution), zap.Error(err))
+		}
+	}
+}
+
+func rollupMetricTier(src, dst metricTier, now time.Time) error {
+	var last instanceModel.MetricPoint
+	var from time.Time
+	err := global.GVA_DB.Table(dst.table).Order("bucket_at desc").Limit(1).Take(&last).Error
+	switch {
+	case err == nil:
+		from = last.BucketAt.Add(dst.step)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		var first instanceModel.MetricPoint
+		err = global.GVA_DB.Table(src.table).Order("bucket_at").Limit(1).Take(&first).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询监控历史失败: %v", err)
+		}
+		from = first.BucketAt.Truncate(dst.step)
+	default:
+		return fmt.Errorf("查询监控历史失败: %v", err)
+	}
+	to := now.Truncate(dst.step)
+	if !from.Before(to) {
+		return nil
+	}
+
+	var points []instanceModel.MetricPoint
+	if err = global.GVA_DB.Table(src.table).Where("bucket_at >= ? AND bucket_at < ?", from, to).
+		Find(&points).Error; err != nil {
+		return fmt.Errorf("查询监控历史失败: %v", err)
+	}
+	merged := rollupMetricPoints(points, dst.step, false)
+	if len(merged) == 0 {
+		return nil
+	}
+	for i := range merged {
+		merged[i].ID = 0
+	}
+	switch dst.resolution {
+	case MetricResolution5m:
+		rows := make([]instanceModel.MetricRollup5m, len(merged))
+		for i, p := range merged {
+			rows[i] = instanceModel.MetricRollup5m{MetricPoint: p}
+		}
+		err = global.GVA_DB.CreateInBatches(&rows, 500).Error
+	case MetricResolution1h:
+		rows := make([]instanceModel.MetricRollup1h, len(merged))
+		for i, p := range merged {
+			rows[i] = instanceModel.MetricRollup1h{MetricPoint: p}
+		}
+		err = global.GVA_DB.CreateInBatches(&rows, 500).Error
+	}
+	if err != nil {
+		return fmt.Errorf("写入监控汇总失败: %v", err)
+	}
+	return nil
+}
+
+// pickMetricTier 选择查询精度：指定精度时直接使用；否则选择保留期覆盖区间起点、且点数不超过上限的最细精度
+func pickMetricTier(resolution string, start, end, now time.Time) metricTier {
+	for _, t := range metricTiers {
+		if t.resolution == resolution {
+			return t
+		}
+	}
+	for _, t := range metricTiers {
+		step := t.step
+		if step == 0 {
+			step = 30 * time.Second
+		}
+		if now.Sub(start) <= t.retention && end.Sub(start)/step <= maxMetricPoints {
+			return t
+		}
+	}
+	return metricTiers[len(metricTiers)-1]
+}
+
+// queryMetricSeries 查询一条监控曲线，instanceID 为 0 时查询节点汇总
+func queryMetricSeries(req instanceReq.MetricsQueryReq, instanceID, nodeID uint) (res MetricSeries, err error) {
+	now := time.Now()
+	res.End = now
+	res.Start = now.Add(-24 * time.Hour)
+	if len(req.TimeRange) == 2 {
+		res.Start, res.End = req.TimeRange[0], req.TimeRange[1]
+	}
+	if !res.Start.Before(res.End) {
+		return res, errors.New("查询区间无效")
+	}
+	tier := pickMetricTier(req.Resolution, res.Start, res.End, now)
+	res.Resolution = tier.resolution
+
+	db := global.GVA_DB.Table(tier.table).Where("instance_id = ? AND bucket_at >= ? AND bucket_at < ?", instanceID, res.Start, res.End)
+	if nodeID != 0 {
+		db = db.Where("node_id = ?", nodeID)
+	}
+	err = db.Order("bucket_at").Find(&res.Points).Error
+	return res, err
+}
+
+// GetInstanceMetrics 查询实例监控历史，普通用户只能查看自己的实例
+func (instanceService *InstanceService) GetInstanceMetrics(ctx context.Context, req instanceReq.MetricsQueryReq, userID uint, isAdmin bool) (MetricSeries, error) {
+	var inst instanceModel.Instance
+	if err := global.GVA_DB.Unscoped().Select("id", "user_id").Where("id = ?", req.ID).First(&inst).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return MetricSeries{}, errors.New("实例不存在")
+		}
+		return MetricSeries{}, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return MetricSeries{}, errors.New("无权查看此实例")
+	}
+	return queryMetricSeries(req, inst.ID, 0)
+}
+
+// GetNodeMetrics 查询节点监控历史（节点上运行实例的平均值）
+func (instanceService *InstanceService) GetNodeMetrics(ctx context.Context, req instanceReq.MetricsQueryReq) (MetricSeries, error) {
+	return queryMetricSeries(req, 0, req.ID)
+}
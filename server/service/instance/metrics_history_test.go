@@ -0,0 +1,64 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+func TestRollupMetricPoints(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	points := []instanceModel.MetricPoint{
+		newMetricPoint(1, 7, base, 10, 20, 0),
+		newMetricPoint(1, 7, base.Add(30*time.Second), 30, 40, 90),
+		newMetricPoint(2, 7, base.Add(time.Minute), 50, 50, 50),
+		newMetricPoint(1, 7, base.Add(5*time.Minute), 60, 60, 60),
+	}
+
+	got := rollupMetricPoints(points, 5*time.Minute, false)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3: %+v", len(got), got)
+	}
+	first := got[0]
+	if first.InstanceId != 1 || !first.BucketAt.Equal(base) || first.Samples != 2 {
+		t.Fatalf("first = %+v", first)
+	}
+	if first.CpuAvg != 20 || first.CpuMax != 30 || first.GpuAvg != 45 || first.GpuMax != 90 {
+		t.Fatalf("first = %+v", first)
+	}
+
+	// 5分钟点再汇总为小时点时按样本数加权
+	hourly := rollupMetricPoints([]instanceModel.MetricPoint{first, got[2]}, time.Hour, false)
+	if len(hourly) != 1 || hourly[0].Samples != 3 || hourly[0].CpuAvg != 33.33 {
+		t.Fatalf("hourly = %+v", hourly)
+	}
+
+	nodes := nodeMetricPoints(points[:3], base)
+	if len(nodes) != 1 || nodes[0].InstanceId != 0 || nodes[0].NodeId != 7 || nodes[0].CpuAvg != 30 || nodes[0].Samples != 1 {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+}
+
+func TestPickMetricTier(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		resolution string
+		start, end time.Time
+		want       string
+	}{
+		{name: "昨晚", start: now.Add(-16 * time.Hour), end: now.Add(-8 * time.Hour), want: MetricResolutionRaw},
+		{name: "近一周", start: now.Add(-7 * 24 * time.Hour), end: now, want: MetricResolution5m},
+		{name: "超过原始保留期的一小时", start: now.Add(-48 * time.Hour), end: now.Add(-47 * time.Hour), want: MetricResolution5m},
+		{name: "近半年", start: now.Add(-180 * 24 * time.Hour), end: now, want: MetricResolution1h},
+		{name: "指定精度", resolution: MetricResolution1h, start: now.Add(-time.Hour), end: now, want: MetricResolution1h},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickMetricTier(tt.resolution, tt.start, tt.end, now).resolution; got != tt.want {
+				t.Fatalf("resolution = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	imageregistrySvc "github.com/flipped-aurora/gin-vue-admin/server/service/imageregistry"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// provisionMigrating 迁移中，期间状态巡检跳过该实例
+const provisionMigrating = "migrating"
+
+// MigrateResult 实例迁移结果
+type MigrateResult struct {
+	InstanceId   uint   `json:"instanceId"`
+	FromNodeId   uint   `json:"fromNodeId"`
+	ToNodeId     uint   `json:"toNodeId"`
+	ContainerId  string `json:"containerId"`
+	DataVolume   string `json:"dataVolume"`
+	DurationMs   int64  `json:"durationMs"`
+	VolumeCopied bool   `json:"volumeCopied"`
+}
+
+// MigrateInstance 将实例迁移到另一算力节点并保留数据卷
+// 流程：目标节点拉取镜像并分配GPU → 停止原容器 → 通过辅助容器以tar流复制数据卷 →
+// 在目标节点按原规格/镜像重建容器 → 原子更新 NodeId/ContainerId → 删除原节点容器与数据卷。
+// 任一步失败时删除目标节点上的容器和数据卷、恢复GPU分配并重新启动原容器。
+func (instanceService *InstanceService) MigrateInstance(ctx context.Context, req instanceReq.MigrateInstanceReq) (res MigrateResult, err error) {
+	startAt := time.Now()
+	inst, srcNode, err := instanceService.getInstanceAndNode(strconv.FormatUint(uint64(req.ID), 10))
+	if err != nil {
+		return res, err
+	}
+	if inst.ContainerId == nil || *inst.ContainerId == "" {
+		return res, errors.New("容器ID为空")
+	}
+	if state := safeString(inst.ProvisionState); state != "" && state != provisionRunning {
+		return res, fmt.Errorf("实例当前状态(%s)不允许迁移", state)
+	}
+	if srcNode.ID == req.TargetNodeId {
+		return res, errors.New("目标节点与当前节点相同")
+	}
+	var dstNode computenode.ComputeNode
+	if err = global.GVA_DB.Where("id = ?", req.TargetNodeId).First(&dstNode).Error; err != nil {
+		return res, fmt.Errorf("获取目标节点信息失败: %v", err)
+	}
+	var image imageregistry.ImageRegistry
+	var spec product.ProductSpec
+	if inst.ImageId == nil || inst.SpecId == nil {
+		return res, errors.New("实例缺少镜像或规格信息")
+	}
+	if err = global.GVA_DB.Unscoped().Where("id = ?", *inst.ImageId).First(&image).Error; err != nil {
+		return res, fmt.Errorf("获取镜像信息失败: %v", err)
+	}
+	if err = global.GVA_DB.Unscoped().Where("id = ?", *inst.SpecId).First(&spec).Error; err != nil {
+		return res, fmt.Errorf("获取产品规格信息失败: %v", err)
+	}
+	if err = checkSnapshotNode(&image, dstNode.ID); err != nil {
+		return res, err
+	}
+
+	// 目标节点按 GetAvailableNodes 的核算方式需能容纳实例规格
+	meta := SchedulingRequestMeta{Source: ScheduleSourceMigrate, InstanceId: inst.ID}
+	if inst.ImageId != nil {
+		meta.ImageId = uint(*inst.ImageId)
+	}
+	nodes, err := instanceService.GetAvailableNodes(ctx, strconv.FormatInt(*inst.SpecId, 10), meta)
+	if err != nil {
+		return res, fmt.Errorf("校验目标节点容量失败: %v", err)
+	}
+	fits := false
+	for _, n := range nodes {
+		if n.ID == dstNode.ID {
+			fits = true
+			break
+		}
+	}
+	if !fits {
+		return res, errors.New("目标节点不可用或剩余资源不足")
+	}
+
+	// 迁移前的状态，结束后恢复
+	var prevState interface{}
+	if inst.ProvisionState != nil {
+		prevState = *inst.ProvisionState
+	}
+	// 标记迁移中，防止并发操作、状态巡检覆盖
+	lock := global.GVA_DB.Model(&instanceModel.Instance{}).
+		Where("id = ? AND (provision_state IS NULL OR provision_state = '' OR provision_state = ?)", inst.ID, provisionRunning).
+		Update("provision_state", provisionMigrating)
+	if lock.Error != nil {
+		return res, fmt.Errorf("更新实例状态失败: %v", lock.Error)
+	}
+	if lock.RowsAffected == 0 {
+		return res, errors.New("实例正在执行其他操作，请稍后重试")
+	}
+	// 结束后恢复迁移前的状态（可能为空），只覆盖本次写入的 migrating
+	defer func() {
+		if uerr := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ? AND provision_state = ?", inst.ID, provisionMigrating).
+			Update("provision_state", prevState).Error; uerr != nil {
+			global.GVA_LOG.Error("恢复实例状态失败", zap.Uint("实例ID", inst.ID), zap.Error(uerr))
+		}
+	}()
+
+	// 迁移包含拉取镜像和数据复制，不随HTTP请求取消
+	timeoutMin := global.GVA_CONFIG.Provision.PullTimeoutMin
+	if timeoutMin <= 0 {
+		timeoutMin = 60
+	}
+	opCtx, cancel := context.WithTimeout(context.Background(), 2*time.Duration(timeoutMin)*time.Minute)
+	defer cancel()
+
+	res = MigrateResult{InstanceId: inst.ID, FromNodeId: srcNode.ID, ToNodeId: dstNode.ID}
+	res.ContainerId, res.DataVolume, err = instanceService.migrateContainer(opCtx, inst, srcNode, &dstNode, &image, &spec)
+	if err != nil {
+		return res, err
+	}
+	res.VolumeCopied = res.DataVolume != ""
+	res.DurationMs = time.Since(startAt).Milliseconds()
+
+	nodeID := int64(dstNode.ID)
+	inst.NodeId = &nodeID
+	inst.ContainerId = &res.ContainerId
+	status := safeString(inst.ContainerStatus)
+	recordUsageEvent(global.GVA_DB, inst, usageEventMigrate, status, status)
+	global.GVA_LOG.Info("实例迁移完成",
+		zap.Uint("实例ID", inst.ID),
+		zap.Uint("源节点", srcNode.ID),
+		zap.Uint("目标节点", dstNode.ID),
+		zap.Int64("耗时ms", res.DurationMs))
+	return res, nil
+}
+
+// releaseMigrateTarget 回滚迁移：删除目标节点上的GPU分配并清空迁移目标节点
+func releaseMigrateTarget(db *gorm.DB, instanceID uint, dstID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("instance_id = ? AND node_id = ?", instanceID, dstID).Delete(&instanceModel.GpuAllocation{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&instanceModel.Instance{}).Where("id = ?", instanceID).Update("migrate_target_node_id", nil).Error
+	})
+}
+
+// migrateContainer 执行迁移的Docker与数据库步骤，返回新容器ID与数据卷名
+func (instanceService *InstanceService) migrateContainer(ctx context.Context, inst *instanceModel.Instance, src, dst *computenode.ComputeNode, image *imageregistry.ImageRegistry, spec *product.ProductSpec) (string, string, error) {
+	oldID := *inst.ContainerId
+	name := safeString(inst.ContainerName)
+	if name == "" {
+		return "", "", errors.New("容器名称为空")
+	}
+	volumeName, err := dockerService.GetDataVolumeName(ctx, src, oldID)
+	if err != nil {
+		return "", "", fmt.Errorf("读取容器数据卷失败: %v", err)
+	}
+	wasRunning := safeString(inst.ContainerStatus) == "running"
+
+	// 目标节点上的数据卷：复制原数据卷，原容器没有数据卷但规格有数据盘时由 CreateContainer 新建
+	dstVolume := volumeName
+	if dstVolume == "" && specInt(spec.DataDiskGb) > 0 {
+		dstVolume = fmt.Sprintf("%s-data", name)
+	}
+	// 目标节点已有同名数据卷时不迁移，避免数据混入他人的卷，回滚时也不会误删
+	if dstVolume != "" {
+		exists, existErr := dockerService.VolumeExists(ctx, dst, dstVolume)
+		if existErr != nil {
+			return "", "", fmt.Errorf("检查目标节点数据卷失败: %v", existErr)
+		}
+		if exists {
+			return "", "", fmt.Errorf("目标节点已存在同名数据卷 %s，请清理后重试", dstVolume)
+		}
+	}
+
+	// 1. 目标节点拉取镜像（同时作为复制数据卷的辅助容器镜像）
+	username, password, token, err := imageregistrySvc.DecryptCredentials(image)
+	if err != nil {
+		return "", "", err
+	}
+	imageRef := safeString(image.Address)
+	if err = dockerService.ImagePull(ctx, dst, imageRef, RegistryAuthFor(imageRef, username, password, token), nil); err != nil {
+		return "", "", fmt.Errorf("目标节点拉取镜像失败: %v", err)
+	}
+
+	// 2. 在目标节点预留资源并分配GPU卡
+	// 源节点的GPU分配保留到切换完成，目标节点的占用通过 migrate_target_node_id 计入核算，
+	// 复制数据期间并发创建的实例不会超卖目标节点，回滚时源节点的卡也不会被占用。
+	var devices []int
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := reserveNodeCapacity(tx, dst, spec, 0); txErr != nil {
+			return txErr
+		}
+		var txErr error
+		if devices, txErr = allocateGpuDevices(tx, inst.ID, dst, spec); txErr != nil {
+			return fmt.Errorf("分配GPU失败: %w", txErr)
+		}
+		if txErr = tx.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Update("migrate_target_node_id", dst.ID).Error; txErr != nil {
+			return fmt.Errorf("记录迁移目标节点失败: %v", txErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	var newID string
+	// 仅删除本次迁移创建的数据卷
+	volumeCreated := false
+	rollback := func(cause error) (string, string, error) {
+		if newID != "" {
+			if rmErr := dockerService.RemoveContainer(ctx, dst, newID); rmErr != nil {
+				global.GVA_LOG.Error("回滚时删除目标容器失败", zap.Uint("实例ID", inst.ID), zap.Error(rmErr))
+			}
+		}
+		if volumeCreated {
+			if rmErr := dockerService.RemoveVolume(ctx, dst, dstVolume); rmErr != nil && !errdefs.IsNotFound(rmErr) {
+				global.GVA_LOG.Error("回滚时删除目标数据卷失败", zap.Uint("实例ID", inst.ID), zap.String("volume", dstVolume), zap.Error(rmErr))
+			}
+		}
+		if wasRunning {
+			if stErr := dockerService.StartContainer(ctx, src, oldID); stErr != nil {
+				global.GVA_LOG.Error("回滚时启动原容器失败", zap.Uint("实例ID", inst.ID), zap.Error(stErr))
+			}
+		}
+		if txErr := releaseMigrateTarget(global.GVA_DB, inst.ID, dst.ID); txErr != nil {
+			global.GVA_LOG.Error("回滚时释放目标节点资源失败", zap.Uint("实例ID", inst.ID), zap.Error(txErr))
+		}
+		return "", "", fmt.Errorf("迁移失败，已恢复原容器: %v", cause)
+	}
+
+	// 3. 停止原容器，保证复制期间数据一致
+	if wasRunning {
+		if err = dockerService.StopContainer(ctx, src, oldID); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// 前面已确认目标节点没有同名卷，此后出现的卷都由本次迁移创建
+	volumeCreated = dstVolume != ""
+
+	// 4. 复制数据卷到目标节点
+	if volumeName != "" {
+		labels := map[string]string{"managed-by": "docker-gpu-manage", "instance": name}
+		if err = dockerService.CopyVolume(ctx, src, dst, volumeName, imageRef, labels); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// 5. 在目标节点按原规格重建容器
+	containerConfig := dockerService.BuildContainerConfig(image, spec, dst, name)
+	containerConfig.GPUDeviceIDs = deviceIDStrings(devices)
+	containerConfig.DataVolumeName = volumeName
+	if newID, err = dockerService.CreateContainer(ctx, dst, containerConfig); err != nil {
+		return rollback(err)
+	}
+	if wasRunning {
+		if err = dockerService.StartContainer(ctx, dst, newID); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// 6. 原子更新实例所在节点与容器，并释放源节点的GPU分配
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := tx.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+			"node_id":                dst.ID,
+			"container_id":           newID,
+			"migrate_target_node_id": nil,
+		}).Error; txErr != nil {
+			return fmt.Errorf("更新实例记录失败: %v", txErr)
+		}
+		if txErr := tx.Where("instance_id = ? AND node_id <> ?", inst.ID, dst.ID).Delete(&instanceModel.GpuAllocation{}).Error; txErr != nil {
+			return fmt.Errorf("释放源节点GPU失败: %v", txErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return rollback(err)
+	}
+
+	// 7. 删除原节点上的容器及数据卷
+	if rmErr := dockerService.DeleteContainer(ctx, src, oldID, name); rmErr != nil {
+		global.GVA_LOG.Warn("删除原节点容器失败，需手动清理", zap.Uint("实例ID", inst.ID), zap.Uint("节点ID", src.ID), zap.Error(rmErr))
+	}
+	return newID, volumeName, nil
+}
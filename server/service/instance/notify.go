@@ -0,0 +1,19 @@
+package instance
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	emailUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/utils"
+	"go.uber.org/zap"
+)
+
+// NotifyUser 向用户邮箱发送通知，未配置邮箱时跳过
+func NotifyUser(userID uint, subject, body string) {
+	var user system.SysUser
+	if err := global.GVA_DB.Select("id, email").Where("id = ?", userID).First(&user).Error; err != nil || user.Email == "" {
+		return
+	}
+	if err := emailUtils.Email(user.Email, subject, body); err != nil {
+		global.GVA_LOG.Warn("发送邮件失败", zap.Uint("userId", userID), zap.String("subject", subject), zap.Error(err))
+	}
+}
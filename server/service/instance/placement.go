@@ -0,0 +1,183 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// provisionScheduling 自动调度步骤，记录在创建事件中
+const provisionScheduling = "scheduling"
+
+// placementAttempt 自动调度的一次落位尝试
+type placementAttempt struct {
+	NodeId uint   `json:"nodeId"`
+	Stage  string `json:"stage"` // reserve: 提交时复核容量失败；creating: 创建容器失败
+	Error  string `json:"error"`
+}
+
+// pickPlacementCandidates 未指定节点时，按 GetAvailableNodes 的打分顺序返回候选节点，
+// 以及本次调度决策记录的追踪ID
+func (instanceService *InstanceService) pickPlacementCandidates(ctx context.Context, inst *instanceModel.Instance, image *imageregistry.ImageRegistry) ([]uint, string, error) {
+	meta := SchedulingRequestMeta{Region: inst.Region, ImageId: image.ID, Source: scheduleSourcePlacement, TraceId: uuid.NewString()}
+	if inst.UserId != nil {
+		meta.UserHash = strconv.FormatInt(*inst.UserId, 10)
+		meta.UserId = uint(*inst.UserId)
+	}
+	nodes, err := instanceService.GetAvailableNodes(ctx, strconv.FormatInt(*inst.SpecId, 10), meta)
+	if err != nil {
+		return nil, meta.TraceId, fmt.Errorf("查询可用节点失败: %v", err)
+	}
+	var labelsByNode map[uint]map[string]string
+	if len(inst.Labels) > 0 {
+		if labelsByNode, err = loadNodeLabels(nodes); err != nil {
+			return nil, meta.TraceId, err
+		}
+	}
+	candidates := make([]uint, 0, len(nodes))
+	for _, n := range nodes {
+		if inst.Region != "" && !strings.EqualFold(n.Region, inst.Region) {
+			continue
+		}
+		if len(inst.Labels) > 0 && selectorMismatch(labelsByNode[n.ID], inst.Labels) != "" {
+			continue
+		}
+		if checkSnapshotNode(image, n.ID) != nil {
+			continue
+		}
+		candidates = append(candidates, n.ID)
+	}
+	if len(candidates) == 0 {
+		return nil, meta.TraceId, errors.New("没有满足规格的可用节点")
+	}
+	return candidates, meta.TraceId, nil
+}
+
+// loadNodeLabels 读取候选节点的有效标签
+func loadNodeLabels(nodes []AvailableNode) (map[uint]map[string]string, error) {
+	ids := make([]uint, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	res := make(map[uint]map[string]string, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	var rows []computenode.ComputeNode
+	if err := global.GVA_DB.Select("id", "region", "gpu_name", "labels").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询节点标签失败: %v", err)
+	}
+	for i := range rows {
+		res[rows[i].ID] = nodeLabels(&rows[i])
+	}
+	return res, nil
+}
+
+// encodeCandidates 备选节点序列化后存入实例记录，进程重启后仍可回退
+func encodeCandidates(ids []uint) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+func decodeCandidates(s *string) []uint {
+	if s == nil || *s == "" {
+		return nil
+	}
+	var ids []uint
+	if err := json.Unmarshal([]byte(*s), &ids); err != nil {
+		global.GVA_LOG.Warn("解析备选节点失败", zap.String("candidates", *s), zap.Error(err))
+		return nil
+	}
+	return ids
+}
+
+// placeOnNextCandidate 自动调度的实例在当前节点创建容器失败时，改到下一个备选节点重新创建
+// 成功切换节点后重新入队并返回 true；没有可用的备选节点时返回 false，由调用方标记失败。
+func placeOnNextCandidate(inst *instanceModel.Instance, spec *product.ProductSpec, cause error) bool {
+	if errors.Is(cause, errInstanceGone) || errors.Is(cause, context.Canceled) || inst.NodeId == nil {
+		return false
+	}
+	remaining := decodeCandidates(inst.PlacementCandidates)
+	if len(remaining) == 0 {
+		return false
+	}
+	attempts := []placementAttempt{{NodeId: uint(*inst.NodeId), Stage: provisionCreating, Error: cause.Error()}}
+	// 备选节点在调度后可能被打上污点（如排空），重新校验调度约束
+	var image *imageregistry.ImageRegistry
+	if inst.ImageId != nil {
+		image = loadAffinityImage(uint(*inst.ImageId))
+	}
+	for len(remaining) > 0 {
+		next := remaining[0]
+		remaining = remaining[1:]
+		err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+			var node computenode.ComputeNode
+			if err := tx.Where("id = ?", next).First(&node).Error; err != nil {
+				return fmt.Errorf("获取算力节点信息失败: %v", err)
+			}
+			if reason := affinityMismatch(&node, spec, image, false); reason != "" {
+				return fmt.Errorf("%w: %s", errNodeUnavailable, reason)
+			}
+			if err := reserveNodeCapacity(tx, &node, spec, 0); err != nil {
+				return err
+			}
+			if _, _, err := reallocateGpuDevices(tx, inst.ID, &node, spec); err != nil {
+				return err
+			}
+			return tx.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+				"node_id":              next,
+				"placement_candidates": encodeCandidates(remaining),
+				"provision_state":      provisionPending,
+			}).Error
+		})
+		if err != nil {
+			attempts = append(attempts, placementAttempt{NodeId: next, Stage: "reserve", Error: err.Error()})
+			continue
+		}
+		recordProvisionEvent(inst.ID, provisionScheduling, eventRetrying, len(attempts),
+			fmt.Sprintf("节点%d创建容器失败，改到节点%d重新创建: %v", *inst.NodeId, next, cause), 0)
+		writePlacementTraceLog("", inst.ID, spec.ID, attempts, next, nil)
+		instanceProvisioner.enqueue(inst.ID)
+		return true
+	}
+	global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Update("placement_candidates", "")
+	writePlacementTraceLog("", inst.ID, spec.ID, attempts, 0, cause)
+	return false
+}
+
+// writePlacementTraceLog 记录自动调度的落位尝试，与 writeSchedulingTraceLog 一起构成调度轨迹
+func writePlacementTraceLog(traceID string, instanceID uint, specID uint, attempts []placementAttempt, chosen uint, err error) {
+	savePlacementLog(traceID, instanceID, specID, attempts, chosen, err)
+	if err != nil {
+		global.GVA_LOG.Warn("自动调度日志",
+			zap.String("traceId", traceID),
+			zap.Uint("instanceId", instanceID),
+			zap.Uint("specId", specID),
+			zap.String("attempts", fmt.Sprintf("%+v", attempts)),
+			zap.String("error", err.Error()),
+		)
+		return
+	}
+	global.GVA_LOG.Info("自动调度日志",
+		zap.String("traceId", traceID),
+		zap.Uint("instanceId", instanceID),
+		zap.Uint("specId", specID),
+		zap.Uint("chosenNodeId", chosen),
+		zap.String("attempts", fmt.Sprintf("%+v", attempts)),
+	)
+}
@@ -0,0 +1,271 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+// metricsPrefix Prometheus 指标名前缀
+const metricsPrefix = "gpu_manage_"
+
+// promLabel 指标标签，按添加顺序输出
+type promLabel struct {
+	name  string
+	value string
+}
+
+// promWriter 生成 Prometheus 文本格式（0.0.4）
+type promWriter struct {
+	buf bytes.Buffer
+}
+
+// family 输出指标的 HELP 与 TYPE，同名样本需紧随其后
+func (w *promWriter) family(name, typ, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, typ)
+}
+
+func (w *promWriter) sample(name string, labels []promLabel, value float64) {
+	w.buf.WriteString(metricsPrefix + name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// nodeMetricLabels 节点维度的标签
+func nodeMetricLabels(node *computenode.ComputeNode) []promLabel {
+	return []promLabel{
+		{"node_id", strconv.FormatUint(uint64(node.ID), 10)},
+		{"node", safeString(node.Name)},
+		{"region", safeString(node.Region)},
+	}
+}
+
+// FleetMetrics 生成整个平台的 Prometheus 指标：节点 Docker 状态与 GPU 分配、实例资源使用率、调度熔断状态
+// GPU 分配与 GetAvailableNodes 共用 resourceAllocator 的核算，实例指标取巡检任务最近一次写入的值。
+func (instanceService *InstanceService) FleetMetrics(ctx context.Context) ([]byte, error) {
+	var nodes []computenode.ComputeNode
+	if err := global.GVA_DB.Order("id").Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("查询算力节点失败: %v", err)
+	}
+	allocator, err := newResourceAllocator(global.GVA_DB, nodes, 0)
+	if err != nil {
+		return nil, fmt.Errorf("核算节点资源失败: %v", err)
+	}
+	var instances []instanceModel.Instance
+	if err = global.GVA_DB.Where("node_id IS NOT NULL AND container_id IS NOT NULL AND container_id <> ''").
+		Order("id").Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("查询实例失败: %v", err)
+	}
+
+	nodeByID := make(map[int64]*computenode.ComputeNode, len(nodes))
+	for i := range nodes {
+		nodeByID[int64(nodes[i].ID)] = &nodes[i]
+	}
+	specNames, imageNames, userNames := loadMetricLabelNames(instances)
+
+	w := &promWriter{}
+
+	w.family("node_docker_up", "gauge", "节点 Docker 连接状态，1 为连接正常")
+	for i := range nodes {
+		w.sample("node_docker_up", nodeMetricLabels(&nodes[i]), boolGauge(safeString(nodes[i].DockerStatus) == "connected"))
+	}
+	w.family("node_schedulable", "gauge", "节点是否接受新实例（已上架且不在维护状态）")
+	for i := range nodes {
+		n := &nodes[i]
+		w.sample("node_schedulable", nodeMetricLabels(n), boolGauge(n.IsOnShelf != nil && *n.IsOnShelf && n.MaintenanceState == ""))
+	}
+	gpuFamilies := []struct {
+		name, help string
+		value      func(n *computenode.ComputeNode, used nodeUsage) int64
+	}{
+		{"node_gpu_total", "节点GPU卡总数", func(n *computenode.ComputeNode, _ nodeUsage) int64 { return safeInt64(n.GpuCount) }},
+		{"node_gpu_allocated", "节点已分配给实例的GPU卡数", func(_ *computenode.ComputeNode, used nodeUsage) int64 { return used.GpuUsed }},
+		{"node_gpu_memory_total_gb", "节点GPU显存总量(GB)", func(n *computenode.ComputeNode, _ nodeUsage) int64 {
+			return safeInt64(n.MemoryCapacity) * safeInt64(n.GpuCount)
+		}},
+		{"node_gpu_memory_allocated_gb", "节点已分配给实例的GPU显存(GB)", func(_ *computenode.ComputeNode, used nodeUsage) int64 { return used.MemoryCapacityUsed }},
+	}
+	for _, f := range gpuFamilies {
+		w.family(f.name, "gauge", f.help)
+		for i := range nodes {
+			n := &nodes[i]
+			w.sample(f.name, nodeMetricLabels(n), float64(f.value(n, allocator.usedResources[int64(n.ID)])))
+		}
+	}
+
+	instanceLabels := func(inst *instanceModel.Instance) []promLabel {
+		labels := []promLabel{
+			{"instance_id", strconv.FormatUint(uint64(inst.ID), 10)},
+			{"instance_name", safeString(inst.Name)},
+		}
+		if n := nodeByID[*inst.NodeId]; n != nil {
+			labels = append(labels, promLabel{"node", safeString(n.Name)}, promLabel{"region", safeString(n.Region)})
+		} else {
+			labels = append(labels, promLabel{"node", ""}, promLabel{"region", ""})
+		}
+		return append(labels,
+			promLabel{"spec", specNames[safeInt64(inst.SpecId)]},
+			promLabel{"user", userNames[safeInt64(inst.UserId)]},
+			promLabel{"image", imageNames[safeInt64(inst.ImageId)]},
+		)
+	}
+	instanceFamilies := []struct {
+		name, help string
+		value      func(inst *instanceModel.Instance) *float64
+	}{
+		{"instance_cpu_usage_percent", "实例CPU使用率(%)", func(inst *instanceModel.Instance) *float64 { return inst.CpuUsagePercent }},
+		{"instance_memory_usage_percent", "实例内存使用率(%)", func(inst *instanceModel.Instance) *float64 { return inst.MemoryUsagePercent }},
+		{"instance_gpu_memory_usage_percent", "实例GPU显存使用率(%)", func(inst *instanceModel.Instance) *float64 { return inst.GpuMemoryUsageRate }},
+	}
+	w.family("instance_running", "gauge", "实例容器是否运行中")
+	for i := range instances {
+		w.sample("instance_running", instanceLabels(&instances[i]), boolGauge(safeString(instances[i].ContainerStatus) == "running"))
+	}
+	for _, f := range instanceFamilies {
+		w.family(f.name, "gauge", f.help)
+		for i := range instances {
+			if v := f.value(&instances[i]); v != nil {
+				w.sample(f.name, instanceLabels(&instances[i]), *v)
+			}
+		}
+	}
+
+	var cards []computenode.NodeGpuTelemetry
+	if err = global.GVA_DB.Order("node_id, gpu_index").Find(&cards).Error; err != nil {
+		return nil, fmt.Errorf("查询显卡遥测失败: %v", err)
+	}
+	cardLabels := func(c *computenode.NodeGpuTelemetry) []promLabel {
+		var labels []promLabel
+		if n := nodeByID[int64(c.NodeId)]; n != nil {
+			labels = nodeMetricLabels(n)
+		} else {
+			labels = []promLabel{{"node_id", strconv.FormatUint(uint64(c.NodeId), 10)}, {"node", ""}, {"region", ""}}
+		}
+		return append(labels, promLabel{"gpu", strconv.Itoa(c.GpuIndex)}, promLabel{"uuid", c.UUID})
+	}
+	gpuCardFamilies := []struct {
+		name, typ, help string
+		value           func(m *computenode.GpuTelemetryMetrics) *float64
+	}{
+		{"gpu_sm_utilization_percent", "gauge", "显卡SM利用率(%)", func(m *computenode.GpuTelemetryMetrics) *float64 { return m.SmUtil }},
+		{"gpu_memory_used_mib", "gauge", "显卡已用显存(MiB)", func(m *computenode.GpuTelemetryMetrics) *float64 { return int64Gauge(m.MemoryUsedMb) }},
+		{"gpu_temperature_celsius", "gauge", "显卡温度(℃)", func(m *computenode.GpuTelemetryMetrics) *float64 { return m.TemperatureC }},
+		{"gpu_power_draw_watts", "gauge", "显卡功耗(W)", func(m *computenode.GpuTelemetryMetrics) *float64 { return m.PowerDrawW }},
+		{"gpu_ecc_uncorrected_errors", "gauge", "驱动加载以来的不可纠正ECC错误数", func(m *computenode.GpuTelemetryMetrics) *float64 { return int64Gauge(m.EccUncorrected) }},
+	}
+	for _, f := range gpuCardFamilies {
+		w.family(f.name, f.typ, f.help)
+		for i := range cards {
+			if v := f.value(&cards[i].GpuTelemetryMetrics); v != nil {
+				w.sample(f.name, cardLabels(&cards[i]), *v)
+			}
+		}
+	}
+
+	st := pcdnSchedulerRuntime.status(time.Now())
+	w.family("scheduler_breaker_state", "gauge", "调度熔断状态，当前状态为 1")
+	for _, s := range []string{breakerClosed, breakerOpen, breakerHalfOpen} {
+		w.sample("scheduler_breaker_state", []promLabel{{"state", s}}, boolGauge(st.State == s))
+	}
+	w.family("scheduler_breaker_window_requests", "gauge", "熔断滑动窗口内的调度请求数")
+	w.sample("scheduler_breaker_window_requests", nil, float64(st.WindowRequests))
+	w.family("scheduler_breaker_failure_rate", "gauge", "熔断滑动窗口内的调度失败率")
+	w.sample("scheduler_breaker_failure_rate", nil, st.FailureRate)
+	w.family("scheduler_breaker_avg_latency_ms", "gauge", "熔断滑动窗口内的平均调度延迟(毫秒)")
+	w.sample("scheduler_breaker_avg_latency_ms", nil, st.AvgLatencyMs)
+	w.family("scheduler_breaker_trips_total", "counter", "进程启动以来的熔断次数")
+	w.sample("scheduler_breaker_trips_total", nil, float64(st.TripCount))
+
+	return w.buf.Bytes(), nil
+}
+
+// loadMetricLabelNames 读取实例标签所需的规格、镜像与用户名称（含已删除的记录）
+func loadMetricLabelNames(instances []instanceModel.Instance) (specs, images, users map[int64]string) {
+	specs, images, users = make(map[int64]string), make(map[int64]string), make(map[int64]string)
+	var specIDs, imageIDs, userIDs []int64
+	for _, inst := range instances {
+		specIDs = append(specIDs, safeInt64(inst.SpecId))
+		imageIDs = append(imageIDs, safeInt64(inst.ImageId))
+		userIDs = append(userIDs, safeInt64(inst.UserId))
+	}
+	if len(instances) == 0 {
+		return
+	}
+	var specRows []product.ProductSpec
+	global.GVA_DB.Unscoped().Select("id", "name").Where("id IN ?", dedupeInt64(specIDs)).Find(&specRows)
+	for _, s := range specRows {
+		specs[int64(s.ID)] = safeString(s.Name)
+	}
+	var imageRows []imageregistry.ImageRegistry
+	global.GVA_DB.Unscoped().Select("id", "name").Where("id IN ?", dedupeInt64(imageIDs)).Find(&imageRows)
+	for _, im := range imageRows {
+		images[int64(im.ID)] = safeString(im.Name)
+	}
+	var userRows []system.SysUser
+	global.GVA_DB.Unscoped().Select("id", "username").Where("id IN ?", dedupeInt64(userIDs)).Find(&userRows)
+	for _, u := range userRows {
+		users[int64(u.ID)] = u.Username
+	}
+	return
+}
+
+func dedupeInt64(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	res := ids[:0]
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+func int64Gauge(p *int64) *float64 {
+	if p == nil {
+		return nil
+	}
+	v := float64(*p)
+	return &v
+}
+
+func safeInt64(p *int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
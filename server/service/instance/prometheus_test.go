@@ -0,0 +1,18 @@
+package instance
+
+import "testing"
+
+func TestPromWriter(t *testing.T) {
+	w := &promWriter{}
+	w.family("node_docker_up", "gauge", "节点 Docker 连接状态")
+	w.sample("node_docker_up", []promLabel{{"node", `gpu-"01"`}, {"region", "bj\\a\nb"}}, 1)
+	w.sample("scheduler_breaker_failure_rate", nil, 0.25)
+
+	want := "# HELP gpu_manage_node_docker_up 节点 Docker 连接状态\n" +
+		"# TYPE gpu_manage_node_docker_up gauge\n" +
+		`gpu_manage_node_docker_up{node="gpu-\"01\"",region="bj\\a\nb"} 1` + "\n" +
+		"gpu_manage_scheduler_breaker_failure_rate 0.25\n"
+	if got := w.buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
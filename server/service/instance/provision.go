@@ -0,0 +1,419 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	imageregistrySvc "github.com/flipped-aurora/gin-vue-admin/server/service/imageregistry"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 实例创建状态机
+const (
+	provisionPending  = "pending"
+	provisionPulling  = "pulling"
+	provisionCreating = "creating"
+	provisionStarting = "starting"
+	provisionRunning  = "running"
+	provisionFailed   = "failed"
+)
+
+// ProvisionInProgressStates 创建、调度、迁移或变更规格尚未结束的状态，此时实例不能直接停止
+func ProvisionInProgressStates() []string {
+	return []string{provisionScheduling, provisionPending, provisionPulling, provisionCreating, provisionStarting, provisionMigrating, provisionResizing}
+}
+
+// 步骤事件结果
+const (
+	eventStarted   = "started"
+	eventSucceeded = "succeeded"
+	eventRetrying  = "retrying"
+	eventFailed    = "failed"
+)
+
+// ProvisionProgress 实例创建进度
+type ProvisionProgress struct {
+	InstanceId      uint                           `json:"instanceId"`
+	ProvisionState  string                         `json:"provisionState"`
+	ProvisionError  string                         `json:"provisionError"`
+	ContainerStatus string                         `json:"containerStatus"`
+	PullProgress    float64                        `json:"pullProgress"`
+	PullDetail      string                         `json:"pullDetail"`
+	Finished        bool                           `json:"finished"`
+	Events          []instanceModel.ProvisionEvent `json:"events"`
+}
+
+// errInstanceGone 创建过程中实例已被删除
+var errInstanceGone = errors.New("实例已被删除")
+
+type provisionWorker struct {
+	once  sync.Once
+	queue chan uint
+}
+
+var instanceProvisioner = &provisionWorker{queue: make(chan uint, 1024)}
+
+// StartProvisionWorker 启动实例后台创建worker，并恢复进程重启前未完成的创建任务
+func StartProvisionWorker() {
+	instanceProvisioner.once.Do(func() {
+		workers := global.GVA_CONFIG.Provision.Workers
+		if workers <= 0 {
+			workers = 4
+		}
+		for i := 0; i < workers; i++ {
+			go instanceProvisioner.loop()
+		}
+
+		var ids []uint
+		if err := global.GVA_DB.Model(&instanceModel.Instance{}).
+			Where("provision_state IN ?", []string{provisionPending, provisionPulling, provisionCreating, provisionStarting}).
+			Pluck("id", &ids).Error; err != nil {
+			global.GVA_LOG.Error("查询未完成的实例创建任务失败", zap.Error(err))
+			return
+		}
+		for _, id := range ids {
+			instanceProvisioner.enqueue(id)
+		}
+		if len(ids) > 0 {
+			global.GVA_LOG.Info("恢复未完成的实例创建任务", zap.Int("count", len(ids)))
+		}
+	})
+}
+
+func (w *provisionWorker) enqueue(instanceID uint) {
+	// 队列满时不阻塞HTTP请求，转由独立goroutine等待入队
+	select {
+	case w.queue <- instanceID:
+	default:
+		go func() { w.queue <- instanceID }()
+	}
+}
+
+func (w *provisionWorker) loop() {
+	for id := range w.queue {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					global.GVA_LOG.Error("实例创建任务异常退出", zap.Uint("实例ID", id), zap.Any("panic", r))
+					markProvisionFailed(id, fmt.Sprintf("内部错误: %v", r))
+				}
+			}()
+			runProvision(context.Background(), id)
+		}()
+	}
+}
+
+// runProvision 按状态机依次执行 拉取镜像 → 创建容器 → 启动容器
+func runProvision(ctx context.Context, instanceID uint) {
+	var inst instanceModel.Instance
+	if err := global.GVA_DB.Where("id = ?", instanceID).First(&inst).Error; err != nil {
+		global.GVA_LOG.Warn("实例不存在，跳过创建", zap.Uint("实例ID", instanceID), zap.Error(err))
+		return
+	}
+	var image imageregistry.ImageRegistry
+	var spec product.ProductSpec
+	var node computenode.ComputeNode
+	if err := loadProvisionDeps(&inst, &image, &spec, &node); err != nil {
+		failProvision(ctx, &inst, &node, err)
+		return
+	}
+
+	containerName := ""
+	if inst.ContainerName != nil && *inst.ContainerName != "" {
+		containerName = *inst.ContainerName
+	} else {
+		baseName := fmt.Sprintf("instance-%d", inst.ID)
+		if inst.Name != nil && *inst.Name != "" {
+			baseName = *inst.Name
+		}
+		containerName = dockerService.GenerateInstanceName(baseName, inst.ID)
+	}
+
+	// 1. 拉取镜像（使用镜像库凭据，进度写回实例）
+	username, password, token, err := imageregistrySvc.DecryptCredentials(&image)
+	if err != nil {
+		failProvision(ctx, &inst, &node, err)
+		return
+	}
+	imageRef := safeString(image.Address)
+	auth := RegistryAuthFor(imageRef, username, password, token)
+	err = runProvisionStep(ctx, &inst, provisionPulling, func(stepCtx context.Context) error {
+		// 节点上已有该镜像（如未推送的快照）时 ImagePull 直接返回
+		return dockerService.ImagePull(stepCtx, &node, imageRef, auth, newPullProgressWriter(inst.ID))
+	})
+	if err != nil {
+		failProvision(ctx, &inst, &node, err)
+		return
+	}
+
+	// 2. 创建容器（进程重启后恢复的任务如已有容器则复用）
+	if inst.ContainerId == nil || *inst.ContainerId == "" {
+		err = runProvisionStep(ctx, &inst, provisionCreating, func(stepCtx context.Context) error {
+			devices, loadErr := instanceGpuDevices(global.GVA_DB, inst.ID)
+			if loadErr != nil {
+				return loadErr
+			}
+			containerConfig := dockerService.BuildContainerConfig(&image, &spec, &node, containerName)
+			containerConfig.GPUDeviceIDs = deviceIDStrings(devices)
+			containerID, createErr := dockerService.CreateContainer(stepCtx, &node, containerConfig)
+			if createErr != nil {
+				return createErr
+			}
+			inst.ContainerId = &containerID
+			inst.ContainerName = &containerName
+			return global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+				"container_id":   containerID,
+				"container_name": containerName,
+			}).Error
+		})
+		if err != nil {
+			// 自动调度的实例改到下一个备选节点重新创建
+			if placeOnNextCandidate(&inst, &spec, err) {
+				return
+			}
+			failProvision(ctx, &inst, &node, err)
+			return
+		}
+	}
+
+	// 3. 启动容器
+	err = runProvisionStep(ctx, &inst, provisionStarting, func(stepCtx context.Context) error {
+		return dockerService.StartContainer(stepCtx, &node, *inst.ContainerId)
+	})
+	if err != nil {
+		failProvision(ctx, &inst, &node, err)
+		return
+	}
+
+	// 4. 完成
+	if err = global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+		"provision_state":  provisionRunning,
+		"provision_error":  "",
+		"container_status": "running",
+	}).Error; err != nil {
+		global.GVA_LOG.Error("更新实例创建状态失败", zap.Uint("实例ID", inst.ID), zap.Error(err))
+	}
+	recordProvisionEvent(inst.ID, provisionRunning, eventSucceeded, 1, "实例创建完成", 0)
+	recordUsageEvent(global.GVA_DB, &inst, usageEventProvisioned, "creating", usageStatusRunning)
+}
+
+// newPullProgressWriter 返回将拉取进度节流写回实例记录的回调
+func newPullProgressWriter(instanceID uint) func(ImagePullProgress) {
+	var lastWrite time.Time
+	lastPercent := -1.0
+	return func(p ImagePullProgress) {
+		if p.Percent == lastPercent || (p.Percent < 100 && time.Since(lastWrite) < 2*time.Second) {
+			return
+		}
+		lastWrite = time.Now()
+		lastPercent = p.Percent
+		detail := fmt.Sprintf("%d/%d层, %.1f/%.1fMB, %s", p.LayersDone, p.LayersTotal,
+			float64(p.CurrentBytes)/1024/1024, float64(p.TotalBytes)/1024/1024, p.Status)
+		if len(detail) > 500 {
+			detail = detail[:500]
+		}
+		if err := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", instanceID).Updates(map[string]interface{}{
+			"pull_progress": p.Percent,
+			"pull_detail":   detail,
+		}).Error; err != nil {
+			global.GVA_LOG.Warn("写入镜像拉取进度失败", zap.Uint("实例ID", instanceID), zap.Error(err))
+		}
+	}
+}
+
+// runProvisionStep 执行单个步骤，临时性Docker错误按配置有限次重试
+func runProvisionStep(ctx context.Context, inst *instanceModel.Instance, step string, fn func(context.Context) error) error {
+	if !instanceExists(inst.ID) {
+		return errInstanceGone
+	}
+	if err := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+		"provision_state":  step,
+		"container_status": "creating",
+	}).Error; err != nil {
+		return fmt.Errorf("更新实例创建状态失败: %v", err)
+	}
+
+	cfg := global.GVA_CONFIG.Provision
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	} else if maxRetries == 0 {
+		maxRetries = 3
+	}
+	interval := time.Duration(cfg.RetryIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	timeout := 2 * time.Minute
+	if step == provisionPulling {
+		timeout = time.Duration(cfg.PullTimeoutMin) * time.Minute
+		if timeout <= 0 {
+			timeout = 60 * time.Minute
+		}
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		recordProvisionEvent(inst.ID, step, eventStarted, attempt, "", 0)
+		startAt := time.Now()
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(stepCtx)
+		cancel()
+		cost := time.Since(startAt).Milliseconds()
+		if err == nil {
+			recordProvisionEvent(inst.ID, step, eventSucceeded, attempt, "", cost)
+			return nil
+		}
+		if !isTransientDockerError(err) || attempt > maxRetries {
+			recordProvisionEvent(inst.ID, step, eventFailed, attempt, err.Error(), cost)
+			return err
+		}
+		recordProvisionEvent(inst.ID, step, eventRetrying, attempt, err.Error(), cost)
+		global.GVA_LOG.Warn("实例创建步骤失败，准备重试",
+			zap.Uint("实例ID", inst.ID), zap.String("步骤", step), zap.Int("尝试次数", attempt), zap.Error(err))
+		time.Sleep(interval * time.Duration(attempt))
+		if !instanceExists(inst.ID) {
+			return errInstanceGone
+		}
+	}
+	return err
+}
+
+// failProvision 创建失败：清理已创建的容器及其数据卷、释放GPU并标记失败
+func failProvision(ctx context.Context, inst *instanceModel.Instance, node *computenode.ComputeNode, cause error) {
+	if inst.ContainerId != nil && *inst.ContainerId != "" && node.ID != 0 {
+		cleanupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := dockerService.DeleteContainer(cleanupCtx, node, *inst.ContainerId, safeString(inst.ContainerName)); err != nil {
+			global.GVA_LOG.Warn("清理创建失败的容器失败", zap.Uint("实例ID", inst.ID), zap.Error(err))
+		}
+		cancel()
+	}
+	if errors.Is(cause, errInstanceGone) {
+		global.GVA_LOG.Info("实例创建过程中已被删除，终止创建", zap.Uint("实例ID", inst.ID))
+		return
+	}
+	global.GVA_LOG.Error("实例创建失败", zap.Uint("实例ID", inst.ID), zap.Error(cause))
+	markProvisionFailed(inst.ID, cause.Error())
+}
+
+func markProvisionFailed(instanceID uint, message string) {
+	if err := releaseGpuDevices(global.GVA_DB, instanceID); err != nil {
+		global.GVA_LOG.Warn("释放GPU分配失败", zap.Uint("实例ID", instanceID), zap.Error(err))
+	}
+	if err := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", instanceID).Updates(map[string]interface{}{
+		"provision_state":  provisionFailed,
+		"provision_error":  message,
+		"container_status": "failed",
+		"container_id":     "",
+	}).Error; err != nil {
+		global.GVA_LOG.Error("更新实例创建状态失败", zap.Uint("实例ID", instanceID), zap.Error(err))
+	}
+	recordProvisionEvent(instanceID, provisionFailed, eventFailed, 1, message, 0)
+}
+
+func loadProvisionDeps(inst *instanceModel.Instance, image *imageregistry.ImageRegistry, spec *product.ProductSpec, node *computenode.ComputeNode) error {
+	if inst.ImageId == nil || inst.SpecId == nil || inst.NodeId == nil {
+		return fmt.Errorf("实例缺少镜像、规格或节点信息")
+	}
+	if err := global.GVA_DB.Where("id = ?", *inst.ImageId).First(image).Error; err != nil {
+		return fmt.Errorf("获取镜像信息失败: %v", err)
+	}
+	if err := global.GVA_DB.Where("id = ?", *inst.SpecId).First(spec).Error; err != nil {
+		return fmt.Errorf("获取产品规格信息失败: %v", err)
+	}
+	if err := global.GVA_DB.Where("id = ?", *inst.NodeId).First(node).Error; err != nil {
+		return fmt.Errorf("获取算力节点信息失败: %v", err)
+	}
+	return nil
+}
+
+func recordProvisionEvent(instanceID uint, step, status string, attempt int, message string, durationMs int64) {
+	event := instanceModel.ProvisionEvent{
+		InstanceId: instanceID,
+		Step:       step,
+		Status:     status,
+		Attempt:    attempt,
+		Message:    message,
+		DurationMs: durationMs,
+	}
+	if err := global.GVA_DB.Create(&event).Error; err != nil {
+		global.GVA_LOG.Warn("写入实例创建事件失败", zap.Uint("实例ID", instanceID), zap.Error(err))
+	}
+}
+
+func instanceExists(instanceID uint) bool {
+	var count int64
+	global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", instanceID).Count(&count)
+	return count > 0
+}
+
+// isTransientDockerError 判断是否为可重试的临时性Docker错误（连接失败、超时、守护进程不可用等）
+func isTransientDockerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errdefs.IsNotFound(err) || errdefs.IsInvalidParameter(err) || errdefs.IsConflict(err) ||
+		errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return false
+	}
+	if client.IsErrConnectionFailed(err) || errdefs.IsUnavailable(err) || errdefs.IsSystem(err) ||
+		errdefs.IsDeadline(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// instanceGpuDevices 读取实例已分配的GPU设备索引
+func instanceGpuDevices(db *gorm.DB, instanceID uint) ([]int, error) {
+	var devices []int
+	err := db.Model(&instanceModel.GpuAllocation{}).Where("instance_id = ?", instanceID).
+		Order("device_index").Pluck("device_index", &devices).Error
+	return devices, err
+}
+
+func safeString(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// GetProvisionProgress 查询实例创建进度
+func (instanceService *InstanceService) GetProvisionProgress(ctx context.Context, ID string, userID uint, isAdmin bool) (progress ProvisionProgress, err error) {
+	var inst instanceModel.Instance
+	if err = global.GVA_DB.Where("id = ?", ID).First(&inst).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return progress, fmt.Errorf("实例不存在或已被删除")
+		}
+		return progress, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return progress, fmt.Errorf("无权查看此实例")
+	}
+
+	progress.InstanceId = inst.ID
+	progress.ProvisionState = safeString(inst.ProvisionState)
+	progress.ProvisionError = safeString(inst.ProvisionError)
+	progress.ContainerStatus = safeString(inst.ContainerStatus)
+	progress.PullDetail = safeString(inst.PullDetail)
+	if inst.PullProgress != nil {
+		progress.PullProgress = *inst.PullProgress
+	}
+	// 历史实例没有创建状态，视为已完成
+	progress.Finished = progress.ProvisionState == "" || progress.ProvisionState == provisionRunning || progress.ProvisionState == provisionFailed
+	err = global.GVA_DB.Where("instance_id = ?", inst.ID).Order("id").Find(&progress.Events).Error
+	return progress, err
+}
@@ -0,0 +1,155 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/quota"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaUsage 按配额维度统计的资源量
+type QuotaUsage struct {
+	Instances   int64 `json:"instances"`
+	Gpus        int64 `json:"gpus"`
+	GpuMemoryGb int64 `json:"gpuMemoryGb"`
+	CpuCores    int64 `json:"cpuCores"`
+	MemoryGb    int64 `json:"memoryGb"`
+	DataDiskGb  int64 `json:"dataDiskGb"`
+}
+
+// QuotaOverview 用户配额与当前用量
+type QuotaOverview struct {
+	UserId uint                `json:"userId"`
+	Quota  quota.ResourceQuota `json:"quota"` // 合并用户级与角色级后的生效配额
+	Usage  QuotaUsage          `json:"usage"`
+}
+
+// specQuotaUsage 单个实例按规格占用的资源
+func specQuotaUsage(spec *product.ProductSpec) QuotaUsage {
+	v := func(p *int64) int64 {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	return QuotaUsage{
+		Instances:   1,
+		Gpus:        v(spec.GpuCount),
+		GpuMemoryGb: v(spec.MemoryCapacity),
+		CpuCores:    v(spec.CpuCores),
+		MemoryGb:    v(spec.MemoryGb),
+		DataDiskGb:  v(spec.DataDiskGb),
+	}
+}
+
+// effectiveQuota 合并用户级与角色级配额，用户级非零项优先
+func effectiveQuota(db *gorm.DB, userID uint) (quota.ResourceQuota, error) {
+	var user system.SysUser
+	if err := db.Select("id, authority_id").Where("id = ?", userID).First(&user).Error; err != nil {
+		return quota.ResourceQuota{}, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	var rows []quota.ResourceQuota
+	if err := db.Where("(subject_type = ? AND subject_id = ?) OR (subject_type = ? AND subject_id = ?)",
+		quota.SubjectUser, userID, quota.SubjectAuthority, user.AuthorityId).Find(&rows).Error; err != nil {
+		return quota.ResourceQuota{}, err
+	}
+	var userQuota, authorityQuota quota.ResourceQuota
+	for _, r := range rows {
+		if r.SubjectType == quota.SubjectUser {
+			userQuota = r
+		} else {
+			authorityQuota = r
+		}
+	}
+	pick := func(u, a int64) int64 {
+		if u > 0 {
+			return u
+		}
+		return a
+	}
+	return quota.ResourceQuota{
+		SubjectType:    quota.SubjectUser,
+		SubjectId:      userID,
+		MaxInstances:   pick(userQuota.MaxInstances, authorityQuota.MaxInstances),
+		MaxGpus:        pick(userQuota.MaxGpus, authorityQuota.MaxGpus),
+		MaxGpuMemoryGb: pick(userQuota.MaxGpuMemoryGb, authorityQuota.MaxGpuMemoryGb),
+		MaxCpuCores:    pick(userQuota.MaxCpuCores, authorityQuota.MaxCpuCores),
+		MaxMemoryGb:    pick(userQuota.MaxMemoryGb, authorityQuota.MaxMemoryGb),
+		MaxDataDiskGb:  pick(userQuota.MaxDataDiskGb, authorityQuota.MaxDataDiskGb),
+	}, nil
+}
+
+// loadQuotaUsage 统计用户名下实例占用的资源（创建失败的实例不计入），excludeID 用于排除正在启动的实例本身
+func loadQuotaUsage(db *gorm.DB, userID uint, excludeID uint) (QuotaUsage, error) {
+	var usage QuotaUsage
+	err := db.Model(&instanceModel.Instance{}).
+		Select("COUNT(*) AS instances, "+
+			"COALESCE(SUM(product_spec.gpu_count), 0) AS gpus, "+
+			"COALESCE(SUM(product_spec.memory_capacity), 0) AS gpu_memory_gb, "+
+			"COALESCE(SUM(product_spec.cpu_cores), 0) AS cpu_cores, "+
+			"COALESCE(SUM(product_spec.memory_gb), 0) AS memory_gb, "+
+			"COALESCE(SUM(product_spec.data_disk_gb), 0) AS data_disk_gb").
+		Joins("LEFT JOIN product_spec ON product_spec.id = instance.spec_id").
+		Where("instance.user_id = ? AND instance.id <> ?", userID, excludeID).
+		Where("(instance.provision_state IS NULL OR instance.provision_state <> ?)", provisionFailed).
+		Scan(&usage).Error
+	return usage, err
+}
+
+// exceededQuota 返回超出上限的维度说明
+func exceededQuota(limit quota.ResourceQuota, used, req QuotaUsage) []string {
+	var out []string
+	check := func(name string, max, u, r int64, unit string) {
+		if max > 0 && u+r > max {
+			out = append(out, fmt.Sprintf("%s已用%d%s/上限%d%s，本次需要%d%s", name, u, unit, max, unit, r, unit))
+		}
+	}
+	check("实例数", limit.MaxInstances, used.Instances, req.Instances, "个")
+	check("GPU", limit.MaxGpus, used.Gpus, req.Gpus, "张")
+	check("显存", limit.MaxGpuMemoryGb, used.GpuMemoryGb, req.GpuMemoryGb, "GB")
+	check("CPU", limit.MaxCpuCores, used.CpuCores, req.CpuCores, "核")
+	check("内存", limit.MaxMemoryGb, used.MemoryGb, req.MemoryGb, "GB")
+	check("数据盘", limit.MaxDataDiskGb, used.DataDiskGb, req.DataDiskGb, "GB")
+	return out
+}
+
+// checkQuota 在事务内校验用户配额；锁定用户行以串行化同一用户的并发创建
+func checkQuota(tx *gorm.DB, userID *int64, spec *product.ProductSpec, excludeID uint) error {
+	if userID == nil {
+		return errors.New("实例未关联用户，无法校验配额")
+	}
+	uid := uint(*userID)
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", uid).First(&system.SysUser{}).Error; err != nil {
+		return fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	limit, err := effectiveQuota(tx, uid)
+	if err != nil {
+		return err
+	}
+	used, err := loadQuotaUsage(tx, uid, excludeID)
+	if err != nil {
+		return fmt.Errorf("统计资源用量失败: %v", err)
+	}
+	if exceeded := exceededQuota(limit, used, specQuotaUsage(spec)); len(exceeded) > 0 {
+		return fmt.Errorf("超出资源配额: %s", strings.Join(exceeded, "；"))
+	}
+	return nil
+}
+
+// GetQuotaUsage 获取用户的生效配额与当前用量
+func (instanceService *InstanceService) GetQuotaUsage(ctx context.Context, userID uint) (overview QuotaOverview, err error) {
+	overview.UserId = userID
+	if overview.Quota, err = effectiveQuota(global.GVA_DB, userID); err != nil {
+		return
+	}
+	overview.Usage, err = loadQuotaUsage(global.GVA_DB, userID, 0)
+	return
+}
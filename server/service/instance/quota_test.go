@@ -0,0 +1,46 @@
+package instance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/quota"
+)
+
+func TestExceededQuota(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit quota.ResourceQuota
+		used  QuotaUsage
+		req   QuotaUsage
+		want  []string
+	}{
+		{
+			name: "未配置配额不限制",
+			used: QuotaUsage{Instances: 100, Gpus: 64},
+			req:  QuotaUsage{Instances: 1, Gpus: 8},
+			want: nil,
+		},
+		{
+			name:  "恰好达到上限允许",
+			limit: quota.ResourceQuota{MaxInstances: 2, MaxGpus: 4},
+			used:  QuotaUsage{Instances: 1, Gpus: 2},
+			req:   QuotaUsage{Instances: 1, Gpus: 2},
+			want:  nil,
+		},
+		{
+			name:  "超出GPU与显存",
+			limit: quota.ResourceQuota{MaxGpus: 4, MaxGpuMemoryGb: 160, MaxCpuCores: 64},
+			used:  QuotaUsage{Gpus: 2, GpuMemoryGb: 80, CpuCores: 16},
+			req:   QuotaUsage{Gpus: 4, GpuMemoryGb: 160, CpuCores: 32},
+			want:  []string{"GPU已用2张/上限4张，本次需要4张", "显存已用80GB/上限160GB，本次需要160GB"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exceededQuota(tt.limit, tt.used, tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exceededQuota() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
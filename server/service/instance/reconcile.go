@@ -0,0 +1,330 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"go.uber.org/zap"
+)
+
+// 对账发现的不一致类型
+const (
+	ReconcileDockerContainer = "docker_only_container" // 节点上有受管容器，但没有实例引用
+	ReconcileDockerVolume    = "docker_only_volume"    // 节点上有受管数据卷，但没有容器挂载也不属于该节点的实例
+	ReconcileDbInstance      = "db_only_instance"      // 实例记录引用的容器在节点上不存在
+)
+
+// 对账处理方式
+const (
+	ReconcileActionRemove      = "remove"       // 删除孤儿容器/数据卷
+	ReconcileActionAdopt       = "adopt"        // 同名容器接管给丢失容器的实例
+	ReconcileActionMarkMissing = "mark_missing" // 实例容器状态置为 missing，停止计费，不删除记录
+	ReconcileActionSkip        = "skip"         // 处于宽限期或实例正在执行操作，本轮不处理
+)
+
+const (
+	managedByLabel         = "managed-by"
+	managedByValue         = "docker-gpu-manage"
+	instanceLabel          = "instance"
+	containerStatusMissing = "missing"
+)
+
+// ReconcileItem 单条对账结果
+type ReconcileItem struct {
+	Kind          string `json:"kind"`
+	Action        string `json:"action"`
+	InstanceId    uint   `json:"instanceId,omitempty"`
+	ContainerId   string `json:"containerId,omitempty"`
+	ContainerName string `json:"containerName,omitempty"`
+	VolumeName    string `json:"volumeName,omitempty"`
+	Reason        string `json:"reason"`
+	Result        string `json:"result,omitempty"` // 执行结果，演练时为空
+}
+
+// ReconcileReport 单个节点的对账报告
+type ReconcileReport struct {
+	NodeId    uint            `json:"nodeId"`
+	NodeName  string          `json:"nodeName"`
+	DryRun    bool            `json:"dryRun"`
+	CheckedAt time.Time       `json:"checkedAt"`
+	Error     string          `json:"error,omitempty"`
+	Items     []ReconcileItem `json:"items"`
+}
+
+// reconcileContainer 节点上的容器快照
+type reconcileContainer struct {
+	ID       string
+	Instance string // instance 标签，辅助容器为空
+	Managed  bool
+	State    string
+	Created  time.Time
+	Volumes  []string
+}
+
+// reconcileVolume 节点上的受管数据卷快照
+type reconcileVolume struct {
+	Name    string
+	Created time.Time
+}
+
+// instanceBusy 实例正在创建、迁移或变更规格，对账不触碰其容器与数据卷
+func instanceBusy(inst *instanceModel.Instance) bool {
+	state := safeString(inst.ProvisionState)
+	return state != "" && state != provisionRunning && state != provisionFailed
+}
+
+// planReconcile 比对节点上的受管容器、数据卷与实例记录，生成处理计划
+// nodeInstances 为该节点上未删除的实例，busyNames 为所有节点上正在执行操作的实例容器名。
+// 新建不足 grace 的容器与数据卷仅标记为 skip，避免与创建、迁移流程竞争。
+func planReconcile(nodeInstances []instanceModel.Instance, busyNames map[string]bool, containers []reconcileContainer, volumes []reconcileVolume, now time.Time, grace time.Duration) []ReconcileItem {
+	present := make(map[string]reconcileContainer, len(containers))
+	mounted := make(map[string]bool)
+	for _, c := range containers {
+		present[c.ID] = c
+		for _, v := range c.Volumes {
+			mounted[v] = true
+		}
+	}
+
+	referenced := make(map[string]bool)
+	ownVolumes := make(map[string]bool)
+	// 容器丢失、可由同名容器接管的实例
+	lost := make(map[string]*instanceModel.Instance)
+	for i := range nodeInstances {
+		inst := &nodeInstances[i]
+		name := safeString(inst.ContainerName)
+		if name != "" {
+			ownVolumes[name+"-data"] = true
+		}
+		id := safeString(inst.ContainerId)
+		if id != "" {
+			if _, ok := present[id]; ok {
+				referenced[id] = true
+				continue
+			}
+		}
+		if name != "" && !instanceBusy(inst) && safeString(inst.ProvisionState) != provisionFailed {
+			lost[name] = inst
+		}
+	}
+
+	var items []ReconcileItem
+	adopted := make(map[uint]bool)
+	for _, c := range containers {
+		if !c.Managed || referenced[c.ID] {
+			continue
+		}
+		item := ReconcileItem{Kind: ReconcileDockerContainer, ContainerId: c.ID, ContainerName: c.Instance}
+		switch {
+		case c.Instance != "" && busyNames[c.Instance]:
+			item.Action, item.Reason = ReconcileActionSkip, "实例正在执行操作"
+		case c.Instance != "" && lost[c.Instance] != nil && !adopted[lost[c.Instance].ID]:
+			inst := lost[c.Instance]
+			adopted[inst.ID] = true
+			item.Action, item.InstanceId = ReconcileActionAdopt, inst.ID
+			item.Reason = "实例记录的容器不存在，节点上有同名容器"
+		case now.Sub(c.Created) < grace:
+			item.Action, item.Reason = ReconcileActionSkip, "容器创建时间在宽限期内"
+		case c.Instance == "":
+			item.Action, item.Reason = ReconcileActionRemove, "残留的辅助容器"
+		default:
+			item.Action, item.Reason = ReconcileActionRemove, "没有实例引用该容器"
+		}
+		items = append(items, item)
+	}
+
+	for i := range nodeInstances {
+		inst := &nodeInstances[i]
+		id := safeString(inst.ContainerId)
+		if id == "" || referenced[id] || adopted[inst.ID] {
+			continue
+		}
+		item := ReconcileItem{Kind: ReconcileDbInstance, InstanceId: inst.ID, ContainerId: id, ContainerName: safeString(inst.ContainerName)}
+		switch {
+		case instanceBusy(inst):
+			item.Action, item.Reason = ReconcileActionSkip, "实例正在执行操作"
+		case safeString(inst.ContainerStatus) == containerStatusMissing:
+			continue
+		default:
+			item.Action, item.Reason = ReconcileActionMarkMissing, "节点上不存在实例记录的容器"
+		}
+		items = append(items, item)
+	}
+
+	for _, v := range volumes {
+		if mounted[v.Name] || ownVolumes[v.Name] {
+			continue
+		}
+		item := ReconcileItem{Kind: ReconcileDockerVolume, VolumeName: v.Name}
+		switch {
+		case busyNames[strings.TrimSuffix(v.Name, "-data")]:
+			item.Action, item.Reason = ReconcileActionSkip, "实例正在执行操作"
+		case v.Created.IsZero() || now.Sub(v.Created) < grace:
+			item.Action, item.Reason = ReconcileActionSkip, "数据卷创建时间在宽限期内"
+		default:
+			item.Action, item.Reason = ReconcileActionRemove, "没有容器挂载且不属于该节点的实例"
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// listReconcileResources 列出节点上的全部容器与受管数据卷
+func (d *DockerService) listReconcileResources(ctx context.Context, node *computenode.ComputeNode) ([]reconcileContainer, []reconcileVolume, error) {
+	cli, err := d.CreateDockerClient(node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+	}
+	defer cli.Close()
+
+	list, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取容器列表失败: %v", err)
+	}
+	containers := make([]reconcileContainer, 0, len(list))
+	for _, c := range list {
+		rc := reconcileContainer{
+			ID:       c.ID,
+			Instance: c.Labels[instanceLabel],
+			Managed:  c.Labels[managedByLabel] == managedByValue,
+			State:    c.State,
+			Created:  time.Unix(c.Created, 0),
+		}
+		for _, m := range c.Mounts {
+			if m.Name != "" {
+				rc.Volumes = append(rc.Volumes, m.Name)
+			}
+		}
+		containers = append(containers, rc)
+	}
+
+	vols, err := cli.VolumeList(ctx, volume.ListOptions{Filters: filters.NewArgs(filters.Arg("label", managedByLabel+"="+managedByValue))})
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取数据卷列表失败: %v", err)
+	}
+	volumes := make([]reconcileVolume, 0, len(vols.Volumes))
+	for _, v := range vols.Volumes {
+		// 只处理实例数据卷（<容器名>-data）
+		if !strings.HasSuffix(v.Name, "-data") {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339, v.CreatedAt)
+		volumes = append(volumes, reconcileVolume{Name: v.Name, Created: created})
+	}
+	return containers, volumes, nil
+}
+
+// ReconcileNode 对账节点上的受管容器、数据卷与实例记录
+// execute 为 false 时只生成报告（演练）；为 true 时删除孤儿、接管同名容器、把容器丢失的实例标记为 missing。
+func (instanceService *InstanceService) ReconcileNode(ctx context.Context, node *computenode.ComputeNode, execute bool) ReconcileReport {
+	report := ReconcileReport{NodeId: node.ID, NodeName: safeString(node.Name), DryRun: !execute, CheckedAt: time.Now()}
+
+	var nodeInstances []instanceModel.Instance
+	if err := global.GVA_DB.Where("node_id = ?", node.ID).Find(&nodeInstances).Error; err != nil {
+		report.Error = fmt.Sprintf("查询节点实例失败: %v", err)
+		return report
+	}
+	var busy []instanceModel.Instance
+	if err := global.GVA_DB.Select("id", "container_name", "provision_state").
+		Where("provision_state IS NOT NULL AND provision_state NOT IN ?", []string{"", provisionRunning, provisionFailed}).
+		Find(&busy).Error; err != nil {
+		report.Error = fmt.Sprintf("查询操作中的实例失败: %v", err)
+		return report
+	}
+	busyNames := make(map[string]bool, len(busy))
+	for _, inst := range busy {
+		if name := safeString(inst.ContainerName); name != "" {
+			busyNames[name] = true
+		}
+	}
+
+	containers, volumes, err := dockerService.listReconcileResources(ctx, node)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	grace := time.Duration(global.GVA_CONFIG.Reconcile.GraceMinutes) * time.Minute
+	if grace <= 0 {
+		grace = 30 * time.Minute
+	}
+	report.Items = planReconcile(nodeInstances, busyNames, containers, volumes, time.Now(), grace)
+	if !execute {
+		return report
+	}
+
+	states := make(map[string]string, len(containers))
+	for _, c := range containers {
+		states[c.ID] = c.State
+	}
+	for i := range report.Items {
+		item := &report.Items[i]
+		if item.Action == ReconcileActionSkip {
+			continue
+		}
+		if err = applyReconcileItem(ctx, node, item, states[item.ContainerId]); err != nil {
+			item.Result = "失败: " + err.Error()
+			global.GVA_LOG.Warn("节点对账处理失败", zap.Uint("nodeId", node.ID), zap.String("kind", item.Kind), zap.String("action", item.Action), zap.Error(err))
+			continue
+		}
+		item.Result = "完成"
+		global.GVA_LOG.Info("节点对账已处理",
+			zap.Uint("nodeId", node.ID),
+			zap.String("kind", item.Kind),
+			zap.String("action", item.Action),
+			zap.Uint("实例ID", item.InstanceId),
+			zap.String("容器ID", item.ContainerId),
+			zap.String("volume", item.VolumeName))
+	}
+	return report
+}
+
+// applyReconcileItem 执行单条对账计划，实例记录的更新都以执行前的容器ID为条件，避免覆盖并发操作
+func applyReconcileItem(ctx context.Context, node *computenode.ComputeNode, item *ReconcileItem, state string) error {
+	switch item.Action {
+	case ReconcileActionRemove:
+		if item.Kind == ReconcileDockerVolume {
+			return dockerService.RemoveVolume(ctx, node, item.VolumeName)
+		}
+		return dockerService.RemoveContainer(ctx, node, item.ContainerId)
+	case ReconcileActionAdopt, ReconcileActionMarkMissing:
+		var inst instanceModel.Instance
+		if err := global.GVA_DB.Where("id = ? AND node_id = ?", item.InstanceId, node.ID).First(&inst).Error; err != nil {
+			return fmt.Errorf("获取实例失败: %v", err)
+		}
+		if instanceBusy(&inst) {
+			return errors.New("实例正在执行操作")
+		}
+		updates := map[string]interface{}{"container_status": containerStatusMissing}
+		if item.Action == ReconcileActionAdopt {
+			updates = map[string]interface{}{"container_id": item.ContainerId, "container_status": state}
+		}
+		db := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID)
+		if inst.ContainerId == nil {
+			db = db.Where("container_id IS NULL")
+		} else {
+			db = db.Where("container_id = ?", *inst.ContainerId)
+		}
+		res := db.Updates(updates)
+		if res.Error != nil {
+			return fmt.Errorf("更新实例失败: %v", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("实例记录已变更")
+		}
+		fromStatus := safeString(inst.ContainerStatus)
+		if toStatus := updates["container_status"].(string); toStatus != fromStatus {
+			recordUsageEvent(global.GVA_DB, &inst, usageEventStatusChange, fromStatus, toStatus)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+func TestPlanReconcile(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	str := func(s string) *string { return &s }
+	inst := func(id uint, name, containerID, state string) instanceModel.Instance {
+		i := instanceModel.Instance{ContainerName: str(name), ContainerId: str(containerID), ProvisionState: str(state)}
+		i.ID = id
+		return i
+	}
+
+	tests := []struct {
+		name       string
+		instances  []instanceModel.Instance
+		busy       map[string]bool
+		containers []reconcileContainer
+		volumes    []reconcileVolume
+		want       map[string]string // 容器ID/卷名/实例容器名 -> action
+	}{
+		{
+			name:       "一致",
+			instances:  []instanceModel.Instance{inst(1, "a", "c1", provisionRunning)},
+			containers: []reconcileContainer{{ID: "c1", Instance: "a", Managed: true, Created: old, Volumes: []string{"a-data"}}},
+			volumes:    []reconcileVolume{{Name: "a-data", Created: old}},
+			want:       map[string]string{},
+		},
+		{
+			name:       "无实例引用的容器与数据卷",
+			containers: []reconcileContainer{{ID: "z1", Instance: "gone", Managed: true, Created: old}, {ID: "u1", Created: old}},
+			volumes:    []reconcileVolume{{Name: "gone-data", Created: old}},
+			want:       map[string]string{"z1": ReconcileActionRemove, "gone-data": ReconcileActionRemove},
+		},
+		{
+			name:       "宽限期内不处理",
+			containers: []reconcileContainer{{ID: "z1", Instance: "new", Managed: true, Created: now.Add(-time.Minute)}},
+			volumes:    []reconcileVolume{{Name: "new-data", Created: now.Add(-time.Minute)}},
+			want:       map[string]string{"z1": ReconcileActionSkip, "new-data": ReconcileActionSkip},
+		},
+		{
+			name:       "同名容器接管",
+			instances:  []instanceModel.Instance{inst(2, "b", "lost", provisionRunning)},
+			containers: []reconcileContainer{{ID: "c2", Instance: "b", Managed: true, Created: old}},
+			want:       map[string]string{"c2": ReconcileActionAdopt},
+		},
+		{
+			name:      "容器丢失",
+			instances: []instanceModel.Instance{inst(3, "c", "lost", provisionRunning)},
+			want:      map[string]string{"c": ReconcileActionMarkMissing},
+		},
+		{
+			name:       "迁移中的实例",
+			instances:  []instanceModel.Instance{inst(4, "d", "c4", provisionMigrating)},
+			busy:       map[string]bool{"d": true},
+			containers: []reconcileContainer{{ID: "c4", Instance: "d", Managed: true, Created: old}},
+			volumes:    []reconcileVolume{{Name: "e-data", Created: old}},
+			want:       map[string]string{"e-data": ReconcileActionRemove},
+		},
+		{
+			name:       "迁移目标节点上的新容器",
+			busy:       map[string]bool{"f": true},
+			containers: []reconcileContainer{{ID: "c6", Instance: "f", Managed: true, Created: old}},
+			volumes:    []reconcileVolume{{Name: "f-data", Created: old}},
+			want:       map[string]string{"c6": ReconcileActionSkip, "f-data": ReconcileActionSkip},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := planReconcile(tt.instances, tt.busy, tt.containers, tt.volumes, now, 30*time.Minute)
+			got := make(map[string]string, len(items))
+			for _, it := range items {
+				key := it.ContainerId
+				switch it.Kind {
+				case ReconcileDockerVolume:
+					key = it.VolumeName
+				case ReconcileDbInstance:
+					key = it.ContainerName
+				}
+				got[key] = it.Action
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("items = %+v, want %v", items, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("%s action = %q, want %q (items %+v)", k, got[k], v, items)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// provisionResizing 变更规格中，期间状态巡检跳过该实例
+const provisionResizing = "resizing"
+
+// 变更规格的执行方式
+const (
+	ResizeModeLive     = "live"     // 在线调整CPU/内存限制
+	ResizeModeRecreate = "recreate" // 在同一节点重建容器，保留数据卷
+)
+
+// ResizeResult 变更规格结果
+type ResizeResult struct {
+	InstanceId  uint   `json:"instanceId"`
+	Mode        string `json:"mode"`
+	ContainerId string `json:"containerId"`
+}
+
+func specInt(p *int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func specBool(p *bool) bool {
+	return p != nil && *p
+}
+
+// canResizeLive 只有CPU与内存变化时可通过 docker update 在线生效；
+// GPU、显存切分与磁盘变化需要重建容器。
+func canResizeLive(from, to *product.ProductSpec) bool {
+	return specInt(from.GpuCount) == specInt(to.GpuCount) &&
+		specBool(from.SupportMemorySplit) == specBool(to.SupportMemorySplit) &&
+		specInt(from.MemoryCapacity) == specInt(to.MemoryCapacity) &&
+		specInt(from.SystemDiskGb) == specInt(to.SystemDiskGb) &&
+		specInt(from.DataDiskGb) == specInt(to.DataDiskGb)
+}
+
+// ResizeInstance 将实例变更为另一产品规格
+// 容量按 GetAvailableNodes 的核算方式重新校验（实例自身占用视为已释放），只在原节点上进行。
+func (instanceService *InstanceService) ResizeInstance(ctx context.Context, req instanceReq.ResizeInstanceReq, userID uint, isAdmin bool) (res ResizeResult, err error) {
+	inst, node, err := instanceService.getInstanceAndNode(strconv.FormatUint(uint64(req.ID), 10))
+	if err != nil {
+		return res, err
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return res, errors.New("无权操作此实例")
+	}
+	if inst.ContainerId == nil || *inst.ContainerId == "" {
+		return res, errors.New("容器ID为空")
+	}
+	if state := safeString(inst.ProvisionState); state != "" && state != provisionRunning {
+		return res, fmt.Errorf("实例当前状态(%s)不允许变更规格", state)
+	}
+	if inst.SpecId != nil && *inst.SpecId == req.SpecId {
+		return res, errors.New("新规格与当前规格相同")
+	}
+
+	var oldSpec, newSpec product.ProductSpec
+	if inst.SpecId != nil {
+		if err = global.GVA_DB.Unscoped().Where("id = ?", *inst.SpecId).First(&oldSpec).Error; err != nil {
+			return res, fmt.Errorf("获取当前规格信息失败: %v", err)
+		}
+	}
+	if err = global.GVA_DB.Where("id = ?", req.SpecId).First(&newSpec).Error; err != nil {
+		return res, fmt.Errorf("获取产品规格信息失败: %v", err)
+	}
+	if err = checkBalance(global.GVA_DB, inst.UserId, &newSpec); err != nil {
+		return res, err
+	}
+
+	// 原节点在释放实例自身占用后需能容纳新规格
+	meta := SchedulingRequestMeta{ExcludeInstanceId: inst.ID, Source: scheduleSourceResize, InstanceId: inst.ID, UserId: userID}
+	if inst.ImageId != nil {
+		meta.ImageId = uint(*inst.ImageId)
+	}
+	nodes, err := instanceService.GetAvailableNodes(ctx, strconv.FormatInt(req.SpecId, 10), meta)
+	if err != nil {
+		return res, fmt.Errorf("校验节点容量失败: %v", err)
+	}
+	fits := false
+	for _, n := range nodes {
+		if n.ID == node.ID {
+			fits = true
+			break
+		}
+	}
+	if !fits {
+		return res, errors.New("当前节点剩余资源不足以容纳新规格")
+	}
+
+	// 标记变更中，防止并发变更、状态巡检覆盖
+	lock := global.GVA_DB.Model(&instanceModel.Instance{}).
+		Where("id = ? AND (provision_state IS NULL OR provision_state = '' OR provision_state = ?)", inst.ID, provisionRunning).
+		Update("provision_state", provisionResizing)
+	if lock.Error != nil {
+		return res, fmt.Errorf("更新实例状态失败: %v", lock.Error)
+	}
+	if lock.RowsAffected == 0 {
+		return res, errors.New("实例正在执行其他操作，请稍后重试")
+	}
+	// 结束后恢复变更前的状态（可能为空），只覆盖本次写入的 resizing
+	var prevState interface{}
+	if inst.ProvisionState != nil {
+		prevState = *inst.ProvisionState
+	}
+	defer func() {
+		if uerr := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ? AND provision_state = ?", inst.ID, provisionResizing).
+			Update("provision_state", prevState).Error; uerr != nil {
+			global.GVA_LOG.Error("恢复实例状态失败", zap.Uint("实例ID", inst.ID), zap.Error(uerr))
+		}
+	}()
+
+	// 变更过程不随HTTP请求取消，避免停在半完成状态
+	opCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	res = ResizeResult{InstanceId: inst.ID, ContainerId: *inst.ContainerId}
+	if canResizeLive(&oldSpec, &newSpec) {
+		res.Mode = ResizeModeLive
+		err = instanceService.resizeLive(opCtx, inst, node, &newSpec)
+	} else {
+		res.Mode = ResizeModeRecreate
+		res.ContainerId, err = instanceService.resizeRecreate(opCtx, inst, node, &newSpec)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	// 价格随规格变化，写入计量流水切分计费区间
+	inst.SpecId = &req.SpecId
+	status := safeString(inst.ContainerStatus)
+	recordUsageEvent(global.GVA_DB, inst, usageEventResize, status, status)
+	global.GVA_LOG.Info("实例规格已变更", zap.Uint("实例ID", inst.ID), zap.Int64("规格ID", req.SpecId), zap.String("mode", res.Mode))
+	return res, nil
+}
+
+// resizeLive 在事务内校验配额并更新规格，提交后在线更新容器CPU与内存限制
+// docker 调用不放在事务内，避免长时间持有节点行锁；更新失败时把规格改回原值。
+func (instanceService *InstanceService) resizeLive(ctx context.Context, inst *instanceModel.Instance, node *computenode.ComputeNode, spec *product.ProductSpec) error {
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkQuota(tx, inst.UserId, spec, inst.ID); err != nil {
+			return err
+		}
+		if err := reserveNodeCapacity(tx, node, spec, inst.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Update("spec_id", spec.ID).Error; err != nil {
+			return fmt.Errorf("更新实例规格失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = dockerService.UpdateContainerResources(ctx, node, *inst.ContainerId, specInt(spec.CpuCores), specInt(spec.MemoryGb)); err != nil {
+		if rbErr := global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Update("spec_id", inst.SpecId).Error; rbErr != nil {
+			global.GVA_LOG.Error("回滚实例规格失败", zap.Uint("实例ID", inst.ID), zap.Error(rbErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// resizeRecreate 在同一节点按新规格重建容器并复用原数据卷；任一步失败时恢复原容器与GPU分配
+func (instanceService *InstanceService) resizeRecreate(ctx context.Context, inst *instanceModel.Instance, node *computenode.ComputeNode, spec *product.ProductSpec) (string, error) {
+	oldID := *inst.ContainerId
+	name := safeString(inst.ContainerName)
+	if name == "" {
+		return "", errors.New("容器名称为空")
+	}
+	var image imageregistry.ImageRegistry
+	if inst.ImageId == nil {
+		return "", errors.New("镜像ID不能为空")
+	}
+	if err := global.GVA_DB.Unscoped().Where("id = ?", *inst.ImageId).First(&image).Error; err != nil {
+		return "", fmt.Errorf("获取镜像信息失败: %v", err)
+	}
+	volumeName, err := dockerService.GetDataVolumeName(ctx, node, oldID)
+	if err != nil {
+		return "", fmt.Errorf("读取容器数据卷失败: %v", err)
+	}
+	wasRunning := safeString(inst.ContainerStatus) == "running"
+
+	// 1. 在事务内校验配额并按新规格重新分配GPU卡
+	var oldAllocs []instanceModel.GpuAllocation
+	var devices []int
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if txErr := checkQuota(tx, inst.UserId, spec, inst.ID); txErr != nil {
+			return txErr
+		}
+		if txErr := reserveNodeCapacity(tx, node, spec, inst.ID); txErr != nil {
+			return txErr
+		}
+		var txErr error
+		oldAllocs, devices, txErr = reallocateGpuDevices(tx, inst.ID, node, spec)
+		return txErr
+	})
+	if err != nil {
+		return "", err
+	}
+
+	backupName := name + "-resize-old"
+	var newID string
+	renamed := false
+	rollback := func(cause error) (string, error) {
+		if newID != "" {
+			if rmErr := dockerService.RemoveContainer(ctx, node, newID); rmErr != nil {
+				global.GVA_LOG.Error("回滚时删除新容器失败", zap.Uint("实例ID", inst.ID), zap.Error(rmErr))
+			}
+		}
+		if renamed {
+			if rnErr := dockerService.RenameContainer(ctx, node, oldID, name); rnErr != nil {
+				global.GVA_LOG.Error("回滚时恢复容器名称失败", zap.Uint("实例ID", inst.ID), zap.Error(rnErr))
+			}
+		}
+		if wasRunning {
+			if stErr := dockerService.StartContainer(ctx, node, oldID); stErr != nil {
+				global.GVA_LOG.Error("回滚时启动原容器失败", zap.Uint("实例ID", inst.ID), zap.Error(stErr))
+			}
+		}
+		if txErr := restoreGpuAllocations(global.GVA_DB, inst.ID, oldAllocs); txErr != nil {
+			global.GVA_LOG.Error("回滚时恢复GPU分配失败", zap.Uint("实例ID", inst.ID), zap.Error(txErr))
+		}
+		return "", fmt.Errorf("变更规格失败，已恢复原容器: %v", cause)
+	}
+
+	// 2. 停止原容器并改名让出容器名，保留到新容器启动成功
+	if wasRunning {
+		if err = dockerService.StopContainer(ctx, node, oldID); err != nil {
+			return rollback(err)
+		}
+	}
+	if err = dockerService.RenameContainer(ctx, node, oldID, backupName); err != nil {
+		return rollback(err)
+	}
+	renamed = true
+
+	// 3. 按新规格创建并启动容器，挂载原数据卷
+	containerConfig := dockerService.BuildContainerConfig(&image, spec, node, name)
+	containerConfig.GPUDeviceIDs = deviceIDStrings(devices)
+	containerConfig.DataVolumeName = volumeName
+	if newID, err = dockerService.CreateContainer(ctx, node, containerConfig); err != nil {
+		return rollback(err)
+	}
+	if wasRunning {
+		if err = dockerService.StartContainer(ctx, node, newID); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// 4. 更新实例记录，最后删除原容器（不删除数据卷）
+	if err = global.GVA_DB.Model(&instanceModel.Instance{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+		"spec_id":      spec.ID,
+		"container_id": newID,
+	}).Error; err != nil {
+		return rollback(fmt.Errorf("更新实例记录失败: %v", err))
+	}
+	inst.ContainerId = &newID
+	if rmErr := dockerService.RemoveContainer(ctx, node, oldID); rmErr != nil {
+		global.GVA_LOG.Warn("删除原容器失败，需手动清理", zap.Uint("实例ID", inst.ID), zap.String("container", backupName), zap.Error(rmErr))
+	}
+	return newID, nil
+}
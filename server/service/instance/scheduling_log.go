@@ -0,0 +1,150 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"go.uber.org/zap"
+)
+
+// 调度决策记录来源，前端查询可用节点等只读请求不记录
+const (
+	scheduleSourcePlacement         = "placement"          // 创建实例时自动调度
+	scheduleSourcePlacementFallback = "placement_fallback" // 创建容器失败后改到备选节点
+	scheduleSourceResize            = "resize"             // 变更规格前校验容量
+	ScheduleSourceMigrate           = "migrate"            // 迁移前校验目标节点容量（含节点排空迁移）
+)
+
+func marshalSchedulingField(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// saveSchedulingLog 持久化一次调度打分，meta.Source 为记录来源，nodes 为排序后的候选节点，首个即选中节点
+func saveSchedulingLog(specID string, version string, state string, rule string, meta SchedulingRequestMeta, details []CandidateScoreDetail, nodes []AvailableNode, breakerOn bool, latency time.Duration, err error) {
+	if global.GVA_DB == nil {
+		return
+	}
+	specId, _ := strconv.ParseInt(specID, 10, 64)
+	record := instanceModel.SchedulingLog{
+		TraceId:          meta.TraceId,
+		Source:           meta.Source,
+		InstanceId:       meta.InstanceId,
+		UserId:           meta.UserId,
+		SpecId:           specId,
+		ImageId:          meta.ImageId,
+		Region:           meta.Region,
+		ISP:              meta.ISP,
+		UserHash:         meta.UserHash,
+		StrategyVersion:  version,
+		StrategyState:    state,
+		HitRule:          rule,
+		Strategy:         resolveStrategy(version).Name(),
+		CircuitBreakerOn: breakerOn,
+		CandidateCount:   len(nodes),
+		Candidates:       marshalSchedulingField(details),
+		LatencyMs:        latency.Milliseconds(),
+	}
+	if len(nodes) > 0 {
+		record.ChosenNodeId = nodes[0].ID
+		record.ChosenNodeName = nodes[0].Name
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if dbErr := global.GVA_DB.Create(&record).Error; dbErr != nil {
+		global.GVA_LOG.Warn("保存调度决策记录失败", zap.String("traceId", meta.TraceId), zap.Error(dbErr))
+	}
+}
+
+// savePlacementLog 自动调度落位后回填调度记录；没有追踪ID（后台改到备选节点）时单独写一条
+func savePlacementLog(traceID string, instanceID uint, specID uint, attempts []placementAttempt, chosen uint, err error) {
+	if global.GVA_DB == nil {
+		return
+	}
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if traceID != "" {
+		updates := map[string]interface{}{
+			"instance_id": instanceID,
+			"attempts":    marshalSchedulingField(attempts),
+		}
+		if chosen != 0 {
+			// 提交时复核失败会落到后续候选节点，选中节点以实际落位为准
+			var node computenode.ComputeNode
+			if global.GVA_DB.Select("id", "name").Where("id = ?", chosen).First(&node).Error == nil {
+				updates["chosen_node_id"] = chosen
+				updates["chosen_node_name"] = safeString(node.Name)
+			}
+		}
+		if errMsg != "" {
+			updates["error"] = errMsg
+		}
+		if dbErr := global.GVA_DB.Model(&instanceModel.SchedulingLog{}).Where("trace_id = ?", traceID).Updates(updates).Error; dbErr != nil {
+			global.GVA_LOG.Warn("回填调度决策记录失败", zap.String("traceId", traceID), zap.Error(dbErr))
+		}
+		return
+	}
+	record := instanceModel.SchedulingLog{
+		Source:         scheduleSourcePlacementFallback,
+		InstanceId:     instanceID,
+		SpecId:         int64(specID),
+		CandidateCount: len(attempts),
+		ChosenNodeId:   chosen,
+		Attempts:       marshalSchedulingField(attempts),
+		Error:          errMsg,
+	}
+	if dbErr := global.GVA_DB.Create(&record).Error; dbErr != nil {
+		global.GVA_LOG.Warn("保存调度决策记录失败", zap.Uint("instanceId", instanceID), zap.Error(dbErr))
+	}
+}
+
+// GetSchedulingLogList 分页查询调度决策记录，按时间倒序
+func (instanceService *InstanceService) GetSchedulingLogList(ctx context.Context, info instanceReq.SchedulingLogSearch) (list []instanceModel.SchedulingLog, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&instanceModel.SchedulingLog{})
+	if len(info.CreatedAtRange) == 2 {
+		db = db.Where("created_at BETWEEN ? AND ?", info.CreatedAtRange[0], info.CreatedAtRange[1])
+	}
+	if info.TraceId != "" {
+		db = db.Where("trace_id = ?", info.TraceId)
+	}
+	if info.Source != "" {
+		db = db.Where("source = ?", info.Source)
+	}
+	if info.InstanceId != 0 {
+		db = db.Where("instance_id = ?", info.InstanceId)
+	}
+	if info.UserId != 0 {
+		db = db.Where("user_id = ?", info.UserId)
+	}
+	if info.ChosenNodeId != 0 {
+		db = db.Where("chosen_node_id = ?", info.ChosenNodeId)
+	}
+	if info.StrategyVersion != "" {
+		db = db.Where("strategy_version = ?", info.StrategyVersion)
+	}
+	if info.HitRule != "" {
+		db = db.Where("hit_rule = ?", info.HitRule)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id desc").Find(&list).Error
+	return list, total, err
+}
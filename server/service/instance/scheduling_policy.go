@@ -22,6 +22,14 @@ type SchedulingRequestMeta struct {
 	Region   string
 	ISP      string
 	UserHash string
+	ImageId  uint // 待创建实例的镜像，用于优先选择已缓存该镜像的节点
+	// 资源核算时视为已释放的实例（变更规格时排除实例自身占用）
+	ExcludeInstanceId uint
+	// 以下用于调度决策记录：调用来源、追踪ID（自动调度据此回填实例与落位结果）、请求用户与关联实例
+	Source     string
+	TraceId    string
+	UserId     uint
+	InstanceId uint
 }
 
 type CandidateScoreDetail struct {
@@ -32,6 +40,9 @@ type CandidateScoreDetail struct {
 	CPUScore         float64 `json:"cpuScore"`
 	MemoryScore      float64 `json:"memoryScore"`
 	DiskScore        float64 `json:"diskScore"`
+	ImageScore       float64 `json:"imageScore"`
+	FragmentScore    float64 `json:"fragmentScore"` // fragmentation 策略：放置后零散显存越少越高
+	Strategy         string  `json:"strategy"`      // 实际使用的调度算法
 	RuleMatched      string  `json:"ruleMatched"`
 	StrategyVersion  string  `json:"strategyVersion"`
 	StrategyState    string  `json:"strategyState"`
@@ -39,11 +50,15 @@ type CandidateScoreDetail struct {
 }
 
 type schedulerRuntime struct {
-	mu                sync.Mutex
-	requestCount      int
-	failureCount      int
-	totalLatencyMs    int64
-	circuitOpened     bool
+	mu sync.Mutex
+	// 熔断状态机，见 circuit_breaker.go
+	state             string
+	stateSince        time.Time
+	window            *slidingWindow
+	probeSeq          int
+	probeSuccesses    int
+	tripCount         int
+	lastTripReason    string
 	lastStableNodes   []AvailableNode
 	lastStableVersion string
 }
@@ -51,6 +66,11 @@ type schedulerRuntime struct {
 var pcdnSchedulerRuntime = &schedulerRuntime{}
 
 func chooseStrategy(meta SchedulingRequestMeta) (version string, state string, rule string, forceStable bool) {
+	return pcdnSchedulerRuntime.chooseStrategy(meta, time.Now())
+}
+
+// releaseConfig 读取当前发布的策略版本、发布状态与稳定版本
+func releaseConfig() (version string, state string, stableVersion string) {
 	cfg := global.GVA_CONFIG.PCDN
 	version = strings.TrimSpace(cfg.Version)
 	if version == "" {
@@ -60,16 +80,45 @@ func chooseStrategy(meta SchedulingRequestMeta) (version string, state string, r
 	if state == "" {
 		state = releaseStable
 	}
-	stableVersion := strings.TrimSpace(cfg.StableVersion)
+	stableVersion = strings.TrimSpace(cfg.StableVersion)
 	if stableVersion == "" {
 		stableVersion = "v1"
 	}
+	return version, state, stableVersion
+}
+
+func (r *schedulerRuntime) chooseStrategy(meta SchedulingRequestMeta, now time.Time) (version string, state string, rule string, forceStable bool) {
+	version, state, stableVersion := releaseConfig()
+
+	// 熔断已打开时，无条件回切到上一稳定策略；半开时只放行探测请求
+	fallback, probe := r.admit(now)
+	if fallback {
+		return stableVersion, releaseStable, "circuit_breaker_fallback", true
+	}
+	version, state, rule, forceStable = chooseReleaseStrategy(meta, version, state, stableVersion)
+	if probe {
+		// 只有实际命中灰度策略的请求才算探测，走稳定版本的结果不能用来关闭熔断
+		if state == releaseCanary {
+			rule += ruleHalfOpenProbe
+		} else {
+			r.releaseProbe()
+		}
+	}
+	return version, state, rule, forceStable
+}
 
-	// 熔断已打开时，无条件回切到上一稳定策略
-	if pcdnSchedulerRuntime.isCircuitOpened() {
+// previewStrategy 只读地选择策略版本（调度模拟用）：熔断未关闭时按回切稳定策略处理，不占用半开探测名额
+func previewStrategy(meta SchedulingRequestMeta, breakerOn bool) (version string, state string, rule string, forceStable bool) {
+	version, state, stableVersion := releaseConfig()
+	if breakerOn {
 		return stableVersion, releaseStable, "circuit_breaker_fallback", true
 	}
+	return chooseReleaseStrategy(meta, version, state, stableVersion)
+}
 
+// chooseReleaseStrategy 按发布状态与灰度规则选择策略版本
+func chooseReleaseStrategy(meta SchedulingRequestMeta, version string, state string, stableVersion string) (string, string, string, bool) {
+	cfg := global.GVA_CONFIG.PCDN
 	switch state {
 	case releaseStable:
 		return version, releaseStable, "stable_release", false
@@ -117,30 +166,10 @@ func containsIgnoreCase(arr []string, v string) bool {
 	return false
 }
 
-func scoreAndSortNodes(nodes []AvailableNode, version, state, rule string, breakerOn bool) ([]AvailableNode, []CandidateScoreDetail) {
-	weights := global.GVA_CONFIG.PCDN.ScoreWeight
-	if weights.Gpu == 0 && weights.CPU == 0 && weights.Memory == 0 && weights.Disk == 0 {
-		weights.Gpu = 0.4
-		weights.CPU = 0.25
-		weights.Memory = 0.25
-		weights.Disk = 0.1
-	}
-	maxGpu, maxCPU, maxMem, maxDisk := int64(1), int64(1), int64(1), int64(1)
-	for _, n := range nodes {
-		if n.AvailableGpu > maxGpu {
-			maxGpu = n.AvailableGpu
-		}
-		if n.AvailableCpu > maxCPU {
-			maxCPU = n.AvailableCpu
-		}
-		if n.AvailableMemory > maxMem {
-			maxMem = n.AvailableMemory
-		}
-		disk := n.AvailableSystemDisk + n.AvailableDataDisk
-		if disk > maxDisk {
-			maxDisk = disk
-		}
-	}
+// scoreAndSortNodes 使用策略版本对应的调度算法为候选节点打分并按分值降序排列
+func scoreAndSortNodes(nodes []AvailableNode, req schedulingRequest, version, state, rule string, breakerOn bool) ([]AvailableNode, []CandidateScoreDetail) {
+	strategy := resolveStrategy(version)
+	env := newScoreEnv(nodes, req)
 
 	details := make([]CandidateScoreDetail, 0, len(nodes))
 	type scoredNode struct {
@@ -150,26 +179,16 @@ func scoreAndSortNodes(nodes []AvailableNode, version, state, rule string, break
 	scored := make([]scoredNode, 0, len(nodes))
 
 	for _, n := range nodes {
-		gpu := float64(n.AvailableGpu) / float64(maxGpu)
-		cpu := float64(n.AvailableCpu) / float64(maxCPU)
-		mem := float64(n.AvailableMemory) / float64(maxMem)
-		diskVal := n.AvailableSystemDisk + n.AvailableDataDisk
-		disk := float64(diskVal) / float64(maxDisk)
-		total := gpu*weights.Gpu + cpu*weights.CPU + mem*weights.Memory + disk*weights.Disk
-		scored = append(scored, scoredNode{node: n, score: total})
-		details = append(details, CandidateScoreDetail{
-			NodeID:           n.ID,
-			NodeName:         n.Name,
-			TotalScore:       total,
-			GPUScore:         gpu,
-			CPUScore:         cpu,
-			MemoryScore:      mem,
-			DiskScore:        disk,
-			RuleMatched:      rule,
-			StrategyVersion:  version,
-			StrategyState:    state,
-			CircuitBreakerOn: breakerOn,
-		})
+		detail := strategy.Score(n, env)
+		detail.NodeID = n.ID
+		detail.NodeName = n.Name
+		detail.Strategy = strategy.Name()
+		detail.RuleMatched = rule
+		detail.StrategyVersion = version
+		detail.StrategyState = state
+		detail.CircuitBreakerOn = breakerOn
+		scored = append(scored, scoredNode{node: n, score: detail.TotalScore})
+		details = append(details, detail)
 	}
 
 	sort.SliceStable(scored, func(i, j int) bool {
@@ -185,62 +204,6 @@ func scoreAndSortNodes(nodes []AvailableNode, version, state, rule string, break
 	return result, details
 }
 
-func (r *schedulerRuntime) isCircuitOpened() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	return r.circuitOpened
-}
-
-func (r *schedulerRuntime) recordAndEvaluate(version string, state string, nodes []AvailableNode, err error, latency time.Duration) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.requestCount++
-	r.totalLatencyMs += latency.Milliseconds()
-	if err != nil || len(nodes) == 0 {
-		r.failureCount++
-	}
-	// 刷新稳定策略快照
-	if state == releaseStable && err == nil && len(nodes) > 0 {
-		r.lastStableNodes = append([]AvailableNode(nil), nodes...)
-		r.lastStableVersion = version
-	}
-
-	cfg := global.GVA_CONFIG.PCDN.CircuitBreaker
-	minSamples := cfg.MinSamples
-	if minSamples <= 0 {
-		minSamples = 10
-	}
-	if r.requestCount < minSamples {
-		return
-	}
-
-	failureRate := float64(r.failureCount) / float64(r.requestCount)
-	avgLatency := float64(r.totalLatencyMs) / float64(r.requestCount)
-	failThreshold := cfg.FailureRateThreshold
-	if failThreshold <= 0 {
-		failThreshold = 0.3
-	}
-	latencyThreshold := float64(cfg.LatencyThresholdMs)
-	if latencyThreshold <= 0 {
-		latencyThreshold = 800
-	}
-
-	if failureRate >= failThreshold || avgLatency >= latencyThreshold {
-		if !r.circuitOpened {
-			global.GVA_LOG.Warn("PCDN调度熔断触发，自动回切稳定策略",
-				zap.Float64("failureRate", failureRate),
-				zap.Float64("avgLatencyMs", avgLatency),
-				zap.Float64("failureRateThreshold", failThreshold),
-				zap.Float64("latencyThresholdMs", latencyThreshold),
-			)
-		}
-		r.circuitOpened = true
-	} else if r.circuitOpened {
-		r.circuitOpened = false
-		global.GVA_LOG.Info("PCDN调度熔断恢复，重新开放灰度")
-	}
-}
-
 func (r *schedulerRuntime) stableFallbackNodes() []AvailableNode {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -250,7 +213,11 @@ func (r *schedulerRuntime) stableFallbackNodes() []AvailableNode {
 	return append([]AvailableNode(nil), r.lastStableNodes...)
 }
 
-func writeSchedulingTraceLog(specID string, version string, state string, rule string, meta SchedulingRequestMeta, details []CandidateScoreDetail, nodeCount int, err error) {
+func writeSchedulingTraceLog(specID string, version string, state string, rule string, meta SchedulingRequestMeta, details []CandidateScoreDetail, nodes []AvailableNode, breakerOn bool, latency time.Duration, err error) {
+	// 只持久化实际落位（自动调度、迁移、变更规格）前的决策，前端查询可用节点不写库
+	if meta.Source != "" {
+		saveSchedulingLog(specID, version, state, rule, meta, details, nodes, breakerOn, latency, err)
+	}
 	if err != nil {
 		global.GVA_LOG.Warn("任务调度日志",
 			zap.String("specId", specID),
@@ -259,7 +226,7 @@ func writeSchedulingTraceLog(specID string, version string, state string, rule s
 			zap.String("hitRule", rule),
 			zap.String("region", meta.Region),
 			zap.String("isp", meta.ISP),
-			zap.Int("candidateCount", nodeCount),
+			zap.Int("candidateCount", len(nodes)),
 			zap.String("error", err.Error()),
 		)
 		return
@@ -271,7 +238,7 @@ func writeSchedulingTraceLog(specID string, version string, state string, rule s
 		zap.String("hitRule", rule),
 		zap.String("region", meta.Region),
 		zap.String("isp", meta.ISP),
-		zap.Int("candidateCount", nodeCount),
+		zap.Int("candidateCount", len(nodes)),
 		zap.String("candidateScoreDetail", fmt.Sprintf("%+v", details)),
 	)
 }
@@ -0,0 +1,217 @@
+package instance
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+)
+
+// 内置调度算法
+const (
+	strategySpread        = "spread"        // 优先剩余资源最多的节点，负载均衡
+	strategyBinpack       = "binpack"       // 优先已用资源最多的节点，尽量保留空闲整机
+	strategyFragmentation = "fragmentation" // 按卡模拟放置，优先减少显存碎片并保留整卡
+)
+
+// SchedulingStrategy 调度打分算法，分值越高越优先
+type SchedulingStrategy interface {
+	Name() string
+	Score(n AvailableNode, env *scoreEnv) CandidateScoreDetail
+}
+
+// schedulingRequest 本次调度的资源需求
+type schedulingRequest struct {
+	GpuCount      int64
+	MemoryPerCard int64 // 每张卡需要的显存(GB)
+	Split         bool  // 规格支持显存切分
+}
+
+// newSchedulingRequest 从产品规格计算调度需求
+func newSchedulingRequest(spec *product.ProductSpec) schedulingRequest {
+	req := schedulingRequest{GpuCount: specInt(spec.GpuCount), Split: specBool(spec.SupportMemorySplit)}
+	if req.GpuCount > 0 && spec.MemoryCapacity != nil {
+		req.MemoryPerCard = *spec.MemoryCapacity / req.GpuCount
+	}
+	return req
+}
+
+// defaultImageWeight 未配置 score-weight.image 时的镜像缓存加分权重
+const defaultImageWeight = 0.2
+
+// scoreEnv 一次打分共享的上下文：权重、候选节点中各资源的最大剩余量
+type scoreEnv struct {
+	weights                         config.ScoreWeight
+	imageWeight                     float64
+	req                             schedulingRequest
+	maxGpu, maxCPU, maxMem, maxDisk int64
+}
+
+func newScoreEnv(nodes []AvailableNode, req schedulingRequest) *scoreEnv {
+	weights := global.GVA_CONFIG.PCDN.ScoreWeight
+	if weights.Gpu == 0 && weights.CPU == 0 && weights.Memory == 0 && weights.Disk == 0 {
+		weights.Gpu = 0.4
+		weights.CPU = 0.25
+		weights.Memory = 0.25
+		weights.Disk = 0.1
+	}
+	imageWeight := defaultImageWeight
+	if weights.Image != nil {
+		imageWeight = *weights.Image
+	}
+	env := &scoreEnv{weights: weights, imageWeight: imageWeight, req: req, maxGpu: 1, maxCPU: 1, maxMem: 1, maxDisk: 1}
+	for _, n := range nodes {
+		if n.AvailableGpu > env.maxGpu {
+			env.maxGpu = n.AvailableGpu
+		}
+		if n.AvailableCpu > env.maxCPU {
+			env.maxCPU = n.AvailableCpu
+		}
+		if n.AvailableMemory > env.maxMem {
+			env.maxMem = n.AvailableMemory
+		}
+		disk := n.AvailableSystemDisk + n.AvailableDataDisk
+		if disk > env.maxDisk {
+			env.maxDisk = disk
+		}
+	}
+	return env
+}
+
+func imageScore(n AvailableNode) float64 {
+	if n.ImageCached {
+		return 1
+	}
+	return 0
+}
+
+// usedRatio 节点某项资源的已用比例，总量未知时按0处理
+func usedRatio(available int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	r := 1 - float64(available)/float64(total)
+	if r < 0 {
+		return 0
+	}
+	return r
+}
+
+func parseTotal(s string) int64 {
+	v, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return v
+}
+
+// spreadStrategy 各项剩余资源按候选节点最大值归一化后加权求和
+type spreadStrategy struct{}
+
+func (spreadStrategy) Name() string { return strategySpread }
+
+func (spreadStrategy) Score(n AvailableNode, env *scoreEnv) CandidateScoreDetail {
+	w := env.weights
+	d := CandidateScoreDetail{
+		GPUScore:    float64(n.AvailableGpu) / float64(env.maxGpu),
+		CPUScore:    float64(n.AvailableCpu) / float64(env.maxCPU),
+		MemoryScore: float64(n.AvailableMemory) / float64(env.maxMem),
+		DiskScore:   float64(n.AvailableSystemDisk+n.AvailableDataDisk) / float64(env.maxDisk),
+		ImageScore:  imageScore(n),
+	}
+	d.TotalScore = d.GPUScore*w.Gpu + d.CPUScore*w.CPU + d.MemoryScore*w.Memory + d.DiskScore*w.Disk + d.ImageScore*env.imageWeight
+	return d
+}
+
+// binpackStrategy 各项资源的已用比例加权求和，尽量填满已使用的节点
+type binpackStrategy struct{}
+
+func (binpackStrategy) Name() string { return strategyBinpack }
+
+func (binpackStrategy) Score(n AvailableNode, env *scoreEnv) CandidateScoreDetail {
+	w := env.weights
+	d := CandidateScoreDetail{
+		GPUScore:    usedRatio(n.AvailableGpu, n.GpuCount),
+		CPUScore:    usedRatio(n.AvailableCpu, parseTotal(n.Cpu)),
+		MemoryScore: usedRatio(n.AvailableMemory, parseTotal(n.Memory)),
+		DiskScore:   usedRatio(n.AvailableSystemDisk+n.AvailableDataDisk, parseTotal(n.SystemDisk)+parseTotal(n.DataDisk)),
+		ImageScore:  imageScore(n),
+	}
+	d.TotalScore = d.GPUScore*w.Gpu + d.CPUScore*w.CPU + d.MemoryScore*w.Memory + d.DiskScore*w.Disk + d.ImageScore*env.imageWeight
+	return d
+}
+
+// fragmentationStrategy 在 CardMemoryUsage 上模拟本次放置：
+// 放置后剩余空闲整卡越少、部分使用卡上的零散显存越少，得分越高；CPU/内存/磁盘按 binpack 计分。
+type fragmentationStrategy struct{}
+
+func (fragmentationStrategy) Name() string { return strategyFragmentation }
+
+func (fragmentationStrategy) Score(n AvailableNode, env *scoreEnv) CandidateScoreDetail {
+	d := binpackStrategy{}.Score(n, env)
+	if env.req.GpuCount > 0 && n.GpuCount > 0 {
+		wholeFree, fragment := simulateCardPlacement(n.CardMemoryUsage, n.GpuCount, n.PerCardCapacity, env.req)
+		d.GPUScore = 1 - float64(wholeFree)/float64(n.GpuCount)
+		d.FragmentScore = 1 - fragment
+		w := env.weights
+		d.TotalScore = (d.GPUScore+d.FragmentScore)/2*w.Gpu + d.CPUScore*w.CPU + d.MemoryScore*w.Memory + d.DiskScore*w.Disk + d.ImageScore*env.imageWeight
+	}
+	return d
+}
+
+// simulateCardPlacement 按 pickGpuDevices 的选卡规则放置请求，返回放置后的空闲整卡数，
+// 以及部分使用卡上剩余零散显存占节点总显存的比例
+func simulateCardPlacement(usage []int64, totalCards int64, perCardCapacity int64, req schedulingRequest) (int64, float64) {
+	cards := make([]cardUsage, totalCards)
+	for i := range cards {
+		if i < len(usage) && usage[i] > 0 {
+			cards[i] = cardUsage{MemoryGb: usage[i], Holders: 1}
+		}
+	}
+	memoryPerCard := perCardCapacity
+	if req.Split && req.MemoryPerCard > 0 {
+		memoryPerCard = req.MemoryPerCard
+	}
+	if picked, err := pickGpuDevices(cards, req.GpuCount, perCardCapacity, memoryPerCard, req.Split); err == nil {
+		for _, idx := range picked {
+			cards[idx].MemoryGb += memoryPerCard
+			cards[idx].Holders++
+		}
+	}
+	wholeFree := int64(0)
+	fragmented := int64(0)
+	for _, c := range cards {
+		if c.Holders == 0 {
+			wholeFree++
+			continue
+		}
+		if perCardCapacity > 0 && c.MemoryGb < perCardCapacity {
+			fragmented += perCardCapacity - c.MemoryGb
+		}
+	}
+	if perCardCapacity <= 0 || totalCards <= 0 {
+		return wholeFree, 0
+	}
+	return wholeFree, float64(fragmented) / float64(perCardCapacity*totalCards)
+}
+
+var schedulingStrategies = map[string]SchedulingStrategy{
+	strategySpread:        spreadStrategy{},
+	strategyBinpack:       binpackStrategy{},
+	strategyFragmentation: fragmentationStrategy{},
+}
+
+// resolveStrategy 将 chooseStrategy 选出的策略版本映射为调度算法
+// 优先使用 pcdn.strategies 中的映射，其次版本名本身即算法名，否则沿用 spread。
+func resolveStrategy(version string) SchedulingStrategy {
+	name := strings.ToLower(strings.TrimSpace(version))
+	for k, v := range global.GVA_CONFIG.PCDN.Strategies {
+		if strings.EqualFold(k, version) {
+			name = strings.ToLower(strings.TrimSpace(v))
+			break
+		}
+	}
+	if s, ok := schedulingStrategies[name]; ok {
+		return s
+	}
+	return spreadStrategy{}
+}
@@ -0,0 +1,69 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+func TestSchedulingStrategies(t *testing.T) {
+	// 节点1：8卡全空；节点2：8卡已用5张整卡
+	empty := AvailableNode{ID: 1, GpuCount: 8, AvailableGpu: 8, Cpu: "64", AvailableCpu: 64, Memory: "512", AvailableMemory: 512,
+		PerCardCapacity: 80, CardMemoryUsage: make([]int64, 8)}
+	busy := AvailableNode{ID: 2, GpuCount: 8, AvailableGpu: 3, Cpu: "64", AvailableCpu: 24, Memory: "512", AvailableMemory: 192,
+		PerCardCapacity: 80, CardMemoryUsage: []int64{80, 80, 80, 80, 80, 0, 0, 0}}
+	// 节点3：显存切分场景下两张卡各用了一半
+	halfUsed := AvailableNode{ID: 3, GpuCount: 8, AvailableGpu: 8, Cpu: "64", AvailableCpu: 64, Memory: "512", AvailableMemory: 512,
+		PerCardCapacity: 80, CardMemoryUsage: []int64{40, 40, 0, 0, 0, 0, 0, 0}}
+
+	wholeCard := schedulingRequest{GpuCount: 2, MemoryPerCard: 80}
+	splitCard := schedulingRequest{GpuCount: 1, MemoryPerCard: 40, Split: true}
+
+	tests := []struct {
+		name    string
+		version string
+		nodes   []AvailableNode
+		req     schedulingRequest
+		wantTop uint
+	}{
+		{name: "spread优先空闲节点", version: strategySpread, nodes: []AvailableNode{empty, busy}, req: wholeCard, wantTop: 1},
+		{name: "binpack优先已使用节点", version: strategyBinpack, nodes: []AvailableNode{empty, busy}, req: wholeCard, wantTop: 2},
+		{name: "碎片感知保留整机", version: strategyFragmentation, nodes: []AvailableNode{empty, busy}, req: wholeCard, wantTop: 2},
+		{name: "碎片感知优先填满半卡", version: strategyFragmentation, nodes: []AvailableNode{empty, halfUsed}, req: splitCard, wantTop: 3},
+		{name: "未知版本沿用spread", version: "v9", nodes: []AvailableNode{busy, empty}, req: wholeCard, wantTop: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, details := scoreAndSortNodes(tt.nodes, tt.req, tt.version, releaseStable, "test", false)
+			if len(details) != len(tt.nodes) {
+				t.Fatalf("scoreAndSortNodes() details = %d, want %d", len(details), len(tt.nodes))
+			}
+			if sorted[0].ID != tt.wantTop {
+				t.Errorf("scoreAndSortNodes() top = %d, want %d, details %+v", sorted[0].ID, tt.wantTop, details)
+			}
+		})
+	}
+}
+
+func TestScoreEnvImageWeight(t *testing.T) {
+	defer func() { global.GVA_CONFIG.PCDN.ScoreWeight = config.ScoreWeight{} }()
+	zero, half := 0.0, 0.5
+	tests := []struct {
+		name   string
+		weight *float64
+		want   float64
+	}{
+		{"未配置使用默认值", nil, defaultImageWeight},
+		{"配置为0关闭镜像加分", &zero, 0},
+		{"使用配置值", &half, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.GVA_CONFIG.PCDN.ScoreWeight = config.ScoreWeight{Image: tt.weight}
+			if got := newScoreEnv(nil, schedulingRequest{}).imageWeight; got != tt.want {
+				t.Fatalf("imageWeight = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
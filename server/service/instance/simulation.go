@@ -0,0 +1,257 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+)
+
+// simulateMaxInstances 单次模拟的实例总数上限
+const simulateMaxInstances = 1000
+
+// SimulationPlacement 模拟中单个实例的落位结果
+type SimulationPlacement struct {
+	Seq             int     `json:"seq"` // 在批次中的顺序，从1开始
+	SpecId          int64   `json:"specId"`
+	SpecName        string  `json:"specName"`
+	Region          string  `json:"region"`
+	NodeId          uint    `json:"nodeId"`
+	NodeName        string  `json:"nodeName"`
+	Score           float64 `json:"score"`
+	Devices         []int   `json:"devices"` // 将占用的GPU设备索引
+	StrategyVersion string  `json:"strategyVersion"`
+	Strategy        string  `json:"strategy"`
+	RuleMatched     string  `json:"ruleMatched"`
+	Failed          bool    `json:"failed"`
+	Reason          string  `json:"reason"`
+}
+
+// SimulationNodeUsage 模拟结束后节点的资源占用
+type SimulationNodeUsage struct {
+	NodeId          uint      `json:"nodeId"`
+	NodeName        string    `json:"nodeName"`
+	Region          string    `json:"region"`
+	GpuTotal        int64     `json:"gpuTotal"`
+	GpuUsed         int64     `json:"gpuUsed"`
+	CpuTotal        int64     `json:"cpuTotal"`
+	CpuUsed         int64     `json:"cpuUsed"`
+	MemoryTotal     int64     `json:"memoryTotal"`
+	MemoryUsed      int64     `json:"memoryUsed"`
+	PerCardCapacity int64     `json:"perCardCapacity"`
+	CardMemoryUsage []int64   `json:"cardMemoryUsage"` // 每张卡已分配的显存(GB)
+	CardUtilization []float64 `json:"cardUtilization"` // 每张卡显存分配比例(0-1)
+	PlacedCount     int       `json:"placedCount"`     // 本次模拟放到该节点的实例数
+}
+
+// SimulationResult 调度模拟结果
+type SimulationResult struct {
+	Placed     int                   `json:"placed"`
+	Failed     int                   `json:"failed"`
+	Placements []SimulationPlacement `json:"placements"`
+	Nodes      []SimulationNodeUsage `json:"nodes"`
+}
+
+// SimulateSchedule 基于当前节点与实例状态按顺序重放一批假设请求
+// 使用与 GetAvailableNodes 相同的资源核算与策略选择，只在内存中累计占用，不写库；
+// 熔断状态只读取不推进，也不占用半开探测名额。
+func (instanceService *InstanceService) SimulateSchedule(ctx context.Context, req instanceReq.SimulateScheduleReq) (res SimulationResult, err error) {
+	total := 0
+	for i := range req.Items {
+		if req.Items[i].Count <= 0 {
+			req.Items[i].Count = 1
+		}
+		total += req.Items[i].Count
+	}
+	if total == 0 {
+		return res, errors.New("模拟请求不能为空")
+	}
+	if total > simulateMaxInstances {
+		return res, fmt.Errorf("单次模拟的实例数不能超过%d", simulateMaxInstances)
+	}
+
+	var allNodes []computenode.ComputeNode
+	if err = global.GVA_DB.Where("is_on_shelf = ?", true).Find(&allNodes).Error; err != nil {
+		return res, fmt.Errorf("获取算力节点失败: %v", err)
+	}
+	allocator, err := newResourceAllocator(global.GVA_DB, allNodes, 0)
+	if err != nil {
+		return res, fmt.Errorf("统计节点资源失败: %v", err)
+	}
+
+	in := simulationInput{
+		specs:         make(map[int64]*product.ProductSpec),
+		cachedByImage: make(map[uint]map[uint]bool),
+		images:        make(map[uint]*imageregistry.ImageRegistry),
+		breakerOn:     pcdnSchedulerRuntime.peekCircuitOpened(),
+		stableNodes:   pcdnSchedulerRuntime.stableFallbackNodes(),
+	}
+	for _, item := range req.Items {
+		if _, ok := in.specs[item.SpecId]; !ok {
+			spec := &product.ProductSpec{}
+			if err = global.GVA_DB.Where("id = ?", item.SpecId).First(spec).Error; err != nil {
+				return res, fmt.Errorf("获取产品规格(%d)失败: %v", item.SpecId, err)
+			}
+			in.specs[item.SpecId] = spec
+		}
+		if _, ok := in.cachedByImage[item.ImageId]; !ok {
+			in.cachedByImage[item.ImageId] = loadCachedNodes(item.ImageId)
+			in.images[item.ImageId] = loadAffinityImage(item.ImageId)
+		}
+	}
+	return runSimulation(req, allNodes, allocator, in), nil
+}
+
+// simulationInput 模拟所需的规格、镜像缓存与熔断状态，由 SimulateSchedule 预先加载
+type simulationInput struct {
+	specs         map[int64]*product.ProductSpec
+	cachedByImage map[uint]map[uint]bool
+	images        map[uint]*imageregistry.ImageRegistry
+	breakerOn     bool
+	stableNodes   []AvailableNode // 稳定策略快照，回切稳定策略且无候选节点时使用
+}
+
+// runSimulation 按顺序放置模拟请求，占用只累计在 allocator 中
+func runSimulation(req instanceReq.SimulateScheduleReq, allNodes []computenode.ComputeNode, allocator *resourceAllocator, in simulationInput) (res SimulationResult) {
+	placedCount := make(map[uint]int)
+	seq := 0
+	for _, item := range req.Items {
+		spec := in.specs[item.SpecId]
+		cached := in.cachedByImage[item.ImageId]
+		image := in.images[item.ImageId]
+
+		version, state, rule, forceStable := req.Version, releaseStable, "simulate_override", false
+		if version == "" {
+			version, state, rule, forceStable = previewStrategy(SchedulingRequestMeta{Region: item.Region}, in.breakerOn)
+		}
+		strategyName := resolveStrategy(version).Name()
+
+		for k := 0; k < item.Count; k++ {
+			seq++
+			p := SimulationPlacement{Seq: seq, SpecId: item.SpecId, Region: item.Region, StrategyVersion: version, Strategy: strategyName, RuleMatched: rule}
+			if spec.Name != nil {
+				p.SpecName = *spec.Name
+			}
+
+			candidates := make([]AvailableNode, 0)
+			for i := range allNodes {
+				if item.Region != "" && !strings.EqualFold(safeString(allNodes[i].Region), item.Region) {
+					continue
+				}
+				if affinityMismatch(&allNodes[i], spec, image, false) != "" {
+					continue
+				}
+				n, fits := allocator.fit(&allNodes[i], spec)
+				if !fits {
+					continue
+				}
+				n.ImageCached = cached[allNodes[i].ID]
+				candidates = append(candidates, n)
+			}
+
+			var top AvailableNode
+			if len(candidates) > 0 {
+				sorted, details := scoreAndSortNodes(candidates, newSchedulingRequest(spec), version, state, rule, in.breakerOn)
+				top = sorted[0]
+				for _, d := range details {
+					if d.NodeID == top.ID {
+						p.Score = d.TotalScore
+						break
+					}
+				}
+			} else if fallback, ok := simulateStableFallback(allocator, spec, in.stableNodes, forceStable); ok {
+				// 与 GetAvailableNodes 一致：回切稳定策略且无候选节点时使用稳定策略快照
+				top = fallback
+				p.RuleMatched = rule + "_snapshot"
+			} else {
+				p.Failed = true
+				p.Reason = "没有满足规格的可用节点"
+				res.Failed++
+				res.Placements = append(res.Placements, p)
+				continue
+			}
+
+			node := allocator.nodeInfoMap[int64(top.ID)]
+			p.NodeId = top.ID
+			p.NodeName = top.Name
+			p.Devices = allocator.place(node, spec)
+			placedCount[top.ID]++
+			res.Placed++
+			res.Placements = append(res.Placements, p)
+		}
+	}
+
+	for i := range allNodes {
+		res.Nodes = append(res.Nodes, simulationNodeUsage(&allNodes[i], allocator.usedResources[int64(allNodes[i].ID)], placedCount[allNodes[i].ID]))
+	}
+	return res
+}
+
+// simulateStableFallback 在稳定策略快照中按快照顺序选出模拟占用后仍能容纳规格的第一个节点
+func simulateStableFallback(allocator *resourceAllocator, spec *product.ProductSpec, stableNodes []AvailableNode, forceStable bool) (AvailableNode, bool) {
+	if !forceStable {
+		return AvailableNode{}, false
+	}
+	for _, sn := range stableNodes {
+		node, ok := allocator.nodeInfoMap[int64(sn.ID)]
+		if !ok {
+			continue
+		}
+		if n, fits := allocator.fit(node, spec); fits {
+			return n, true
+		}
+	}
+	return AvailableNode{}, false
+}
+
+// loadCachedNodes 已缓存指定镜像的节点
+func loadCachedNodes(imageID uint) map[uint]bool {
+	cached := make(map[uint]bool)
+	if imageID == 0 {
+		return cached
+	}
+	var nodeIds []uint
+	if err := global.GVA_DB.Model(&computenode.ComputeNodeImage{}).
+		Where("image_id = ? AND status = ?", imageID, computenode.NodeImagePresent).
+		Pluck("node_id", &nodeIds).Error; err != nil {
+		global.GVA_LOG.Warn("读取节点镜像清单失败", zap.Error(err))
+	}
+	for _, id := range nodeIds {
+		cached[id] = true
+	}
+	return cached
+}
+
+func simulationNodeUsage(node *computenode.ComputeNode, used nodeUsage, placed int) SimulationNodeUsage {
+	u := SimulationNodeUsage{
+		NodeId:          node.ID,
+		NodeName:        safeString(node.Name),
+		Region:          safeString(node.Region),
+		GpuTotal:        specInt(node.GpuCount),
+		GpuUsed:         used.GpuUsed,
+		CpuTotal:        specInt(node.Cpu),
+		CpuUsed:         used.CpuUsed,
+		MemoryTotal:     specInt(node.Memory),
+		MemoryUsed:      used.MemUsed,
+		PerCardCapacity: specInt(node.MemoryCapacity),
+		PlacedCount:     placed,
+	}
+	if u.GpuTotal > 0 {
+		u.CardMemoryUsage = make([]int64, u.GpuTotal)
+		copy(u.CardMemoryUsage, used.CardMemoryUsage)
+		u.CardUtilization = make([]float64, u.GpuTotal)
+		for i, m := range u.CardMemoryUsage {
+			if u.PerCardCapacity > 0 {
+				u.CardUtilization[i] = roundToTwoDecimals(float64(m) / float64(u.PerCardCapacity))
+			}
+		}
+	}
+	return u
+}
@@ -0,0 +1,121 @@
+package instance
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"go.uber.org/zap"
+)
+
+func TestResourceAllocatorPlace(t *testing.T) {
+	i64 := func(v int64) *int64 { return &v }
+	yes := true
+	node := computenode.ComputeNode{GpuCount: i64(4), MemoryCapacity: i64(80), Cpu: i64(64), Memory: i64(256)}
+	node.ID = 1
+	a := &resourceAllocator{
+		nodeInfoMap:   map[int64]*computenode.ComputeNode{1: &node},
+		usedResources: map[int64]nodeUsage{1: {GpuUsed: 1, CardMemoryUsage: []int64{80, 0, 0, 0}}},
+	}
+	whole := &product.ProductSpec{GpuCount: i64(2), MemoryCapacity: i64(160), CpuCores: i64(8), MemoryGb: i64(32)}
+	if got := a.place(&node, whole); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("整卡放置 devices = %v, want [1 2]", got)
+	}
+	// 切分规格优先占用已部分使用的卡
+	split := &product.ProductSpec{GpuCount: i64(1), MemoryCapacity: i64(20), SupportMemorySplit: &yes, CpuCores: i64(4), MemoryGb: i64(16)}
+	a.usedResources[1] = nodeUsage{GpuUsed: 1, CardMemoryUsage: []int64{0, 40, 0, 0}}
+	if got := a.place(&node, split); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("切分放置 devices = %v, want [1]", got)
+	}
+	used := a.usedResources[1]
+	if used.CpuUsed != 4 || used.MemUsed != 16 || used.CardMemoryUsage[1] != 60 || used.MemoryCapacityUsed != 20 {
+		t.Fatalf("usage = %+v", used)
+	}
+}
+
+func TestRunSimulation(t *testing.T) {
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.PCDN = config.PCDN{Version: "binpack", StableVersion: "spread", ReleaseState: releaseCanary, Enabled: true,
+		Canary: config.Canary{UserHashPercentage: 100}}
+	defer func() { global.GVA_CONFIG.PCDN = config.PCDN{} }()
+
+	i64 := func(v int64) *int64 { return &v }
+	str := func(s string) *string { return &s }
+	newNode := func(id uint, region string) computenode.ComputeNode {
+		n := computenode.ComputeNode{Name: str(region), Region: str(region), GpuCount: i64(2), MemoryCapacity: i64(80), Cpu: i64(32), Memory: i64(128)}
+		n.ID = id
+		return n
+	}
+	spec := &product.ProductSpec{Name: str("1卡"), GpuCount: i64(1), MemoryCapacity: i64(80), CpuCores: i64(8), MemoryGb: i64(32)}
+	in := func(breakerOn bool, stable []AvailableNode) simulationInput {
+		return simulationInput{
+			specs:         map[int64]*product.ProductSpec{1: spec},
+			cachedByImage: map[uint]map[uint]bool{0: {}},
+			images:        map[uint]*imageregistry.ImageRegistry{},
+			breakerOn:     breakerOn,
+			stableNodes:   stable,
+		}
+	}
+	run := func(req instanceReq.SimulateScheduleReq, simIn simulationInput) SimulationResult {
+		nodes := []computenode.ComputeNode{newNode(1, "east"), newNode(2, "east"), newNode(3, "west")}
+		a := &resourceAllocator{nodeInfoMap: map[int64]*computenode.ComputeNode{}, usedResources: map[int64]nodeUsage{}}
+		for i := range nodes {
+			a.nodeInfoMap[int64(nodes[i].ID)] = &nodes[i]
+		}
+		return runSimulation(req, nodes, a, simIn)
+	}
+
+	t.Run("灰度策略装箱并累计占用", func(t *testing.T) {
+		res := run(instanceReq.SimulateScheduleReq{Items: []instanceReq.SimulateScheduleItem{{SpecId: 1, Count: 5, Region: "east"}}}, in(false, nil))
+		if res.Placed != 4 || res.Failed != 1 {
+			t.Fatalf("placed = %d failed = %d, want 4/1", res.Placed, res.Failed)
+		}
+		// binpack 先填满一个节点
+		if res.Placements[0].NodeId != res.Placements[1].NodeId || res.Placements[0].RuleMatched != "canary_hit" {
+			t.Fatalf("placements = %+v", res.Placements[:2])
+		}
+	})
+
+	t.Run("熔断时回切稳定策略并使用快照", func(t *testing.T) {
+		pcdnSchedulerRuntime.mu.Lock()
+		before := pcdnSchedulerRuntime.probeSeq
+		pcdnSchedulerRuntime.mu.Unlock()
+
+		stable := []AvailableNode{{ID: 3, Name: "west"}}
+		res := run(instanceReq.SimulateScheduleReq{Items: []instanceReq.SimulateScheduleItem{{SpecId: 1, Count: 7, Region: "east"}}}, in(true, stable))
+		if res.Placed != 6 || res.Failed != 1 {
+			t.Fatalf("placed = %d failed = %d, want 6/1", res.Placed, res.Failed)
+		}
+		first, fifth := res.Placements[0], res.Placements[4]
+		if first.StrategyVersion != "spread" || first.RuleMatched != "circuit_breaker_fallback" {
+			t.Fatalf("first = %+v", first)
+		}
+		if fifth.NodeId != 3 || fifth.RuleMatched != "circuit_breaker_fallback_snapshot" {
+			t.Fatalf("fifth = %+v", fifth)
+		}
+
+		pcdnSchedulerRuntime.mu.Lock()
+		after := pcdnSchedulerRuntime.probeSeq
+		pcdnSchedulerRuntime.mu.Unlock()
+		if after != before {
+			t.Fatalf("模拟不应占用探测名额: probeSeq %d -> %d", before, after)
+		}
+	})
+}
+
+func TestSimulateScheduleValidate(t *testing.T) {
+	svc := &InstanceService{}
+	if _, err := svc.SimulateSchedule(context.Background(), instanceReq.SimulateScheduleReq{}); err == nil {
+		t.Fatal("空请求应报错")
+	}
+	req := instanceReq.SimulateScheduleReq{Items: []instanceReq.SimulateScheduleItem{{SpecId: 1, Count: simulateMaxInstances + 1}}}
+	if _, err := svc.SimulateSchedule(context.Background(), req); err == nil {
+		t.Fatal("超过上限应报错")
+	}
+}
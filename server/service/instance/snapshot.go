@@ -0,0 +1,180 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/imageregistry"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	imageregistrySvc "github.com/flipped-aurora/gin-vue-admin/server/service/imageregistry"
+	"go.uber.org/zap"
+)
+
+// localSnapshotPrefix 未推送的快照镜像仓库名前缀，这类镜像只存在于保存时所在的节点
+const localSnapshotPrefix = "snapshot/"
+
+// snapshotRepoName 生成快照镜像的仓库名，只保留Docker仓库名允许的字符
+func snapshotRepoName(userID uint, name string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, name)
+	for strings.Contains(clean, "--") {
+		clean = strings.ReplaceAll(clean, "--", "-")
+	}
+	clean = strings.Trim(clean, "-_.")
+	if clean == "" {
+		clean = "image"
+	}
+	return fmt.Sprintf("u%d-%s", userID, clean)
+}
+
+// checkSnapshotConfig 校验保存镜像所需的配置：推送需要快照仓库，带密码的仓库凭据需要加密密钥才能登记
+func checkSnapshotConfig(push bool) error {
+	cfg := global.GVA_CONFIG.Registry
+	if !push {
+		return nil
+	}
+	if cfg.SnapshotRepository == "" {
+		return errors.New("未配置快照仓库，无法推送")
+	}
+	if cfg.SnapshotUsername != "" && cfg.SnapshotPassword != "" {
+		return imageregistrySvc.CheckCredentialKey()
+	}
+	return nil
+}
+
+// SaveInstanceImage 将实例容器提交为新镜像，可选推送到快照仓库，并登记为实例所属用户的私有镜像
+func (instanceService *InstanceService) SaveInstanceImage(ctx context.Context, req instanceReq.SaveInstanceImageReq, userID uint, isAdmin bool) (img imageregistry.ImageRegistry, err error) {
+	inst, node, err := instanceService.getInstanceAndNode(strconv.FormatUint(uint64(req.ID), 10))
+	if err != nil {
+		return img, err
+	}
+	if !isAdmin && (inst.UserId == nil || *inst.UserId != int64(userID)) {
+		return img, errors.New("无权操作此实例")
+	}
+	if inst.ContainerId == nil || *inst.ContainerId == "" {
+		return img, errors.New("容器ID为空")
+	}
+	cfg := global.GVA_CONFIG.Registry
+	// 提交镜像前校验，避免提交或推送后登记失败留下无主镜像
+	if err = checkSnapshotConfig(req.Push); err != nil {
+		return img, err
+	}
+
+	ownerID := userID
+	if inst.UserId != nil {
+		ownerID = uint(*inst.UserId)
+	}
+	repo := localSnapshotPrefix + snapshotRepoName(ownerID, req.Name)
+	if req.Push {
+		repo = strings.TrimSuffix(cfg.SnapshotRepository, "/") + "/" + snapshotRepoName(ownerID, req.Name)
+	}
+	imageRef := fmt.Sprintf("%s:%s", repo, time.Now().Format("20060102150405"))
+
+	// 提交与推送可能持续较久，不随HTTP请求取消
+	timeoutMin := global.GVA_CONFIG.Provision.PullTimeoutMin
+	if timeoutMin <= 0 {
+		timeoutMin = 60
+	}
+	opCtx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMin)*time.Minute)
+	defer cancel()
+
+	dockerImageID, err := dockerService.CommitContainer(opCtx, node, *inst.ContainerId, imageRef, fmt.Sprintf("saved from instance %d", inst.ID))
+	if err != nil {
+		return img, err
+	}
+	var username, password string
+	if req.Push {
+		username, password = cfg.SnapshotUsername, cfg.SnapshotPassword
+		if err = dockerService.ImagePush(opCtx, node, imageRef, RegistryAuthFor(imageRef, username, password, "")); err != nil {
+			return img, err
+		}
+	}
+
+	// 登记为私有镜像，继承源镜像的显存切分能力
+	owner := int64(ownerID)
+	private := true
+	onShelf := true
+	supportSplit := false
+	source := "snapshot"
+	if inst.ImageId != nil {
+		var srcImage imageregistry.ImageRegistry
+		if global.GVA_DB.Unscoped().Where("id = ?", *inst.ImageId).First(&srcImage).Error == nil && srcImage.SupportMemorySplit != nil {
+			supportSplit = *srcImage.SupportMemorySplit
+		}
+	}
+	name := req.Name
+	img = imageregistry.ImageRegistry{
+		Name:               &name,
+		Address:            &imageRef,
+		Description:        &req.Description,
+		Source:             &source,
+		IsOnShelf:          &onShelf,
+		SupportMemorySplit: &supportSplit,
+		OwnerId:            &owner,
+		IsPrivate:          &private,
+	}
+	if username != "" {
+		img.Username = &username
+		img.Password = &password
+	}
+	if err = (&imageregistrySvc.ImageRegistryService{}).CreateImageRegistry(ctx, &img); err != nil {
+		return img, fmt.Errorf("登记镜像失败: %v", err)
+	}
+
+	// 未推送的镜像只存在于当前节点，写入节点镜像清单以便调度优先选择该节点
+	now := time.Now()
+	if err = global.GVA_DB.Create(&computenode.ComputeNodeImage{
+		NodeId:        node.ID,
+		ImageId:       img.ID,
+		ImageRef:      imageRef,
+		DockerImageId: dockerImageID,
+		Status:        computenode.NodeImagePresent,
+		Progress:      100,
+		LastSeenAt:    &now,
+	}).Error; err != nil {
+		global.GVA_LOG.Warn("写入节点镜像清单失败", zap.Uint("镜像ID", img.ID), zap.Error(err))
+	}
+	global.GVA_LOG.Info("实例已保存为镜像", zap.Uint("实例ID", inst.ID), zap.String("image", imageRef), zap.Bool("push", req.Push))
+	return img, nil
+}
+
+// checkSnapshotNode 未推送的快照镜像只能在已有该镜像的节点上创建实例
+func checkSnapshotNode(image *imageregistry.ImageRegistry, nodeID uint) error {
+	if image.Address == nil || !strings.HasPrefix(*image.Address, localSnapshotPrefix) {
+		return nil
+	}
+	var count int64
+	if err := global.GVA_DB.Model(&computenode.ComputeNodeImage{}).
+		Where("node_id = ? AND image_id = ? AND status = ?", nodeID, image.ID, computenode.NodeImagePresent).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("该镜像未推送到仓库，只能在保存镜像时所在的节点上创建实例")
+	}
+	return nil
+}
+
+// checkImageAccess 私有镜像只允许所属用户使用
+func checkImageAccess(image *imageregistry.ImageRegistry, userID *int64) error {
+	if image.IsPrivate == nil || !*image.IsPrivate {
+		return nil
+	}
+	if image.OwnerId == nil || userID == nil || *image.OwnerId != *userID {
+		return errors.New("无权使用该私有镜像")
+	}
+	return nil
+}
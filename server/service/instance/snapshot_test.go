@@ -0,0 +1,51 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+func TestSnapshotRepoName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "PyTorch Env", want: "u7-pytorch-env"},
+		{name: "my_env.v2", want: "u7-my_env.v2"},
+		{name: "训练环境", want: "u7-image"},
+		{name: "--A  B--", want: "u7-a-b"},
+	}
+	for _, tt := range tests {
+		if got := snapshotRepoName(7, tt.name); got != tt.want {
+			t.Errorf("snapshotRepoName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSnapshotConfig(t *testing.T) {
+	defer func() { global.GVA_CONFIG.Registry = config.Registry{} }()
+	repo := "harbor.example.com/snapshots"
+	tests := []struct {
+		name    string
+		cfg     config.Registry
+		push    bool
+		wantErr bool
+	}{
+		{"只保存在节点", config.Registry{}, false, false},
+		{"推送但未配置仓库", config.Registry{}, true, true},
+		{"推送无凭据", config.Registry{SnapshotRepository: repo}, true, false},
+		{"推送带密码但未配置密钥", config.Registry{SnapshotRepository: repo, SnapshotUsername: "robot", SnapshotPassword: "secret"}, true, true},
+		{"推送带密码且已配置密钥", config.Registry{SnapshotRepository: repo, SnapshotUsername: "robot", SnapshotPassword: "secret", EncryptionKey: "k"}, true, false},
+		{"推送只有用户名", config.Registry{SnapshotRepository: repo, SnapshotUsername: "robot"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.GVA_CONFIG.Registry = tt.cfg
+			if err := checkSnapshotConfig(tt.push); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	instanceReq "github.com/flipped-aurora/gin-vue-admin/server/model/instance/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 计量流水事件
+const (
+	usageEventProvisioned  = "provisioned"
+	usageEventStart        = "start"
+	usageEventStop         = "stop"
+	usageEventRestart      = "restart"
+	usageEventDelete       = "delete"
+	usageEventStatusChange = "status_change"
+	usageEventResize       = "resize"
+	usageEventMigrate      = "migrate"
+)
+
+const usageStatusRunning = "running"
+
+// UsageReportRow 月度用量报表行
+type UsageReportRow struct {
+	GroupId       int64   `json:"groupId"`
+	GroupName     string  `json:"groupName"`
+	InstanceCount int     `json:"instanceCount"`
+	RunningHours  float64 `json:"runningHours"`
+	Cost          float64 `json:"cost"`
+}
+
+// usageInterval 单个实例的一段运行区间
+type usageInterval struct {
+	InstanceId   uint
+	UserId       int64
+	SpecId       int64
+	Start        time.Time
+	End          time.Time
+	PricePerHour float64
+}
+
+// recordUsageEvent 追加一条计量流水，失败只记录日志，不影响状态变更本身
+func recordUsageEvent(db *gorm.DB, inst *instanceModel.Instance, event, fromStatus, toStatus string) {
+	rec := instanceModel.UsageRecord{
+		InstanceId: inst.ID,
+		Event:      event,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		OccurredAt: time.Now(),
+	}
+	if inst.UserId != nil {
+		rec.UserId = *inst.UserId
+	}
+	if inst.NodeId != nil {
+		rec.NodeId = *inst.NodeId
+	}
+	if inst.SpecId != nil {
+		rec.SpecId = *inst.SpecId
+		var spec product.ProductSpec
+		if err := db.Unscoped().Select("price_per_hour").Where("id = ?", *inst.SpecId).First(&spec).Error; err == nil && spec.PricePerHour != nil {
+			rec.PricePerHour = *spec.PricePerHour
+		}
+	}
+	if err := db.Create(&rec).Error; err != nil {
+		global.GVA_LOG.Error("写入计量流水失败", zap.Uint("实例ID", inst.ID), zap.String("event", event), zap.Error(err))
+	}
+}
+
+// buildUsageIntervals 根据计量流水计算 [from, to) 内的运行区间
+// records 需按实例分组且组内按发生时间升序；月初之前的最后一条流水用于确定月初时的运行状态。
+// 连续的 running 流水（如重启）不会切断区间，价格以区间开始时的快照为准；变更规格会按新价格开启新区间。
+func buildUsageIntervals(records []instanceModel.UsageRecord, from, to time.Time) []usageInterval {
+	var intervals []usageInterval
+	var open *usageInterval
+	closeOpen := func(at time.Time) {
+		if open == nil {
+			return
+		}
+		if at.After(to) {
+			at = to
+		}
+		if at.After(open.Start) {
+			open.End = at
+			intervals = append(intervals, *open)
+		}
+		open = nil
+	}
+	for i, r := range records {
+		if i > 0 && records[i-1].InstanceId != r.InstanceId {
+			closeOpen(to)
+		}
+		if r.ToStatus == usageStatusRunning {
+			if open != nil && r.Event == usageEventResize {
+				closeOpen(r.OccurredAt)
+			}
+			if open == nil {
+				start := r.OccurredAt
+				if start.Before(from) {
+					start = from
+				}
+				open = &usageInterval{InstanceId: r.InstanceId, UserId: r.UserId, SpecId: r.SpecId, Start: start, PricePerHour: r.PricePerHour}
+			}
+			continue
+		}
+		closeOpen(r.OccurredAt)
+	}
+	closeOpen(to)
+	return intervals
+}
+
+// parseUsageMonth 解析统计月份，返回 [月初, 下月初)
+func parseUsageMonth(month string) (time.Time, time.Time, error) {
+	if month == "" {
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("月份格式错误，应为 2006-01: %v", err)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// loadUsageRecords 读取统计区间内的流水及区间开始前每个实例的最后一条流水
+func loadUsageRecords(from, to time.Time, userID uint, isAdmin bool) ([]instanceModel.UsageRecord, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if !isAdmin {
+			return db.Where("user_id = ?", int64(userID))
+		}
+		return db
+	}
+	var before []instanceModel.UsageRecord
+	latest := scope(global.GVA_DB.Model(&instanceModel.UsageRecord{})).
+		Select("MAX(id)").Where("occurred_at < ?", from).Group("instance_id")
+	if err := global.GVA_DB.Where("id IN (?)", latest).Find(&before).Error; err != nil {
+		return nil, err
+	}
+	var within []instanceModel.UsageRecord
+	if err := scope(global.GVA_DB).Where("occurred_at >= ? AND occurred_at < ?", from, to).Find(&within).Error; err != nil {
+		return nil, err
+	}
+	records := append(before, within...)
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].InstanceId != records[j].InstanceId {
+			return records[i].InstanceId < records[j].InstanceId
+		}
+		if !records[i].OccurredAt.Equal(records[j].OccurredAt) {
+			return records[i].OccurredAt.Before(records[j].OccurredAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+// GetUsageReport 按用户或规格汇总月度运行时长与费用；普通用户只统计自己的实例
+func (instanceService *InstanceService) GetUsageReport(ctx context.Context, req instanceReq.UsageReportReq, userID uint, isAdmin bool) (rows []UsageReportRow, err error) {
+	from, to, err := parseUsageMonth(req.Month)
+	if err != nil {
+		return nil, err
+	}
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+	groupBySpec := req.GroupBy == "spec"
+
+	records, err := loadUsageRecords(from, to, userID, isAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("读取计量流水失败: %v", err)
+	}
+
+	byGroup := make(map[int64]*UsageReportRow)
+	instances := make(map[int64]map[uint]struct{})
+	for _, iv := range buildUsageIntervals(records, from, to) {
+		key := iv.UserId
+		if groupBySpec {
+			key = iv.SpecId
+		}
+		row, ok := byGroup[key]
+		if !ok {
+			row = &UsageReportRow{GroupId: key}
+			byGroup[key] = row
+			instances[key] = make(map[uint]struct{})
+		}
+		hours := iv.End.Sub(iv.Start).Hours()
+		row.RunningHours += hours
+		row.Cost += hours * iv.PricePerHour
+		instances[key][iv.InstanceId] = struct{}{}
+	}
+
+	ids := make([]int64, 0, len(byGroup))
+	for key, row := range byGroup {
+		row.InstanceCount = len(instances[key])
+		row.RunningHours = roundToTwoDecimals(row.RunningHours)
+		row.Cost = roundToTwoDecimals(row.Cost)
+		ids = append(ids, key)
+	}
+	names := make(map[int64]string)
+	if groupBySpec {
+		var specs []product.ProductSpec
+		global.GVA_DB.Unscoped().Where("id IN ?", ids).Find(&specs)
+		for _, s := range specs {
+			if s.Name != nil {
+				names[int64(s.ID)] = *s.Name
+			}
+		}
+	} else {
+		var users []system.SysUser
+		global.GVA_DB.Unscoped().Where("id IN ?", ids).Find(&users)
+		for _, u := range users {
+			names[int64(u.ID)] = u.NickName + "(" + u.Username + ")"
+		}
+	}
+
+	rows = make([]UsageReportRow, 0, len(byGroup))
+	for _, row := range byGroup {
+		row.GroupName = names[row.GroupId]
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Cost != rows[j].Cost {
+			return rows[i].Cost > rows[j].Cost
+		}
+		return rows[i].GroupId < rows[j].GroupId
+	})
+	return rows, nil
+}
+
+// ExportUsageReport 导出月度用量报表为Excel
+func (instanceService *InstanceService) ExportUsageReport(ctx context.Context, req instanceReq.UsageReportReq, userID uint, isAdmin bool) (file *bytes.Buffer, name string, err error) {
+	rows, err := instanceService.GetUsageReport(ctx, req, userID, isAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	from, _, _ := parseUsageMonth(req.Month)
+	groupTitle := "用户"
+	if req.GroupBy == "spec" {
+		groupTitle = "产品规格"
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Sheet1"
+	titles := []string{groupTitle + "ID", groupTitle, "实例数", "运行时长(小时)", "费用"}
+	for i, t := range titles {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err = f.SetCellValue(sheet, cell, t); err != nil {
+			return nil, "", err
+		}
+	}
+	for r, row := range rows {
+		values := []interface{}{row.GroupId, row.GroupName, row.InstanceCount, row.RunningHours, row.Cost}
+		for i, v := range values {
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			if err = f.SetCellValue(sheet, cell, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	file, err = f.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+	return file, fmt.Sprintf("用量报表-%s-%s", groupTitle, from.Format("2006-01")), nil
+}
+
+// BackfillUsageRecords 为启用计量前已在运行、尚无任何流水的实例补写一条起始流水
+func BackfillUsageRecords() {
+	var instances []instanceModel.Instance
+	if err := global.GVA_DB.Where("container_status = ?", usageStatusRunning).
+		Where("NOT EXISTS (SELECT 1 FROM instance_usage_record r WHERE r.instance_id = instance.id)").
+		Find(&instances).Error; err != nil {
+		global.GVA_LOG.Error("查询待补写计量流水的实例失败", zap.Error(err))
+		return
+	}
+	for i := range instances {
+		recordUsageEvent(global.GVA_DB, &instances[i], usageEventStatusChange, "", usageStatusRunning)
+	}
+	if len(instances) > 0 {
+		global.GVA_LOG.Info("已为运行中实例补写计量流水", zap.Int("count", len(instances)))
+	}
+}
+
+// ComputeUsageCost 计算用户在 [from, to) 内按计量流水产生的费用
+func ComputeUsageCost(userID uint, from, to time.Time) (float64, error) {
+	records, err := loadUsageRecords(from, to, userID, false)
+	if err != nil {
+		return 0, err
+	}
+	cost := 0.0
+	for _, iv := range buildUsageIntervals(records, from, to) {
+		cost += iv.End.Sub(iv.Start).Hours() * iv.PricePerHour
+	}
+	return cost, nil
+}
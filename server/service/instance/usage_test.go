@@ -0,0 +1,88 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+)
+
+func TestBuildUsageIntervals(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	rec := func(id uint, status string, t time.Time, price float64) instanceModel.UsageRecord {
+		return instanceModel.UsageRecord{InstanceId: id, ToStatus: status, OccurredAt: t, PricePerHour: price}
+	}
+
+	tests := []struct {
+		name      string
+		records   []instanceModel.UsageRecord
+		wantHours []float64
+		wantCost  float64
+	}{
+		{
+			name:      "启动后停止",
+			records:   []instanceModel.UsageRecord{rec(1, "running", at(2, 0), 10), rec(1, "exited", at(2, 3), 10)},
+			wantHours: []float64{3},
+			wantCost:  30,
+		},
+		{
+			name:      "月初前已在运行从月初开始计",
+			records:   []instanceModel.UsageRecord{rec(1, "running", from.Add(-48*time.Hour), 2), rec(1, "exited", at(1, 5), 2)},
+			wantHours: []float64{5},
+			wantCost:  10,
+		},
+		{
+			name: "重启不切断区间",
+			records: []instanceModel.UsageRecord{
+				rec(1, "running", at(3, 0), 1), rec(1, "running", at(3, 1), 5), rec(1, "exited", at(3, 2), 5),
+			},
+			wantHours: []float64{2},
+			wantCost:  2,
+		},
+		{
+			name: "变更规格按新价格切分区间",
+			records: []instanceModel.UsageRecord{
+				rec(1, "running", at(4, 0), 1),
+				{InstanceId: 1, Event: usageEventResize, ToStatus: "running", OccurredAt: at(4, 2), PricePerHour: 3},
+				rec(1, "exited", at(4, 3), 3),
+			},
+			wantHours: []float64{2, 1},
+			wantCost:  5,
+		},
+		{
+			name: "未停止的实例计到月末且按实例切分",
+			records: []instanceModel.UsageRecord{
+				rec(1, "running", at(31, 22), 1),
+				rec(2, "exited", at(5, 0), 1), rec(2, "running", at(5, 1), 1), rec(2, "deleted", at(5, 2), 1),
+			},
+			wantHours: []float64{2, 1},
+			wantCost:  3,
+		},
+		{
+			name:      "月初前已停止不计费",
+			records:   []instanceModel.UsageRecord{rec(1, "exited", from.Add(-time.Hour), 1)},
+			wantHours: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildUsageIntervals(tt.records, from, to)
+			if len(got) != len(tt.wantHours) {
+				t.Fatalf("buildUsageIntervals() got %d intervals, want %d", len(got), len(tt.wantHours))
+			}
+			cost := 0.0
+			for i, iv := range got {
+				hours := iv.End.Sub(iv.Start).Hours()
+				if hours != tt.wantHours[i] {
+					t.Errorf("interval %d hours = %v, want %v", i, hours, tt.wantHours[i])
+				}
+				cost += hours * iv.PricePerHour
+			}
+			if cost != tt.wantCost {
+				t.Errorf("cost = %v, want %v", cost, tt.wantCost)
+			}
+		})
+	}
+}
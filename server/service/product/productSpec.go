@@ -4,6 +4,7 @@ package product
 import (
 	"context"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/computenode"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
     productReq "github.com/flipped-aurora/gin-vue-admin/server/model/product/request"
 )
@@ -12,6 +13,9 @@ type ProductSpecService struct {}
 // CreateProductSpec 创建产品规格记录
 // Author [yourname](https://github.com/yourname)
 func (productSpecService *ProductSpecService) CreateProductSpec(ctx context.Context, productSpec *product.ProductSpec) (err error) {
+	if err = computenode.ValidateSchedulingRules(productSpec.NodeSelector, productSpec.Tolerations); err != nil {
+		return err
+	}
 	err = global.GVA_DB.Create(productSpec).Error
 	return err
 }
@@ -33,6 +37,9 @@ func (productSpecService *ProductSpecService)DeleteProductSpecByIds(ctx context.
 // UpdateProductSpec 更新产品规格记录
 // Author [yourname](https://github.com/yourname)
 func (productSpecService *ProductSpecService)UpdateProductSpec(ctx context.Context, productSpec product.ProductSpec) (err error) {
+	if err = computenode.ValidateSchedulingRules(productSpec.NodeSelector, productSpec.Tolerations); err != nil {
+		return err
+	}
 	err = global.GVA_DB.Model(&product.ProductSpec{}).Where("id = ?",productSpec.ID).Updates(&productSpec).Error
 	return err
 }
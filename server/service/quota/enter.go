@@ -0,0 +1,3 @@
+package quota
+
+type ServiceGroup struct{ ResourceQuotaService }
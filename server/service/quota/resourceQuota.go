@@ -0,0 +1,57 @@
+package quota
+
+import (
+	"context"
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	quotaModel "github.com/flipped-aurora/gin-vue-admin/server/model/quota"
+	quotaReq "github.com/flipped-aurora/gin-vue-admin/server/model/quota/request"
+	"gorm.io/gorm"
+)
+
+type ResourceQuotaService struct{}
+
+// SetResourceQuota 设置用户或角色的资源配额，已存在则覆盖
+func (resourceQuotaService *ResourceQuotaService) SetResourceQuota(ctx context.Context, q *quotaModel.ResourceQuota) (err error) {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var existing quotaModel.ResourceQuota
+		findErr := tx.Where("subject_type = ? AND subject_id = ?", q.SubjectType, q.SubjectId).First(&existing).Error
+		if errors.Is(findErr, gorm.ErrRecordNotFound) {
+			return tx.Create(q).Error
+		}
+		if findErr != nil {
+			return findErr
+		}
+		q.ID = existing.ID
+		q.CreatedAt = existing.CreatedAt
+		return tx.Save(q).Error
+	})
+}
+
+// DeleteResourceQuota 删除资源配额
+func (resourceQuotaService *ResourceQuotaService) DeleteResourceQuota(ctx context.Context, ID string) (err error) {
+	// 物理删除，避免唯一索引阻止同一对象重新设置配额
+	return global.GVA_DB.Unscoped().Delete(&quotaModel.ResourceQuota{}, "id = ?", ID).Error
+}
+
+// GetResourceQuotaList 分页获取资源配额
+func (resourceQuotaService *ResourceQuotaService) GetResourceQuotaList(ctx context.Context, info quotaReq.ResourceQuotaSearch) (list []quotaModel.ResourceQuota, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&quotaModel.ResourceQuota{})
+	if info.SubjectType != nil && *info.SubjectType != "" {
+		db = db.Where("subject_type = ?", *info.SubjectType)
+	}
+	if info.SubjectId != nil {
+		db = db.Where("subject_id = ?", *info.SubjectId)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("subject_type, subject_id").Find(&list).Error
+	return
+}
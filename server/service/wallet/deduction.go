@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	instanceModel "github.com/flipped-aurora/gin-vue-admin/server/model/instance"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/product"
+	walletModel "github.com/flipped-aurora/gin-vue-admin/server/model/wallet"
+	instanceSvc "github.com/flipped-aurora/gin-vue-admin/server/service/instance"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RunBillingCycle 周期扣费：按计量流水扣除各用户自上次扣费以来的费用，并处理欠费实例
+func RunBillingCycle(ctx context.Context) {
+	cfg := global.GVA_CONFIG.Billing
+	if !cfg.Enabled {
+		return
+	}
+	interval := time.Duration(cfg.DeductIntervalMin) * time.Minute
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	// 有运行中实例但尚未开户的用户先开户，扣费从开户时开始
+	var runningUsers []uint
+	if err := global.GVA_DB.Model(&instanceModel.Instance{}).
+		Where("container_status = ? AND user_id IS NOT NULL", "running").
+		Distinct().Pluck("user_id", &runningUsers).Error; err != nil {
+		global.GVA_LOG.Error("查询运行中实例的用户失败", zap.Error(err))
+		return
+	}
+	for _, uid := range runningUsers {
+		if err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+			_, err := lockWallet(tx, uid)
+			return err
+		}); err != nil {
+			global.GVA_LOG.Warn("创建用户钱包失败", zap.Uint("userId", uid), zap.Error(err))
+		}
+	}
+
+	var wallets []walletModel.Wallet
+	if err := global.GVA_DB.Find(&wallets).Error; err != nil {
+		global.GVA_LOG.Error("查询用户钱包失败", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	for i := range wallets {
+		w := &wallets[i]
+		if now.Sub(w.BilledUntil) >= interval {
+			if err := deductWallet(w, now); err != nil {
+				global.GVA_LOG.Error("周期扣费失败", zap.Uint("userId", w.UserId), zap.Error(err))
+				continue
+			}
+		}
+		handleArrears(ctx, w, now)
+	}
+}
+
+// deductWallet 扣除 [BilledUntil, now) 内的费用并推进扣费截止时间
+func deductWallet(w *walletModel.Wallet, now time.Time) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockWallet(tx, w.UserId)
+		if err != nil {
+			return err
+		}
+		from := locked.BilledUntil
+		if !now.After(from) {
+			*w = locked
+			return nil
+		}
+		cost, err := instanceSvc.ComputeUsageCost(locked.UserId, from, now)
+		if err != nil {
+			return fmt.Errorf("计算费用失败: %v", err)
+		}
+		locked.BilledUntil = now
+		if cost > 0 {
+			err = changeBalance(tx, &locked, walletModel.TransactionDeduct, cost, 0, "实例运行费用", &from, &now)
+		} else {
+			err = tx.Model(&walletModel.Wallet{}).Where("id = ?", locked.ID).Update("billed_until", now).Error
+		}
+		if err != nil {
+			return err
+		}
+		*w = locked
+		return nil
+	})
+}
+
+// inArrears 只有余额耗尽且仍有计费中的实例时才算欠费，免费规格的实例不受余额影响
+func inArrears(balance float64, pricedRunning int) bool {
+	return pricedRunning > 0 && balance <= 0
+}
+
+// handleArrears 余额耗尽时先发送预警邮件，宽限期过后停止（不删除）该用户运行中的计费实例
+func handleArrears(ctx context.Context, w *walletModel.Wallet, now time.Time) {
+	var running []instanceModel.Instance
+	if w.Balance <= 0 {
+		// 规格可能已下架，按 Unscoped 读取价格，与计量流水一致
+		priced := global.GVA_DB.Unscoped().Model(&product.ProductSpec{}).Select("id").Where("price_per_hour > 0")
+		if err := global.GVA_DB.Where("user_id = ? AND container_status = ? AND spec_id IN (?)", w.UserId, "running", priced).
+			Find(&running).Error; err != nil {
+			global.GVA_LOG.Error("查询欠费用户的运行中实例失败", zap.Uint("userId", w.UserId), zap.Error(err))
+			return
+		}
+	}
+	if !inArrears(w.Balance, len(running)) {
+		if w.ArrearsSince != nil {
+			global.GVA_DB.Model(&walletModel.Wallet{}).Where("id = ?", w.ID).Update("arrears_since", nil)
+		}
+		return
+	}
+
+	grace := time.Duration(global.GVA_CONFIG.Billing.GracePeriodMin) * time.Minute
+	if w.ArrearsSince == nil {
+		if err := global.GVA_DB.Model(&walletModel.Wallet{}).Where("id = ?", w.ID).Update("arrears_since", now).Error; err != nil {
+			global.GVA_LOG.Error("记录欠费时间失败", zap.Uint("userId", w.UserId), zap.Error(err))
+			return
+		}
+		w.ArrearsSince = &now
+		instanceSvc.NotifyUser(w.UserId, "余额不足提醒",
+			fmt.Sprintf("您的账户余额为 %.2f，已无法支付运行中的 %d 个实例。请在 %d 分钟内充值，否则实例将被停止（数据不会删除）。",
+				w.Balance, len(running), int(grace.Minutes())))
+		global.GVA_LOG.Warn("用户余额耗尽，进入宽限期", zap.Uint("userId", w.UserId), zap.Float64("balance", w.Balance))
+		return
+	}
+	if now.Sub(*w.ArrearsSince) < grace {
+		return
+	}
+
+	instanceService := instanceSvc.InstanceService{}
+	stopped := 0
+	for _, inst := range running {
+		if err := instanceService.StopContainer(ctx, strconv.FormatUint(uint64(inst.ID), 10)); err != nil {
+			global.GVA_LOG.Error("欠费停止实例失败", zap.Uint("userId", w.UserId), zap.Uint("实例ID", inst.ID), zap.Error(err))
+			continue
+		}
+		stopped++
+	}
+	global.GVA_LOG.Warn("欠费宽限期已过，已停止用户实例", zap.Uint("userId", w.UserId), zap.Int("stopped", stopped))
+	if stopped > 0 {
+		instanceSvc.NotifyUser(w.UserId, "实例已因欠费停止",
+			fmt.Sprintf("您的账户余额为 %.2f，宽限期已过，已停止 %d 个实例。充值后可重新启动实例。", w.Balance, stopped))
+	}
+}
@@ -0,0 +1,25 @@
+package wallet
+
+import "testing"
+
+func TestInArrears(t *testing.T) {
+	tests := []struct {
+		name          string
+		balance       float64
+		pricedRunning int
+		want          bool
+	}{
+		{"余额充足", 10, 2, false},
+		{"余额为0且有计费实例", 0, 1, true},
+		{"余额为负且有计费实例", -3.5, 1, true},
+		{"余额为0但实例均免费", 0, 0, false},
+		{"余额为负但实例均免费", -1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inArrears(tt.balance, tt.pricedRunning); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
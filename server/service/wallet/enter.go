@@ -0,0 +1,3 @@
+package wallet
+
+type ServiceGroup struct{ WalletService }
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	walletModel "github.com/flipped-aurora/gin-vue-admin/server/model/wallet"
+	walletReq "github.com/flipped-aurora/gin-vue-admin/server/model/wallet/request"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WalletService struct{}
+
+// lockWallet 加行锁读取用户钱包，不存在时创建（扣费起点为创建时间）
+func lockWallet(tx *gorm.DB, userID uint) (w walletModel.Wallet, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&w).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w = walletModel.Wallet{UserId: userID, BilledUntil: time.Now()}
+		err = tx.Create(&w).Error
+	}
+	return
+}
+
+// changeBalance 在事务内变更余额并写入流水；amount 为正数，扣费时余额允许为负（欠费）
+func changeBalance(tx *gorm.DB, w *walletModel.Wallet, txType string, amount float64, operatorID uint, remark string, periodStart, periodEnd *time.Time) error {
+	switch txType {
+	case walletModel.TransactionRecharge:
+		w.Balance += amount
+	case walletModel.TransactionDeduct:
+		w.Balance -= amount
+	default:
+		return fmt.Errorf("未知的流水类型: %s", txType)
+	}
+	if w.Balance > 0 {
+		w.ArrearsSince = nil
+	}
+	if err := tx.Save(w).Error; err != nil {
+		return err
+	}
+	return tx.Create(&walletModel.WalletTransaction{
+		UserId:       w.UserId,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: w.Balance,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		OperatorId:   operatorID,
+		Remark:       remark,
+	}).Error
+}
+
+// Recharge 管理员为用户充值
+func (walletService *WalletService) Recharge(ctx context.Context, req walletReq.WalletChangeReq, operatorID uint) (w walletModel.Wallet, err error) {
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		if w, txErr = lockWallet(tx, req.UserId); txErr != nil {
+			return txErr
+		}
+		return changeBalance(tx, &w, walletModel.TransactionRecharge, req.Amount, operatorID, req.Remark, nil, nil)
+	})
+	return
+}
+
+// Deduct 管理员手工扣减余额
+func (walletService *WalletService) Deduct(ctx context.Context, req walletReq.WalletChangeReq, operatorID uint) (w walletModel.Wallet, err error) {
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		if w, txErr = lockWallet(tx, req.UserId); txErr != nil {
+			return txErr
+		}
+		return changeBalance(tx, &w, walletModel.TransactionDeduct, req.Amount, operatorID, req.Remark, nil, nil)
+	})
+	return
+}
+
+// GetWallet 获取用户钱包，尚未开户时返回零余额
+func (walletService *WalletService) GetWallet(ctx context.Context, userID uint) (w walletModel.Wallet, err error) {
+	err = global.GVA_DB.Where("user_id = ?", userID).First(&w).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return walletModel.Wallet{UserId: userID}, nil
+	}
+	return
+}
+
+// GetWalletTransactionList 分页获取钱包流水；普通用户只能查看自己的流水
+func (walletService *WalletService) GetWalletTransactionList(ctx context.Context, info walletReq.WalletTransactionSearch, userID uint, isAdmin bool) (list []walletModel.WalletTransaction, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&walletModel.WalletTransaction{})
+	if !isAdmin {
+		db = db.Where("user_id = ?", userID)
+	} else if info.UserId != nil {
+		db = db.Where("user_id = ?", *info.UserId)
+	}
+	if info.Type != nil && *info.Type != "" {
+		db = db.Where("type = ?", *info.Type)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id desc").Find(&list).Error
+	return
+}
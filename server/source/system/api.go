@@ -210,6 +210,15 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "算力节点", Method: "PUT", Path: "/computeNode/updateComputeNode", Description: "更新算力节点"},
 		{ApiGroup: "算力节点", Method: "GET", Path: "/computeNode/findComputeNode", Description: "根据ID获取算力节点"},
 		{ApiGroup: "算力节点", Method: "GET", Path: "/computeNode/getComputeNodeList", Description: "获取算力节点列表"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/prePullImage", Description: "预拉取镜像到节点"},
+		{ApiGroup: "算力节点", Method: "GET", Path: "/computeNode/getNodeImageList", Description: "获取节点镜像缓存清单"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/cordonNode", Description: "隔离节点"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/uncordonNode", Description: "解除节点隔离"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/drainNode", Description: "排空节点"},
+		{ApiGroup: "算力节点", Method: "GET", Path: "/computeNode/getNodeMaintenance", Description: "查询节点维护状态"},
+		{ApiGroup: "算力节点", Method: "GET", Path: "/computeNode/getNodeInventory", Description: "查询节点硬件清单"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/refreshNodeInventory", Description: "立即采集节点硬件信息"},
+		{ApiGroup: "算力节点", Method: "POST", Path: "/computeNode/reconcileNodes", Description: "对账节点孤儿容器与数据卷"},
 
 		{ApiGroup: "镜像库", Method: "POST", Path: "/imageRegistry/createImageRegistry", Description: "新增镜像库"},
 		{ApiGroup: "镜像库", Method: "DELETE", Path: "/imageRegistry/deleteImageRegistry", Description: "删除镜像库"},
@@ -233,11 +242,53 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "实例管理", Method: "GET", Path: "/instance/getInstanceList", Description: "获取实例管理列表"},
 
 		{ApiGroup: "instance", Method: "GET", Path: "/instance/getAvailableNodes", Description: "根据产品规格获取可用算力节点"},
+		{ApiGroup: "instance", Method: "POST", Path: "/instance/simulateSchedule", Description: "调度模拟（不写库）"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getSchedulingLogList", Description: "查询调度决策记录"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getSchedulerBreaker", Description: "查询调度熔断状态"},
 		{ApiGroup: "instance", Method: "GET", Path: "/instance/getContainerLogs", Description: "获取容器日志"},
 		{ApiGroup: "instance", Method: "POST", Path: "/instance/restartContainer", Description: "重启容器"},
 		{ApiGroup: "instance", Method: "POST", Path: "/instance/startContainer", Description: "启动容器"},
 		{ApiGroup: "instance", Method: "POST", Path: "/instance/stopContainer", Description: "停止容器"},
 		{ApiGroup: "instance", Method: "GET", Path: "/instance/terminal", Description: "容器终端WebSocket"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getProvisionProgress", Description: "查询实例创建进度"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getUsageReport", Description: "获取月度用量报表"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/exportUsageReport", Description: "导出月度用量报表"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getInstanceMetrics", Description: "查询实例监控历史"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getNodeMetrics", Description: "查询节点监控历史"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getNodeGpuTelemetry", Description: "查询节点显卡最新遥测"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getNodeGpuHistory", Description: "查询节点显卡遥测历史"},
+		{ApiGroup: "instance", Method: "GET", Path: "/instance/getInstanceGpuTelemetry", Description: "查询实例显卡遥测"},
+		{ApiGroup: "instance", Method: "POST", Path: "/instance/saveInstanceImage", Description: "实例保存为镜像"},
+		{ApiGroup: "instance", Method: "POST", Path: "/instance/resizeInstance", Description: "实例变更规格"},
+		{ApiGroup: "instance", Method: "POST", Path: "/instance/setIdleStopExempt", Description: "设置空闲自动停止豁免"},
+		{ApiGroup: "instance", Method: "POST", Path: "/instance/migrateInstance", Description: "实例迁移到其他节点"},
+
+		{ApiGroup: "用户钱包", Method: "POST", Path: "/wallet/recharge", Description: "充值"},
+		{ApiGroup: "用户钱包", Method: "POST", Path: "/wallet/deduct", Description: "手工扣减余额"},
+		{ApiGroup: "用户钱包", Method: "GET", Path: "/wallet/getWallet", Description: "获取钱包余额"},
+		{ApiGroup: "用户钱包", Method: "GET", Path: "/wallet/getWalletTransactionList", Description: "获取钱包流水"},
+
+		{ApiGroup: "资源配额", Method: "POST", Path: "/quota/setResourceQuota", Description: "设置资源配额"},
+		{ApiGroup: "资源配额", Method: "DELETE", Path: "/quota/deleteResourceQuota", Description: "删除资源配额"},
+		{ApiGroup: "资源配额", Method: "GET", Path: "/quota/getResourceQuotaList", Description: "获取资源配额列表"},
+		{ApiGroup: "资源配额", Method: "GET", Path: "/quota/getQuotaUsage", Description: "获取配额与当前用量"},
+		{ApiGroup: "空闲停止策略", Method: "POST", Path: "/idlePolicy/setIdlePolicy", Description: "设置空闲停止策略"},
+		{ApiGroup: "空闲停止策略", Method: "DELETE", Path: "/idlePolicy/deleteIdlePolicy", Description: "删除空闲停止策略"},
+		{ApiGroup: "空闲停止策略", Method: "GET", Path: "/idlePolicy/getIdlePolicyList", Description: "获取空闲停止策略列表"},
+		{ApiGroup: "空闲停止策略", Method: "GET", Path: "/idlePolicy/getIdleStopRecordList", Description: "获取空闲停止审计记录"},
+		{ApiGroup: "告警", Method: "POST", Path: "/alert/createAlertRule", Description: "新建告警规则"},
+		{ApiGroup: "告警", Method: "PUT", Path: "/alert/updateAlertRule", Description: "更新告警规则"},
+		{ApiGroup: "告警", Method: "DELETE", Path: "/alert/deleteAlertRule", Description: "删除告警规则"},
+		{ApiGroup: "告警", Method: "GET", Path: "/alert/getAlertRuleList", Description: "获取告警规则列表"},
+		{ApiGroup: "告警", Method: "POST", Path: "/alert/createAlertChannel", Description: "新建通知渠道"},
+		{ApiGroup: "告警", Method: "PUT", Path: "/alert/updateAlertChannel", Description: "更新通知渠道"},
+		{ApiGroup: "告警", Method: "DELETE", Path: "/alert/deleteAlertChannel", Description: "删除通知渠道"},
+		{ApiGroup: "告警", Method: "POST", Path: "/alert/testAlertChannel", Description: "发送测试通知"},
+		{ApiGroup: "告警", Method: "GET", Path: "/alert/getAlertChannelList", Description: "获取通知渠道列表"},
+		{ApiGroup: "告警", Method: "POST", Path: "/alert/createAlertSilence", Description: "新建告警静默"},
+		{ApiGroup: "告警", Method: "DELETE", Path: "/alert/deleteAlertSilence", Description: "删除告警静默"},
+		{ApiGroup: "告警", Method: "GET", Path: "/alert/getAlertSilenceList", Description: "获取告警静默列表"},
+		{ApiGroup: "告警", Method: "GET", Path: "/alert/getAlertEventList", Description: "获取告警历史"},
 
 		// 端口转发API
 		{ApiGroup: "端口转发", Method: "POST", Path: "/portForward/createPortForward", Description: "创建端口转发规则"},
@@ -213,12 +213,56 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/instance/findInstance", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/instance/getInstanceList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/instance/getAvailableNodes", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/simulateSchedule", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/instance/getSchedulingLogList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getSchedulerBreaker", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/instance/startContainer", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/instance/stopContainer", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/instance/restartContainer", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/instance/getContainerLogs", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/instance/getContainerStats", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/instance/terminal", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getProvisionProgress", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getUsageReport", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/exportUsageReport", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getInstanceMetrics", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getNodeMetrics", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getNodeGpuTelemetry", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getNodeGpuHistory", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/getInstanceGpuTelemetry", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/instance/saveInstanceImage", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/instance/resizeInstance", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/instance/setIdleStopExempt", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/instance/migrateInstance", V2: "POST"},
+
+		// 用户钱包相关权限
+		{Ptype: "p", V0: "888", V1: "/wallet/recharge", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/wallet/deduct", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/wallet/getWallet", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/wallet/getWalletTransactionList", V2: "GET"},
+
+		// 资源配额相关权限
+		{Ptype: "p", V0: "888", V1: "/quota/setResourceQuota", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/quota/deleteResourceQuota", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/quota/getResourceQuotaList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/quota/getQuotaUsage", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/idlePolicy/setIdlePolicy", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/idlePolicy/deleteIdlePolicy", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/idlePolicy/getIdlePolicyList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/idlePolicy/getIdleStopRecordList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/alert/createAlertRule", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/alert/updateAlertRule", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/alert/deleteAlertRule", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/alert/getAlertRuleList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/alert/createAlertChannel", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/alert/updateAlertChannel", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/alert/deleteAlertChannel", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/alert/testAlertChannel", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/alert/getAlertChannelList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/alert/createAlertSilence", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/alert/deleteAlertSilence", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/alert/getAlertSilenceList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/alert/getAlertEventList", V2: "GET"},
 
 		// 算力节点相关权限
 		{Ptype: "p", V0: "888", V1: "/computeNode/createComputeNode", V2: "POST"},
@@ -227,6 +271,15 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/computeNode/updateComputeNode", V2: "PUT"},
 		{Ptype: "p", V0: "888", V1: "/computeNode/findComputeNode", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/computeNode/getComputeNodeList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/prePullImage", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/getNodeImageList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/cordonNode", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/uncordonNode", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/drainNode", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/getNodeMaintenance", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/getNodeInventory", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/refreshNodeInventory", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/computeNode/reconcileNodes", V2: "POST"},
 
 		// 镜像库相关权限
 		{Ptype: "p", V0: "888", V1: "/imageRegistry/createImageRegistry", V2: "POST"},
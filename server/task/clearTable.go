@@ -30,6 +30,42 @@ func ClearTable(db *gorm.DB) error {
 		Interval:     "168h",
 	})
 
+	// 调度决策记录每次查询可用节点都会写入，保留30天
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "instance_scheduling_log",
+		CompareField: "created_at",
+		Interval:     "720h",
+	})
+
+	// 监控历史按精度分级保留：原始采样1天，5分钟汇总30天，1小时汇总1年
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "instance_metric_raw",
+		CompareField: "bucket_at",
+		Interval:     "24h",
+	})
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "instance_metric_5m",
+		CompareField: "bucket_at",
+		Interval:     "720h",
+	})
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "instance_metric_1h",
+		CompareField: "bucket_at",
+		Interval:     "8760h",
+	})
+	// 显卡遥测历史保留7天
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "compute_node_gpu_sample",
+		CompareField: "collected_at",
+		Interval:     "168h",
+	})
+	// 已恢复的告警历史保留90天
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "alert_event",
+		CompareField: "ends_at",
+		Interval:     "2160h",
+	})
+
 	if db == nil {
 		return errors.New("db Cannot be empty")
 	}
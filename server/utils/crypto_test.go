@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func TestAesGcmEncryptDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{name: "empty", plaintext: ""},
+		{name: "ascii", plaintext: "harbor-robot-token"},
+		{name: "unicode", plaintext: "镜像仓库密码"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := AesGcmEncrypt(key, tt.plaintext)
+			if err != nil {
+				t.Fatalf("AesGcmEncrypt() error = %v", err)
+			}
+			if tt.plaintext != "" && ciphertext == tt.plaintext {
+				t.Errorf("AesGcmEncrypt() returned plaintext")
+			}
+			got, err := AesGcmDecrypt(key, ciphertext)
+			if err != nil {
+				t.Fatalf("AesGcmDecrypt() error = %v", err)
+			}
+			if got != tt.plaintext {
+				t.Errorf("AesGcmDecrypt() got = %v, want %v", got, tt.plaintext)
+			}
+		})
+	}
+
+	ciphertext, _ := AesGcmEncrypt(key, "secret")
+	if _, err := AesGcmDecrypt([]byte("fedcba9876543210fedcba9876543210"), ciphertext); err == nil {
+		t.Errorf("AesGcmDecrypt() with wrong key should fail")
+	}
+}